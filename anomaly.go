@@ -0,0 +1,136 @@
+package circuit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// AnomalyConfig is the configuration of NewAnomalyPolicy, the zero values take the defaults.
+type AnomalyConfig struct {
+	Deviations  float64 // # of standard deviations above the baseline to open at, 3 by default
+	Alpha       float64 // weight of the latest interval in the baseline, in (0, 1], 0.1 by default
+	MinRequests uint32  // # of requests in an interval to learn from it and judge it, 1 by default
+	Warmup      int     // # of intervals to learn from before opening, 10 by default
+}
+
+// AnomalyPolicy is a TripPolicy learning the baseline failure rate and latency
+// of the intervals and opening the circuit breaker once the current ones deviate
+// from it by a number of the standard deviations, see NewAnomalyPolicy.
+type AnomalyPolicy struct {
+	cfg AnomalyConfig
+
+	mu      sync.Mutex
+	rate    baseline // failure rate of the intervals
+	latency baseline // P99 of the latency of the intervals in seconds, if tracked
+	samples int      // # of the intervals learned from
+	last    Counts   // counts of the current interval last seen
+}
+
+// baseline is an exponentially weighted mean and variance.
+type baseline struct {
+	mean     float64
+	variance float64
+}
+
+func (b *baseline) add(x float64, alpha float64, first bool) {
+	if first {
+		b.mean, b.variance = x, 0
+		return
+	}
+	d := x - b.mean
+	b.mean += alpha * d
+	b.variance = (1 - alpha) * (b.variance + alpha*d*d)
+}
+
+// NewAnomalyPolicy returns a policy for WithTripPolicy opening the circuit breaker
+// when the failure rate of the interval, or the P99 of the latency tracked with WithLatencyPercentile,
+// exceeds its baseline by a number of the standard deviations, for the dependencies
+// whose normal failure rate isn't zero:
+//
+//	circuit.WithTripPolicy(circuit.NewAnomalyPolicy(circuit.AnomalyConfig{Deviations: 4, MinRequests: 100}))
+//
+// The baseline is learned from the counts of every closed interval which hasn't opened
+// the circuit breaker, the policy receives them as an Observer. It's decided on every failure
+// once the warmup intervals have been learned, never before.
+// The policy keeps its own state, so it must not be shared between circuit breakers.
+func NewAnomalyPolicy(cfg AnomalyConfig) *AnomalyPolicy {
+	if cfg.Deviations <= 0 {
+		cfg.Deviations = 3
+	}
+	if cfg.Alpha <= 0 || cfg.Alpha > 1 {
+		cfg.Alpha = 0.1
+	}
+	if cfg.MinRequests == 0 {
+		cfg.MinRequests = 1
+	}
+	if cfg.Warmup <= 0 {
+		cfg.Warmup = 10
+	}
+	return &AnomalyPolicy{cfg: cfg}
+}
+
+// Baseline returns the learned mean failure rate and P99 of the latency,
+// and the number of the intervals learned from.
+func (p *AnomalyPolicy) Baseline() (rate float64, p99 time.Duration, samples int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rate.mean, time.Duration(p.latency.mean * float64(time.Second)), p.samples
+}
+
+// ShouldTrip implements TripPolicy.
+func (p *AnomalyPolicy) ShouldTrip(s Snapshot) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.samples < p.cfg.Warmup || s.Total < p.cfg.MinRequests {
+		return false
+	}
+
+	// the deviation of the interval's own sampling is added, a zero baseline doesn't open on a single failure
+	rate := float64(s.Failures) / float64(s.Total)
+	noise := p.rate.mean * (1 - p.rate.mean) / float64(s.Total)
+	if rate > p.rate.mean+p.cfg.Deviations*math.Sqrt(p.rate.variance+noise) {
+		return true
+	}
+
+	if s.Latency.P99 > 0 && p.latency.mean > 0 {
+		latency := s.Latency.P99.Seconds()
+		return latency > p.latency.mean+p.cfg.Deviations*math.Sqrt(p.latency.variance)
+	}
+	return false
+}
+
+// Observe implements Observer, it learns from the finished intervals.
+func (p *AnomalyPolicy) Observe(e Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e.Type == EventStateChanged {
+		// the interval opening the circuit breaker is no baseline, nor the other periods
+		p.last = Counts{}
+		return
+	}
+	if e.Counts.State != StateClosed {
+		return
+	}
+
+	if !p.last.WindowStart.IsZero() && !e.Counts.WindowStart.Equal(p.last.WindowStart) {
+		p.learn(p.last)
+	}
+	p.last = e.Snapshot()
+}
+
+// learn adds a finished interval to the baseline.
+func (p *AnomalyPolicy) learn(c Counts) {
+	if c.Total < p.cfg.MinRequests {
+		return
+	}
+
+	first := p.samples == 0
+	p.rate.add(float64(c.Failures)/float64(c.Total), p.cfg.Alpha, first)
+	if c.Latency.P99 > 0 {
+		p.latency.add(c.Latency.P99.Seconds(), p.cfg.Alpha, p.latency.mean == 0)
+	}
+	p.samples++
+}
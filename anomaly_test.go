@@ -0,0 +1,79 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnomalyPolicy_ShouldTrip(t *testing.T) {
+	p := NewAnomalyPolicy(AnomalyConfig{Warmup: 2, MinRequests: 10})
+	interval := func(sec int64, total, failures uint32, p99 time.Duration) {
+		p.Observe(Event{Type: EventRequestAllowed, Counts: Counts{State: StateClosed, WindowStart: time.Unix(sec, 0),
+			Total: total, Failures: failures, Latency: Latency{P99: p99}}})
+	}
+
+	interval(1520100000, 100, 10, time.Second)
+	interval(1520100010, 5, 5, time.Second) // too few requests to learn from
+	interval(1520100020, 100, 10, time.Second)
+	assert.False(t, p.ShouldTrip(Snapshot{Total: 100, Failures: 100}), "warming up")
+
+	interval(1520100030, 0, 0, 0)
+	rate, p99, samples := p.Baseline()
+	assert.InDelta(t, 0.1, rate, 1e-9)
+	assert.Equal(t, time.Second, p99)
+	assert.Equal(t, 2, samples)
+
+	assert.False(t, p.ShouldTrip(Snapshot{Total: 100, Failures: 15}))
+	assert.True(t, p.ShouldTrip(Snapshot{Total: 100, Failures: 20}))
+	assert.False(t, p.ShouldTrip(Snapshot{Total: 5, Failures: 5}), "too few requests")
+	assert.True(t, p.ShouldTrip(Snapshot{Total: 100, Failures: 10, Latency: Latency{P99: 2 * time.Second}}))
+}
+
+func TestAnomalyPolicy_Observe_StateChanged(t *testing.T) {
+	p := NewAnomalyPolicy(AnomalyConfig{Warmup: 1})
+	p.Observe(Event{Type: EventRequestAllowed, Counts: Counts{State: StateClosed, WindowStart: time.Unix(1520100000, 0), Total: 10, Failures: 9}})
+	p.Observe(Event{Type: EventStateChanged, From: StateClosed, State: StateOpen})
+	p.Observe(Event{Type: EventRequestAllowed, Counts: Counts{State: StateClosed, WindowStart: time.Unix(1520100060, 0), Total: 1}})
+
+	_, _, samples := p.Baseline()
+	assert.Equal(t, 0, samples, "the interval opening the circuit breaker isn't learned")
+}
+
+func TestBreaker_WithTripPolicy_Anomaly(t *testing.T) {
+	p := NewAnomalyPolicy(AnomalyConfig{Warmup: 3, MinRequests: 10})
+	toOpen := func(total uint32, failures uint32) bool { panic("replaced by the policy") }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := withTimeNow(10*time.Second, time.Minute, 1, toOpen, toClosed, now(1520100000), WithTripPolicy(p))
+	assert.NoError(t, err)
+
+	// 10% of the requests fail normally
+	for i := int64(0); i < 4; i++ {
+		b.now = now(1520100000 + 11*i)
+		for j := 0; j < 20; j++ {
+			b.Execute(func() error {
+				if j%10 == 4 {
+					return errors.New("failed")
+				}
+				return nil
+			})
+		}
+	}
+	assert.Equal(t, closed, b.loadState())
+
+	b.now = now(1520100044)
+	for j := 0; j < 5; j++ {
+		b.Execute(func() error { return nil })
+	}
+	_, _, samples := p.Baseline()
+	assert.Equal(t, 4, samples)
+
+	for j := 0; j < 4; j++ {
+		b.Execute(func() error { return errors.New("failed") })
+	}
+	assert.Equal(t, closed, b.loadState())
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState())
+}
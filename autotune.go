@@ -0,0 +1,120 @@
+package circuit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// AutoTune is the configuration of WithAutoTune, the bounds the settings are tuned within.
+type AutoTune struct {
+	MinRate     float64       // lower bound of the failure rate threshold
+	MaxRate     float64       // upper bound of the failure rate threshold, 1 if zero
+	Headroom    float64       // threshold as a multiple of the usual failure rate, 3 by default
+	MinRequests uint32        // # of requests in an interval to learn from it and to open
+	Alpha       float64       // weight of the latest interval in the usual failure rate, in (0, 1], 0.1 by default
+	MinCooldown time.Duration // lower bound of the cooldown, it isn't tuned unless both bounds are set
+	MaxCooldown time.Duration // upper bound of the cooldown
+}
+
+// Tuning is the settings tuned by WithAutoTune.
+type Tuning struct {
+	Rate     float64       // failure rate threshold of toOpen, zero until tuned
+	Cooldown time.Duration // cooldown of the open state
+}
+
+// WithAutoTune makes the circuit breaker tune its failure rate threshold and cooldown
+// within the given bounds by the traffic seen, sparing the tuning of every circuit breaker by hand:
+//
+//	circuit.WithAutoTune(circuit.AutoTune{
+//		MinRate: 0.05, MaxRate: 0.5, MinRequests: 20,
+//		MinCooldown: time.Second, MaxCooldown: time.Minute,
+//	})
+//
+// The threshold is kept at Headroom times the usual failure rate of the closed intervals,
+// rounded up to a percent, and replaces toOpen with RateThreshold once the first interval is learned.
+// The cooldown doubles when the circuit breaker opens again from the half-open state
+// and halves when it recovers.
+//
+// EventTuned is emitted to the observers whenever the settings change.
+func WithAutoTune(t AutoTune) Option {
+	return func(b *Breaker) {
+		if t.MaxRate <= 0 || t.MaxRate > 1 {
+			t.MaxRate = 1
+		}
+		if t.Headroom <= 0 {
+			t.Headroom = 3
+		}
+		if t.Alpha <= 0 || t.Alpha > 1 {
+			t.Alpha = 0.1
+		}
+		b.tuner = &tuner{AutoTune: t}
+	}
+}
+
+// tuner keeps the state of WithAutoTune.
+type tuner struct {
+	AutoTune
+
+	mu      sync.Mutex
+	usual   float64 // exponentially weighted failure rate of the closed intervals
+	learned bool    // whether any interval was learned
+	rate    float64 // current threshold, zero until tuned
+}
+
+// tune adjusts the settings after a transition from a period with counts,
+// emitting EventTuned if they changed.
+func (b *Breaker) tune(from, to State, counts Counts, now int64) {
+	t := b.tuner
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	changed := false
+	switch {
+	case from == StateClosed && to == StateClosed:
+		changed = t.learn(counts)
+		if changed {
+			p := b.policies()
+			b.policy.Store(policy{toOpen: RateThreshold(t.rate, t.MinRequests), toClosed: p.toClosed})
+		}
+	case from == StateHalfOpen && t.MinCooldown > 0 && t.MaxCooldown > 0:
+		cooldown := time.Duration(b.cooldownNanos())
+		tuned := cooldown * 2
+		if to == StateClosed {
+			tuned = cooldown / 2
+		}
+		tuned = min(max(tuned, t.MinCooldown), t.MaxCooldown)
+		if tuned != cooldown {
+			b.SetCooldown(tuned)
+			changed = true
+		}
+	}
+
+	if changed && len(b.observers) > 0 {
+		tuning := Tuning{Rate: t.rate, Cooldown: time.Duration(b.cooldownNanos())}
+		b.emit(Event{Type: EventTuned, Time: time.Unix(0, now), Name: b.name, State: to, Counts: counts, Tuning: tuning})
+	}
+}
+
+// learn adds a finished closed interval to the usual failure rate,
+// reports whether the threshold changed.
+func (t *tuner) learn(c Counts) bool {
+	if c.Total == 0 || c.Total < t.MinRequests {
+		return false
+	}
+
+	rate := float64(c.Failures) / float64(c.Total)
+	if t.learned {
+		t.usual += t.Alpha * (rate - t.usual)
+	} else {
+		t.usual, t.learned = rate, true
+	}
+
+	threshold := math.Ceil(t.usual*t.Headroom*100-1e-9) / 100
+	threshold = min(max(threshold, t.MinRate), t.MaxRate)
+	if threshold == t.rate {
+		return false
+	}
+	t.rate = threshold
+	return true
+}
@@ -0,0 +1,77 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithAutoTune(t *testing.T) {
+	var tunings []Tuning
+	observer := ObserverFunc(func(e Event) {
+		if e.Type == EventTuned {
+			tunings = append(tunings, e.Tuning)
+		}
+	})
+	toOpen := RateThreshold(0.9, 10)
+	toClosed := func(total uint32, failures uint32) bool { return failures == 0 }
+	tune := AutoTune{MinRate: 0.05, MaxRate: 0.5, MinRequests: 10, MinCooldown: 5 * time.Second, MaxCooldown: 40 * time.Second}
+	b, err := withTimeNow(10*time.Second, 10*time.Second, 1, toOpen, toClosed, now(1520100000), WithAutoTune(tune), WithObserver(observer))
+	assert.NoError(t, err)
+
+	// 10% of the requests fail usually
+	for i := 0; i < 20; i++ {
+		b.Execute(func() error {
+			if i%10 == 0 {
+				return errors.New("failed")
+			}
+			return nil
+		})
+	}
+
+	b.now = now(1520100011)
+	for i := 0; i < 7; i++ {
+		b.Execute(func() error { return nil })
+	}
+	assert.Equal(t, []Tuning{{Rate: 0.3, Cooldown: 10 * time.Second}}, tunings)
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, closed, b.loadState())
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState(), "opens at 30%")
+
+	b.now = now(1520100022)
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return nil })
+	assert.Equal(t, open, b.loadState())
+	assert.Equal(t, 20*time.Second, b.Config().Cooldown, "doubles when opens again")
+
+	b.now = now(1520100043)
+	b.Execute(func() error { return nil })
+	b.Execute(func() error { return nil })
+	assert.Equal(t, closed, b.loadState())
+	assert.Equal(t, 10*time.Second, b.Config().Cooldown, "halves when recovers")
+
+	assert.Equal(t, []Tuning{
+		{Rate: 0.3, Cooldown: 10 * time.Second},
+		{Rate: 0.3, Cooldown: 20 * time.Second},
+		{Rate: 0.3, Cooldown: 10 * time.Second},
+	}, tunings)
+}
+
+func TestBreaker_WithAutoTune_Bounds(t *testing.T) {
+	toOpen := RateThreshold(0.9, 10)
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := withTimeNow(10*time.Second, 10*time.Second, 1, toOpen, toClosed, now(1520100000),
+		WithAutoTune(AutoTune{MinRate: 0.05, MaxRate: 0.5}))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return nil })
+	b.now = now(1520100011)
+	b.Execute(func() error { return nil })
+	assert.Equal(t, 0.5, b.tuner.rate, "50% usually, within MaxRate")
+	assert.Equal(t, 10*time.Second, b.Config().Cooldown, "not tuned without the bounds")
+}
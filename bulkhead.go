@@ -0,0 +1,118 @@
+package circuit
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrBulkheadFull is returned from Bulkhead's Execute
+	// when the limit of the concurrent requests is reached.
+	ErrBulkheadFull = errors.New("circuit: bulkhead full")
+
+	// ErrQueueFull is returned from Bulkhead's Execute
+	// when the limit is reached and the queue is full, see WithQueue.
+	ErrQueueFull = errors.New("circuit: bulkhead queue full")
+
+	// ErrQueueTimeout is returned from Bulkhead's Execute
+	// when the request waited in the queue for too long, see WithQueue.
+	ErrQueueTimeout = errors.New("circuit: bulkhead queue timeout")
+)
+
+// BulkheadOption configures the optional behavior of the bulkhead.
+type BulkheadOption func(*Bulkhead)
+
+// WithQueue makes the requests above the limit wait for their turn
+// in a queue of a given size for at most maxWait instead of being rejected.
+//
+// Execute returns ErrQueueFull when the queue is full
+// and ErrQueueTimeout when the request waited for maxWait.
+func WithQueue(size int, maxWait time.Duration) BulkheadOption {
+	return func(bh *Bulkhead) {
+		bh.queue = make(chan struct{}, size)
+		bh.maxWait = maxWait
+	}
+}
+
+// Bulkhead limits the number of the requests running concurrently,
+// isolating a dependency so it can't take all the resources of the application.
+//
+// It's composed with a circuit breaker by running one in the other,
+// the requests rejected by the bulkhead are not counted by the circuit breaker:
+//
+//	err := bh.Execute(func() error {
+//		return b.Execute(req)
+//	})
+type Bulkhead struct {
+	sem     chan struct{} // a slot per running request
+	queue   chan struct{} // a slot per waiting request if queued
+	maxWait time.Duration // max duration of waiting in the queue
+}
+
+// NewBulkhead returns a new bulkhead running at most maxConcurrent requests at once.
+//
+// Options tune the optional behavior of the bulkhead.
+func NewBulkhead(maxConcurrent int, opts ...BulkheadOption) (*Bulkhead, error) {
+	if maxConcurrent <= 0 {
+		return nil, errors.New("circuit: maxConcurrent must be set")
+	}
+
+	bh := &Bulkhead{sem: make(chan struct{}, maxConcurrent)}
+	for _, opt := range opts {
+		opt(bh)
+	}
+	return bh, nil
+}
+
+// Execute runs a given request if the limit of the concurrent requests is not reached,
+// or once there is a room for it when it's queued.
+//
+// Returns ErrBulkheadFull when the limit is reached, ErrQueueFull or ErrQueueTimeout
+// when the request is queued, otherwise the error from the req function.
+func (bh *Bulkhead) Execute(req func() error) error {
+	if err := bh.acquire(); err != nil {
+		return err
+	}
+	defer func() { <-bh.sem }()
+
+	return req()
+}
+
+func (bh *Bulkhead) acquire() error {
+	select {
+	case bh.sem <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if bh.queue == nil {
+		return ErrBulkheadFull
+	}
+
+	select {
+	case bh.queue <- struct{}{}:
+	default:
+		return ErrQueueFull
+	}
+	defer func() { <-bh.queue }()
+
+	timer := time.NewTimer(bh.maxWait)
+	defer timer.Stop()
+
+	select {
+	case bh.sem <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrQueueTimeout
+	}
+}
+
+// InFlight returns the number of the requests running.
+func (bh *Bulkhead) InFlight() int {
+	return len(bh.sem)
+}
+
+// Queued returns the number of the requests waiting in the queue.
+func (bh *Bulkhead) Queued() int {
+	return len(bh.queue)
+}
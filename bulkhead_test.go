@@ -0,0 +1,86 @@
+package circuit
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBulkhead(t *testing.T) {
+	_, err := NewBulkhead(0)
+	assert.EqualError(t, err, "circuit: maxConcurrent must be set")
+
+	bh, err := NewBulkhead(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, bh.InFlight())
+}
+
+func TestBulkhead_Execute(t *testing.T) {
+	bh, err := NewBulkhead(2)
+	assert.NoError(t, err)
+
+	var started, done sync.WaitGroup
+	release := make(chan struct{})
+	started.Add(2)
+	done.Add(2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			bh.Execute(func() error {
+				started.Done()
+				<-release
+				return nil
+			})
+			done.Done()
+		}()
+	}
+	started.Wait()
+	assert.Equal(t, 2, bh.InFlight())
+
+	// rejected above the limit
+	assert.Equal(t, ErrBulkheadFull, bh.Execute(func() error { return nil }))
+
+	close(release)
+	done.Wait()
+	assert.Equal(t, 0, bh.InFlight())
+	assert.EqualError(t, bh.Execute(func() error { return errors.New("failed") }), "failed")
+
+	// released on a panic
+	assert.Panics(t, func() { bh.Execute(func() error { panic("boom") }) })
+	assert.Equal(t, 0, bh.InFlight())
+}
+
+func TestBulkhead_WithQueue(t *testing.T) {
+	bh, err := NewBulkhead(1, WithQueue(1, 50*time.Millisecond))
+	assert.NoError(t, err)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go bh.Execute(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	// waits in the queue for the running request
+	queued := make(chan error)
+	go func() { queued <- bh.Execute(func() error { return nil }) }()
+	assert.Eventually(t, func() bool { return bh.Queued() == 1 }, time.Second, time.Millisecond)
+
+	// the queue is full
+	assert.Equal(t, ErrQueueFull, bh.Execute(func() error { return nil }))
+
+	// timed out waiting
+	assert.Equal(t, ErrQueueTimeout, <-queued)
+	assert.Equal(t, 0, bh.Queued())
+
+	// runs once the running request returned
+	go func() { queued <- bh.Execute(func() error { return errors.New("failed") }) }()
+	assert.Eventually(t, func() bool { return bh.Queued() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	assert.EqualError(t, <-queued, "failed")
+	assert.Equal(t, 0, bh.InFlight())
+}
@@ -0,0 +1,31 @@
+package circuit
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type bypassKey struct{}
+
+// WithBypass returns a copy of ctx making ExecuteContext run the request
+// even when the circuit breaker doesn't accept it, e.g. for the health checks,
+// the admin probes or a break-glass operation which must reach the dependency.
+//
+// A bypassing request is counted neither as accepted nor as rejected, so it doesn't
+// move the circuit breaker, but in Counts().LifetimeBypasses and EventRequestBypassed.
+// It runs as any other request while the circuit breaker accepts them.
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// Bypassed reports whether ctx was made with WithBypass.
+func Bypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// bypass counts a request run bypassing the circuit breaker.
+func (b *Breaker) bypass(md *Metadata) {
+	atomic.AddUint64(&b.lifetimeBypasses, 1)
+	b.emitRequest(EventRequestBypassed, nil, md)
+}
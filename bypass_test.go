@@ -0,0 +1,47 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBypassed(t *testing.T) {
+	assert.False(t, Bypassed(context.Background()))
+	assert.True(t, Bypassed(WithBypass(context.Background())))
+}
+
+func TestBreaker_ExecuteContext_WithBypass(t *testing.T) {
+	var events []EventType
+	observer := ObserverFunc(func(e Event) { events = append(events, e.Type) })
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithObserver(observer))
+	assert.NoError(t, err)
+
+	ctx := WithBypass(context.Background())
+	err = b.ExecuteContext(ctx, func(context.Context) error { return errors.New("failed") })
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, open, b.loadState(), "counted while the circuit breaker accepts the requests")
+
+	events = nil
+	ran := false
+	err = b.ExecuteContext(ctx, func(context.Context) error {
+		ran = true
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed")
+	assert.True(t, ran)
+	assert.Equal(t, []EventType{EventRequestBypassed}, events)
+
+	c := b.Counts()
+	assert.Equal(t, uint32(0), c.Rejections)
+	assert.Equal(t, uint64(1), c.LifetimeBypasses)
+	assert.Equal(t, uint64(1), c.LifetimeFailures, "the outcome of a bypassing request isn't counted")
+
+	err = b.ExecuteContext(context.Background(), func(context.Context) error { return nil })
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+}
@@ -0,0 +1,73 @@
+package circuit
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Cache keeps the last good results of the requests by key
+// for DoCached to serve while the circuit breaker is open.
+type Cache[T any] struct {
+	ttl time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]cached[T]
+
+	now func() time.Time // time.Now
+}
+
+type cached[T any] struct {
+	v  T
+	at time.Time
+}
+
+// NewCache returns a new cache keeping the results for a given ttl.
+func NewCache[T any](ttl time.Duration) *Cache[T] {
+	return &Cache[T]{ttl: ttl, entries: make(map[string]cached[T]), now: time.Now}
+}
+
+// Get returns the result for a given key unless it's expired.
+func (c *Cache[T]) Get(key string) (T, bool) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || c.now().Sub(e.at) > c.ttl {
+		var zero T
+		return zero, false
+	}
+	return e.v, true
+}
+
+// Set stores the result for a given key.
+func (c *Cache[T]) Set(key string, v T) {
+	c.mu.Lock()
+	c.entries[key] = cached[T]{v: v, at: c.now()}
+	c.mu.Unlock()
+}
+
+// Delete removes the result for a given key.
+func (c *Cache[T]) Delete(key string) {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+}
+
+// DoCached runs a given request like Do does and stores its result in the cache
+// by key once it succeeded. When the circuit breaker doesn't accept the request
+// the last good result is returned instead of ErrBreakerOpen unless it's expired.
+func DoCached[T any](b *Breaker, c *Cache[T], key string, req func() (T, error)) (T, error) {
+	v, err := Do(b, req)
+	if errors.Is(err, ErrBreakerOpen) {
+		if cv, ok := c.Get(key); ok {
+			return cv, nil
+		}
+		return v, err
+	}
+
+	if err == nil {
+		c.Set(key, v)
+	}
+	return v, err
+}
@@ -0,0 +1,64 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	c := NewCache[string](time.Minute)
+	c.now = now(1520100000)
+
+	_, ok := c.Get("key")
+	assert.False(t, ok)
+
+	c.Set("key", "value")
+	v, ok := c.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, "value", v)
+
+	c.now = now(1520100061)
+	_, ok = c.Get("key")
+	assert.False(t, ok)
+
+	c.Set("key", "value")
+	c.Delete("key")
+	_, ok = c.Get("key")
+	assert.False(t, ok)
+}
+
+func TestDoCached(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	c := NewCache[int](time.Minute)
+	c.now = now(1520100000)
+
+	v, err := DoCached(b, c, "key", func() (int, error) { return 42, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v)
+
+	// a failure is returned as is
+	v, err = DoCached(b, c, "key", func() (int, error) { return 0, errors.New("failed") })
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, 0, v)
+	assert.Equal(t, StateOpen, b.State())
+
+	// the last good value while open
+	v, err = DoCached(b, c, "key", func() (int, error) { return 1, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v)
+
+	_, err = DoCached(b, c, "other", func() (int, error) { return 1, nil })
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+
+	// expired
+	c.now = now(1520100061)
+	_, err = DoCached(b, c, "key", func() (int, error) { return 1, nil })
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+}
@@ -0,0 +1,51 @@
+package circuit
+
+import "sync/atomic"
+
+// CategoryUnknown is the category of the failures without an error,
+// e.g. recorded with Token.Failure or classified by the result in Do.
+const CategoryUnknown = "unknown"
+
+// WithFailureCategories makes the circuit breaker count the failures
+// of the current period by the category of their errors returned by categorize,
+// e.g. "timeout", "5xx" or "connection-refused", exposed as Counts().FailuresByCategory,
+// knowing which ones fail changes how to respond.
+//
+// The categories should be a small fixed set, every one is kept once seen.
+func WithFailureCategories(categorize func(err error) string) Option {
+	return func(b *Breaker) {
+		b.categorize = categorize
+	}
+}
+
+// countCategory counts a failure with err, nil if unknown, by its category.
+func (b *Breaker) countCategory(err error) {
+	category := CategoryUnknown
+	if err != nil {
+		category = b.categorize(err)
+	}
+
+	n, ok := b.categories.Load(category)
+	if !ok {
+		n, _ = b.categories.LoadOrStore(category, new(uint32))
+	}
+	atomic.AddUint32(n.(*uint32), 1)
+}
+
+// failuresByCategory returns the failures of the current period by every category seen.
+func (b *Breaker) failuresByCategory() map[string]uint32 {
+	m := make(map[string]uint32)
+	b.categories.Range(func(category, n any) bool {
+		m[category.(string)] = atomic.LoadUint32(n.(*uint32))
+		return true
+	})
+	return m
+}
+
+// resetCategories zeroes the failures of every category seen.
+func (b *Breaker) resetCategories() {
+	b.categories.Range(func(_, n any) bool {
+		atomic.StoreUint32(n.(*uint32), 0)
+		return true
+	})
+}
@@ -0,0 +1,46 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithFailureCategories(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures >= 5 }
+	toClosed := func(total uint32, failures uint32) bool { return failures == 0 }
+	categorize := func(err error) string {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrExecutionTimeout) {
+			return "timeout"
+		}
+		return "error"
+	}
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithFailureCategories(categorize))
+	assert.NoError(t, err)
+	assert.Empty(t, b.Counts().FailuresByCategory)
+
+	b.Execute(func() error { return context.DeadlineExceeded })
+	b.Execute(func() error { return context.DeadlineExceeded })
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return nil })
+	tok, _ := b.Allow()
+	tok.Failure()
+	assert.Equal(t, map[string]uint32{"timeout": 2, "error": 1, CategoryUnknown: 1}, b.Counts().FailuresByCategory)
+
+	// reset on the transition, the seen categories are kept
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, map[string]uint32{"timeout": 0, "error": 0, CategoryUnknown: 0}, b.Counts().FailuresByCategory)
+}
+
+func TestBreaker_WithoutFailureCategories(t *testing.T) {
+	to := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, to, to, now(1520100000))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Nil(t, b.Counts().FailuresByCategory)
+}
@@ -0,0 +1,47 @@
+package circuit
+
+import "time"
+
+// Policy is a resilience policy running the requests,
+// e.g. *Breaker, *Bulkhead, RetryPolicy or Timeout.
+type Policy interface {
+	Execute(req func() error) error
+}
+
+// PolicyFunc is a function used as a Policy.
+type PolicyFunc func(req func() error) error
+
+// Execute calls f(req).
+func (f PolicyFunc) Execute(req func() error) error {
+	return f(req)
+}
+
+// Chain returns a policy running the requests through the given policies,
+// the first one is the outermost, e.g.
+//
+//	p := circuit.Chain(circuit.Timeout(time.Second), bulkhead, breaker, retry)
+//	err := p.Execute(req)
+func Chain(policies ...Policy) Policy {
+	return PolicyFunc(func(req func() error) error {
+		next := req
+		for i := len(policies) - 1; i >= 0; i-- {
+			p, inner := policies[i], next
+			next = func() error { return p.Execute(inner) }
+		}
+		return next()
+	})
+}
+
+// Timeout returns a policy which stops waiting for a request after a given duration
+// and returns ErrExecutionTimeout, the request keeps running in the background.
+func Timeout(d time.Duration) Policy {
+	return PolicyFunc(func(req func() error) error {
+		_, err := runTimed(time.After, d, func() (struct{}, error) {
+			return struct{}{}, req()
+		})
+		if pe, ok := err.(*PanicError); ok {
+			panic(pe.Value)
+		}
+		return err
+	})
+}
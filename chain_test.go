@@ -0,0 +1,52 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain(t *testing.T) {
+	var order []string
+	policy := func(name string) Policy {
+		return PolicyFunc(func(req func() error) error {
+			order = append(order, name)
+			return req()
+		})
+	}
+
+	err := Chain(policy("outer"), policy("inner")).Execute(func() error {
+		order = append(order, "req")
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, []string{"outer", "inner", "req"}, order)
+
+	assert.NoError(t, Chain().Execute(func() error { return nil }))
+}
+
+func TestChain_Policies(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+	bh, err := NewBulkhead(1)
+	assert.NoError(t, err)
+
+	p := Chain(Timeout(time.Second), RetryPolicy{MaxAttempts: 5}, bh, b)
+
+	// retried until the circuit breaker opened
+	var attempts int
+	err = p.Execute(func() error { attempts++; return errors.New("failed") })
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+	assert.Equal(t, 2, attempts)
+
+	// timed out
+	b.Reset()
+	release := make(chan struct{})
+	defer close(release)
+	err = Chain(Timeout(10*time.Millisecond), b).Execute(func() error { <-release; return nil })
+	assert.Equal(t, ErrExecutionTimeout, err)
+}
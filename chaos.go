@@ -0,0 +1,82 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// ErrChaos is the synthetic failure injected with WithChaos.
+var ErrChaos = errors.New("circuit: injected failure")
+
+// Chaos is the failure injection of the circuit breaker for the game days,
+// to verify the fallbacks of the application actually work.
+// The rates are the shares of the requests, from 0 to 1.
+type Chaos struct {
+	FailureRate float64       // rate of the accepted requests failing with ErrChaos without being run
+	LatencyRate float64       // rate of the accepted requests delayed by Latency before being run
+	Latency     time.Duration // latency added to the delayed requests
+	OpenRate    float64       // rate of the requests tripping the circuit breaker open, see Trip
+}
+
+// WithChaos injects the synthetic failures, latency and opens into the requests
+// at the given rates, it can be changed or turned off at runtime with SetChaos.
+//
+// The failures and latency are injected into the requests run by Execute, ExecuteContext and Do,
+// they are counted as the outcomes and duration of the requests, the opens on Allow as well.
+func WithChaos(c Chaos) Option {
+	return func(b *Breaker) {
+		b.SetChaos(c)
+	}
+}
+
+// SetChaos replaces the failure injection of the circuit breaker, see WithChaos.
+// The zero Chaos turns it off.
+func (b *Breaker) SetChaos(c Chaos) {
+	if c == (Chaos{}) {
+		b.chaos.Store(nil)
+		return
+	}
+	b.chaos.Store(&c)
+}
+
+// Chaos returns the current failure injection of the circuit breaker,
+// the zero Chaos if it's off.
+func (b *Breaker) Chaos() Chaos {
+	if c := b.chaos.Load(); c != nil {
+		return *c
+	}
+	return Chaos{}
+}
+
+// injectOpen trips the circuit breaker at the rate of the injected opens.
+func (b *Breaker) injectOpen() {
+	c := b.chaos.Load()
+	if c == nil || c.OpenRate <= 0 || rand.Float64() >= c.OpenRate {
+		return
+	}
+	b.Trip()
+}
+
+// inject delays an accepted request at the rate of the injected latency
+// and returns ErrChaos at the rate of the injected failures,
+// or the error of ctx done while it's delayed.
+func (b *Breaker) inject(ctx context.Context) error {
+	c := b.chaos.Load()
+	if c == nil {
+		return nil
+	}
+
+	if c.LatencyRate > 0 && rand.Float64() < c.LatencyRate {
+		select {
+		case <-b.after(c.Latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if c.FailureRate > 0 && rand.Float64() < c.FailureRate {
+		return ErrChaos
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package circuit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithChaos(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures >= 3 }
+	toClosed := func(total uint32, failures uint32) bool { return failures == 0 }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithChaos(Chaos{FailureRate: 1}))
+	assert.NoError(t, err)
+	assert.Equal(t, Chaos{FailureRate: 1}, b.Chaos())
+
+	// failed without being run, counted as failures
+	var runs int
+	req := func() error { runs++; return nil }
+	assert.ErrorIs(t, b.Execute(req), ErrChaos)
+	assert.ErrorIs(t, b.ExecuteContext(context.Background(), func(context.Context) error { return req() }), ErrChaos)
+	_, err = Do(b, func() (int, error) { return 1, req() })
+	assert.ErrorIs(t, err, ErrChaos)
+	assert.Zero(t, runs)
+	assert.Equal(t, StateOpen, b.State())
+
+	// turned off at runtime
+	b.Reset()
+	b.SetChaos(Chaos{})
+	assert.Equal(t, Chaos{}, b.Chaos())
+	assert.NoError(t, b.Execute(req))
+	assert.Equal(t, 1, runs)
+
+	// opened as by Trip, recovers as usual
+	b.SetChaos(Chaos{OpenRate: 1})
+	assert.ErrorIs(t, b.Execute(req), ErrBreakerOpen)
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, OverrideNone, b.Override())
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+	assert.Equal(t, 1, runs)
+}
+
+func TestBreaker_WithChaos_Latency(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return false }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	clock := &fakeClock{now: time.Unix(1520100000, 0)}
+	b, err := NewBreaker(time.Minute, 2*time.Minute, 1, toOpen, toClosed,
+		WithClock(clock), WithChaos(Chaos{LatencyRate: 1, Latency: time.Second}))
+	assert.NoError(t, err)
+
+	done := make(chan error)
+	go func() { done <- b.Execute(func() error { return nil }) }()
+	for clock.waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-done:
+		t.Fatal("returned before the latency")
+	default:
+	}
+	clock.Advance(time.Second)
+	assert.NoError(t, <-done)
+
+	// the delay is cut short by the context
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- b.ExecuteContext(ctx, func(context.Context) error { return nil }) }()
+	for clock.waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -1,7 +1,10 @@
 package circuit
 
 import (
+	"context"
 	"errors"
+	"log/slog"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -17,26 +20,115 @@ const (
 )
 
 // ErrBreakerOpen is returned from Execute when the breaker is not ready,
-// use it to distinguish from request's errors.
+// use it to distinguish from request's errors with errors.Is,
+// the error returned is an *OpenError carrying the details.
 var ErrBreakerOpen = errors.New("circuit: breaker open")
 
 // Breaker is a state machine to prevent an application
 // from repeatedly trying to execute an operation that's likely to fail.
 type Breaker struct {
-	state int32 // current state
-	until int64 // until timestamp of the interval (in closed state) or cooldown (in open state) period
+	word  uint64 // current state and generation of the period incremented on every reset of the counters, see pack
+	until int64  // until timestamp of the interval (in closed state) or cooldown (in open state) period
+
+	override int32 // manual override of the state, see Override
+	disabled int32 // 1 if the circuit breaker is a pass-through, see Disable
 
 	interval    int64  // the cyclic period of the closed state
 	cooldown    int64  // the period of the open state
+	opens       uint32 // # of times opened since the last closed state
 	atLeastReqs uint32 // # of requests in the half-open state
 
-	toOpenState   ToState // called on failure being in the closed state
-	toClosedState ToState // called after atLeastReqs being in the half-open state
-
-	total    uint32 // # of requests in total during the interval
-	failures uint32 // # of requests returned an error during the interval
-
-	now func() time.Time // time.Now
+	policy        atomic.Value  // policy, toOpen and toClosed swapped by UpdateConfig
+	tripPolicy    TripPolicy    // decides on opening instead of toOpen if set
+	recoverPolicy RecoverPolicy // decides on closing instead of toClosed if set
+
+	start      int64   // start timestamp of the current interval, cooldown or half-open period
+	window     window  // outcomes of the requests for toOpen instead of the interval counters if set
+	total      uint64  // # of requests in total during the interval, see counters
+	failures   uint64  // # of requests returned an error during the interval
+	successes  uint64  // # of requests succeeded during the interval
+	shards     []shard // stripes of the counters above and lifetimeTotal if set, see WithShardedCounters
+	rejections uint32  // # of requests rejected with ErrBreakerOpen during the interval
+
+	failureScore uint64             // sum of the weights of the failures during the interval, see WithFailureWeight
+	weight       func(error) uint32 // weight of the error of a failure if set
+
+	consecutive    uint32 // # of requests failed in a row, reset on a success or transition
+	maxConsecutive uint32 // # of requests failed in a row to open the circuit breaker if set
+	minVolume      uint32 // # of requests in the interval required to open the circuit breaker
+
+	multiWindow []thresholdWindow // sliding windows opening the circuit breaker by their failure rates if set
+
+	slowCalls     uint32  // # of requests slower than slowThreshold during the interval
+	slowThreshold int64   // duration of a request to count it as slow if set
+	slowRate      float64 // rate of the slow requests to open the circuit breaker
+
+	latencies       *histogram    // latency of the requests during the interval if tracked
+	latencyQuantile float64       // quantile of the latency to open the circuit breaker
+	latencyBound    time.Duration // bound of the quantile of the latency
+
+	lifetimeTotal      uint64 // # of requests in total, never reset
+	lifetimeFailures   uint64 // # of requests returned an error, never reset
+	lifetimeRejections uint64 // # of requests rejected with ErrBreakerOpen, never reset
+	lifetimeBypasses   uint64 // # of requests run bypassing the circuit breaker, never reset
+	transitions        uint64 // # of transitions between the states
+
+	name                string                          // name of the circuit breaker
+	isFailure           func(error) bool                // whether an error counts as a failure, all do if nil
+	isCritical          func(error) bool                // whether an error opens the circuit breaker immediately if set
+	ignoredErrs         []error                         // errors of the requests not counted at all if set
+	categorize          func(error) string              // category of the error of a failure if set
+	categories          sync.Map                        // category → *uint32, # of failures during the period
+	classifyResult      func(any, error) Outcome        // the outcome of a request by its result in Do
+	contextErrors       bool                            // whether a request of a done context counts as contextErrorOutcome
+	contextErrorOutcome Outcome                         // outcome of a request of a done context if contextErrors
+	rampStep            int64                           // duration of a step of the ramp-up
+	rampFractions       []float64                       // fractions of the requests admitted during the ramp-up steps
+	rampStart           int64                           // start timestamp of the ramp-up, zero if not ramping up
+	rampSeen            uint64                          // # of requests seen during the ramp-up
+	halfOpenTimeout     int64                           // max duration of the half-open state if set
+	halfOpenTo          State                           // state to move into once the half-open state timed out
+	healthProbe         func(context.Context) error     // run in the background while open if set
+	healthInterval      time.Duration                   // interval of the health probe
+	probing             uint64                          // generation of the open period the health probe runs for, 0 if none
+	shedding            bool                            // whether the requests are shed by the priority in the half-open state
+	minPriority         Priority                        // min priority of the requests admitted in the half-open state
+	executionTimeout    time.Duration                   // max duration of a request if set
+	coalesceFor         int64                           // duration of coalescing the requests after the recovery if set
+	recovered           int64                           // timestamp of the last transition from the half-open into the closed state
+	flights             flights                         // requests in flight coalesced by key
+	storage             Storage                         // shares the state with the other instances if set
+	storedMu            sync.Mutex                      // guards stored, publish and publishing
+	stored              StoredState                     // the state in the storage last seen or published
+	publish             *StoredState                    // the state to publish next if any
+	publishing          bool                            // whether the states are being published
+	maxProbes           uint32                          // # of requests in flight in the half-open state if set
+	probes              uint32                          // # of requests in flight accepted in the half-open state
+	observers           []Observer                      // receive the events
+	panicAsError        bool                            // whether a panic of the request is returned as *PanicError
+	logger              *slog.Logger                    // logs transitions, rejections and invalid configuration if set
+	watchers            watchers                        // receive the transitions
+	shadow              bool                            // whether the rejected requests are run anyway
+	chaos               atomic.Pointer[Chaos]           // failure injection if set, see WithChaos
+	cooldownFunc        func(int, Counts) time.Duration // computes the cooldown period if set
+	tuner               *tuner                          // tunes the threshold and cooldown if set, see WithAutoTune
+	wrapErrors          bool                            // whether the errors of the requests are wrapped into *RequestError
+	opts                []Option                        // options the circuit breaker was created with, see With
+
+	classifyContext func(context.Context, error) Outcome // the outcome of a request by its context in ExecuteContext if set
+	onOpen          func()                               // called once the circuit breaker opened if set, see Group.SetGlobal
+
+	storageTimeout      time.Duration // max duration of a call of the storage, see WithStorageTimeout
+	storageSyncInterval time.Duration // min interval between the loads of the state, see WithStorageSyncInterval
+	nextSync            int64         // timestamp the state is loaded from the storage again after
+	syncing             int32         // 1 while the state is being loaded from the storage
+	flushing            int32         // 1 while the outcomes are being recorded to the storage
+	pendingTotal        uint64        // # of requests not recorded to the storage yet
+	pendingFailures     uint64        // # of failed requests not recorded to the storage yet
+
+	epoch time.Time                            // time of the creation with the monotonic clock reading, see nanotime
+	now   func() time.Time                     // time.Now unless set with WithClock
+	after func(time.Duration) <-chan time.Time // time.After unless set with WithClock
 }
 
 // NewBreaker returns a new circuit breaker,
@@ -59,43 +151,81 @@ type Breaker struct {
 // otherwise into the open state.
 //
 // A function signature of toOpen and toClosed:
-//     func(total uint32, failures uint32) bool
-func NewBreaker(interval time.Duration, cooldown time.Duration, atLeastReqs uint32, toOpen ToState, toClosed ToState) (*Breaker, error) {
-	return withTimeNow(interval, cooldown, atLeastReqs, toOpen, toClosed, time.Now)
+//
+//	func(total uint32, failures uint32) bool
+//
+// Options tune the optional behavior of the circuit breaker.
+func NewBreaker(interval time.Duration, cooldown time.Duration, atLeastReqs uint32, toOpen ToState, toClosed ToState, opts ...Option) (*Breaker, error) {
+	return withTimeNow(interval, cooldown, atLeastReqs, toOpen, toClosed, time.Now, opts...)
 }
 
-func withTimeNow(interval time.Duration, cooldown time.Duration, atLeastReqs uint32, toOpen ToState, toClosed ToState, now func() time.Time) (*Breaker, error) {
+func withTimeNow(interval time.Duration, cooldown time.Duration, atLeastReqs uint32, toOpen ToState, toClosed ToState, now func() time.Time, opts ...Option) (*Breaker, error) {
+	b := &Breaker{
+		word:        pack(closed, 0),
+		interval:    interval.Nanoseconds(),
+		cooldown:    cooldown.Nanoseconds(),
+		atLeastReqs: atLeastReqs,
+		now:         now,
+		after:       time.After,
+	}
+	b.policy.Store(policy{toOpen: toOpen, toClosed: toClosed})
+	b.opts = opts
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	// after the options as WithClock replaces the clock
+	b.epoch = b.now()
+	b.start = b.epoch.UnixNano()
+	b.until = b.start + b.interval
+
+	err := validate(interval, cooldown, atLeastReqs, toOpen, toClosed)
+	if err == nil {
+		err = b.validateOptions()
+	}
+	if err != nil {
+		b.logInvalid(err)
+		return nil, err
+	}
+	return b, nil
+}
+
+func validate(interval time.Duration, cooldown time.Duration, atLeastReqs uint32, toOpen ToState, toClosed ToState) error {
 	if interval.Nanoseconds() == 0 {
-		return nil, errors.New("circuit: interval must be set")
+		return errors.New("circuit: interval must be set")
 	}
 
 	if cooldown.Nanoseconds() == 0 {
-		return nil, errors.New("circuit: cooldown must be set")
+		return errors.New("circuit: cooldown must be set")
 	}
 
 	if atLeastReqs == 0 {
-		return nil, errors.New("circuit: atLeastReqs must be set")
+		return errors.New("circuit: atLeastReqs must be set")
 	}
 
 	if toOpen == nil {
-		return nil, errors.New("circuit: toOpen must be defined")
+		return errors.New("circuit: toOpen must be defined")
 	}
 
 	if toClosed == nil {
-		return nil, errors.New("circuit: toClosed must be defined")
+		return errors.New("circuit: toClosed must be defined")
 	}
 
-	b := &Breaker{
-		state:         closed,
-		until:         now().UnixNano() + interval.Nanoseconds(),
-		interval:      interval.Nanoseconds(),
-		cooldown:      cooldown.Nanoseconds(),
-		atLeastReqs:   atLeastReqs,
-		toOpenState:   toOpen,
-		toClosedState: toClosed,
-		now:           now,
+	return nil
+}
+
+// validateOptions validates the settings of the options, which can't return errors themselves.
+func (b *Breaker) validateOptions() error {
+	if len(b.rampFractions) > 0 && b.rampStep <= 0 {
+		return errors.New("circuit: ramp-up step must be positive")
 	}
-	return b, nil
+
+	return nil
+}
+
+// Name returns the name of the circuit breaker set with WithName.
+func (b *Breaker) Name() string {
+	return b.name
 }
 
 // Execute runs a given request if the circuit breaker accepts it,
@@ -104,98 +234,423 @@ func withTimeNow(interval time.Duration, cooldown time.Duration, atLeastReqs uin
 //
 // Returns ErrBreakerOpen when it doesn't accept the request,
 // otherwise the error from the req function.
+//
+// A panic of the request is counted as a failure and resumed,
+// or returned as *PanicError when WithPanicAsError is used.
+// The outcome of a request outliving the period it was accepted in
+// isn't counted in the next one, only in the lifetime counters.
 func (b *Breaker) Execute(req func() error) error {
-	if !b.ready() {
-		return ErrBreakerOpen
+	b.injectOpen()
+	ok, probe := b.ready()
+	if !ok {
+		b.reject(nil)
+		if !b.shadow {
+			return b.openError()
+		}
+		return b.runUncounted(req)
 	}
 
-	atomic.AddUint32(&b.total, 1)
-	err := req()
+	gen, start := b.accept(nil)
+	err := b.inject(context.Background())
+	if err == nil {
+		err = guard(b.timed(req))
+	}
+	b.release(probe)
+	b.onResult(gen, start, err, nil)
+	b.repanic(err)
+	return b.wrap(err)
+}
 
-	if err != nil {
-		atomic.AddUint32(&b.failures, 1)
-		b.onFailure()
+// ExecuteContext runs a given request like Execute does, passing ctx into it.
+//
+// Returns the context's error without running the request
+// when ctx is already done, ErrBreakerOpen when the circuit breaker
+// doesn't accept the request, otherwise the error from the req function.
+//
+// With WithContextErrorOutcome a request returned after ctx is done
+// is counted as the given outcome, it's the caller who gave up, not the dependency.
+//
+// With WithPriorityShedding the requests with a low priority carried by ctx
+// are rejected with ErrBreakerOpen in the half-open state.
+//
+// A request of ctx made with WithBypass is run even when the circuit breaker doesn't accept it.
+//
+// The metadata carried by ctx, see WithMetadata, is forwarded to the events
+// of the request and ctx to the classifier set with WithContextClassifier.
+func (b *Breaker) ExecuteContext(ctx context.Context, req func(context.Context) error) error {
+	return b.executeContext(ctx, req, false)
+}
+
+// executeContext does ExecuteContext, not counting a request rejected
+// by a circuit breaker nested into it with ErrBreakerOpen if nested, see Group.SetGlobal.
+func (b *Breaker) executeContext(ctx context.Context, req func(context.Context) error, nested bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	md := b.metadata(ctx)
+	bypass := Bypassed(ctx)
+	if !bypass && b.shed(ctx) {
+		b.reject(md)
+		return b.openError()
+	}
+
+	b.injectOpen()
+	ok, probe := b.ready()
+	if !ok && bypass {
+		b.bypass(md)
+		return b.runUncounted(func() error { return req(ctx) })
+	}
+	if !ok {
+		b.reject(md)
+		if !b.shadow {
+			return b.openError()
+		}
+		return b.runUncounted(func() error { return req(ctx) })
+	}
+
+	gen, start := b.accept(md)
+	err := b.inject(ctx)
+	if err == nil && b.executionTimeout > 0 {
+		reqCtx, cancel := context.WithTimeout(ctx, b.executionTimeout)
+		defer cancel()
+		err = guard(b.timed(func() error { return req(reqCtx) }))
+	} else if err == nil {
+		err = guardContext(ctx, req)
+	}
+
+	b.release(probe)
+	_, panicked := err.(*PanicError)
+	switch {
+	case !panicked && nested && errors.Is(err, ErrBreakerOpen):
+		// rejected by the nested circuit breaker, the dependency wasn't reached
+		b.settle(gen, start, OutcomeIgnored, err, md)
+	case !panicked && b.contextErrors && ctx.Err() != nil:
+		// the caller gave up, not the dependency
+		b.settle(gen, start, b.contextErrorOutcome, err, md)
+	case !panicked && b.classifyContext != nil:
+		b.settle(gen, start, b.classifyContext(ctx, err), err, md)
+	default:
+		b.onResult(gen, start, err, md)
+	}
+
+	b.repanic(err)
+
+	return b.wrap(err)
+}
+
+// ExecuteWithFallback runs a given request like Execute does
+// and invokes the fallback with the error when the circuit breaker
+// doesn't accept the request (ErrBreakerOpen) or the request fails.
+//
+// Returns the error from the fallback function, nil if the request succeeded.
+func (b *Breaker) ExecuteWithFallback(req func() error, fallback func(error) error) error {
+	if err := b.Execute(req); err != nil {
+		return fallback(err)
 	}
+	return nil
+}
 
-	return err
+// runUncounted runs a request rejected in the shadow mode without counting its outcome.
+func (b *Breaker) runUncounted(req func() error) error {
+	err := guard(req)
+	b.repanic(err)
+	return b.wrap(err)
 }
 
-func (b *Breaker) ready() bool {
-	// any state changes are done based on CompareAndSwap(until)
-	until := atomic.LoadInt64(&b.until)
+// ready reports whether the circuit breaker accepts a request
+// and whether it's accepted as a probe which must be released once done.
+func (b *Breaker) ready() (ok bool, probe bool) {
+	if atomic.LoadInt32(&b.disabled) == 1 {
+		return true, false
+	}
+
+	if b.storage != nil {
+		b.syncState()
+	}
+
+	// any state changes are done based on CompareAndSwap(word)
+	w, until := b.period()
 
-	state := atomic.LoadInt32(&b.state)
-	now := b.now().UnixNano()
+	state := stateOf(w)
+	now := b.nanotime()
 
 	if state == closed {
 		if now > until {
 			// interval period elapsed
-			if atomic.CompareAndSwapInt64(&b.until, until, now+b.interval) {
-				atomic.StoreUint32(&b.failures, 0)
-				atomic.StoreUint32(&b.total, 0)
-			}
+			b.switchTo(closed, w, now, now+b.intervalNanos())
 		}
-		return true
+		return b.rampAdmit(now), false
 	}
 
 	if state == open {
-		if now > until {
+		if now > until && atomic.LoadInt32(&b.override) != int32(OverrideOpen) {
 			// cooldown period elapsed
-			if atomic.CompareAndSwapInt64(&b.until, until, now+b.interval) {
-				atomic.StoreUint32(&b.failures, 0)
-				atomic.StoreUint32(&b.total, 0)
-				atomic.StoreInt32(&b.state, halfOpen)
-				return true
+			if b.switchTo(halfOpen, w, now, now+b.halfOpenPeriod()) {
+				return b.acquireProbe()
 			}
 		}
-		return false
+		return false, false
 	}
 
 	// in halfOpen state
-	total := atomic.LoadUint32(&b.total)
-	failures := atomic.LoadUint32(&b.failures)
+	if w&settling != 0 {
+		// the counters are being reset by the transition into it
+		return false, false
+	}
+	if b.expireHalfOpen() {
+		return b.ready()
+	}
+
+	total, failures := b.policyCounts()
 	atLeastReqs := atomic.LoadUint32(&b.atLeastReqs)
 
 	if total < atLeastReqs {
 		// there is still a room for the request in halfOpen state
-		return true
+		return b.acquireProbe()
 	}
 
-	if b.toClosedState(total, failures) {
-		if atomic.CompareAndSwapInt64(&b.until, until, now+b.interval) {
-			atomic.StoreUint32(&b.failures, 0)
-			atomic.StoreUint32(&b.total, 0)
-			atomic.StoreInt32(&b.state, closed)
+	if b.shouldClose(total, failures) {
+		if b.switchTo(closed, w, now, now+b.intervalNanos()) {
+			b.startRamp(now)
 		}
-		return true
+		return b.rampAdmit(now), false
 	}
 
 	// didn't pass, back to the open state
-	if atomic.CompareAndSwapInt64(&b.until, until, now+b.cooldown) {
-		atomic.StoreUint32(&b.failures, 0)
-		atomic.StoreUint32(&b.total, 0)
-		atomic.StoreInt32(&b.state, open)
+	b.trip(w, now)
+	return false, false
+}
+
+// onResult records the outcome of a request returned err, see finish.
+func (b *Breaker) onResult(gen uint64, start int64, err error, md *Metadata) {
+	if b.ignored(err) {
+		b.ignore(gen)
+		return
+	}
+	b.finish(gen, start, b.failed(err), err, md)
+	if b.critical(err) {
+		b.tripCritical(gen)
 	}
-	return false
+}
+
+// settle records a given outcome of a request returned err, e.g. decided by a classifier.
+func (b *Breaker) settle(gen uint64, start int64, o Outcome, err error, md *Metadata) {
+	switch o {
+	case OutcomeSuccess:
+		b.finish(gen, start, false, nil, md)
+	case OutcomeFailure:
+		b.finish(gen, start, true, err, md)
+	case OutcomeIgnored:
+		b.ignore(gen)
+	case OutcomeCritical:
+		b.finish(gen, start, true, err, md)
+		b.tripCritical(gen)
+	}
+}
+
+// tripCritical opens the circuit breaker on a critical failure of a request
+// unless the period it was accepted in is over, see WithCriticalErrors.
+func (b *Breaker) tripCritical(gen uint64) {
+	if b.loadGen() != gen {
+		return
+	}
+	b.Trip()
+}
+
+// finish records the outcome and the latency of a request
+// accepted in the period of a given generation at start.
+//
+// The outcome of a request accepted in a previous period only counts in the lifetime counters,
+// the counters were reset since and it would pollute the ones of the current period.
+func (b *Breaker) finish(gen uint64, start int64, failed bool, err error, md *Metadata) {
+	if b.loadGen() != gen {
+		if failed {
+			atomic.AddUint64(&b.lifetimeFailures, 1)
+		}
+		return
+	}
+
+	if failed {
+		b.fail(err, md)
+	} else {
+		b.succeed()
+	}
+	b.observeLatency(start)
+}
+
+// ignore uncounts a request accepted in the period of a given generation
+// unless the period is over.
+func (b *Breaker) ignore(gen uint64) {
+	if b.loadGen() != gen {
+		return
+	}
+	b.addTotal(-1)
+}
+
+// accept counts a request accepted to run, returns the generation of the period
+// and its start timestamp if the latency is tracked, otherwise zero.
+func (b *Breaker) accept(md *Metadata) (gen uint64, start int64) {
+	gen = b.loadGen()
+	b.addTotal(1)
+	b.emitRequest(EventRequestAllowed, nil, md)
+	return gen, b.startTimer()
+}
+
+// reject counts a request rejected with ErrBreakerOpen.
+func (b *Breaker) reject(md *Metadata) {
+	atomic.AddUint32(&b.rejections, 1)
+	atomic.AddUint64(&b.lifetimeRejections, 1)
+	b.emitRequest(EventRequestRejected, nil, md)
+}
+
+// succeed counts a succeeded request.
+func (b *Breaker) succeed() {
+	b.recordOutcome(false)
+	b.addSuccess()
+	if atomic.LoadUint32(&b.consecutive) != 0 {
+		// not stored on every success not to contend on it
+		atomic.StoreUint32(&b.consecutive, 0)
+	}
+	if b.window != nil {
+		b.window.record(b.nanotime(), false)
+	}
+	if b.multiWindow != nil {
+		b.recordMultiWindow(b.nanotime(), false)
+	}
+}
+
+// fail counts a request failed with err, which is nil if unknown.
+func (b *Breaker) fail(err error, md *Metadata) {
+	b.recordOutcome(true)
+	b.addFailure()
+	atomic.AddUint32(&b.consecutive, 1)
+	atomic.AddUint64(&b.lifetimeFailures, 1)
+	if b.window != nil {
+		b.window.record(b.nanotime(), true)
+	}
+	if b.multiWindow != nil {
+		b.recordMultiWindow(b.nanotime(), true)
+	}
+	if b.categorize != nil {
+		b.countCategory(err)
+	}
+	if b.weight != nil {
+		b.addFailureScore(err)
+	}
+	b.emitRequest(EventRequestFailed, err, md)
+	b.onFailure()
 }
 
 func (b *Breaker) onFailure() {
-	// any state changes are done based on CompareAndSwap(until)
-	until := atomic.LoadInt64(&b.until)
+	// any state changes are done based on CompareAndSwap(word)
+	w := atomic.LoadUint64(&b.word)
+
+	if stateOf(w) != closed {
+		return
+	}
+
+	total, failures := b.policyCounts()
+	if b.window != nil {
+		total, failures = b.window.counts(b.nanotime())
+	}
 
-	if atomic.LoadInt32(&b.state) != closed {
+	if total < b.minVolume {
+		// too few requests to judge
 		return
 	}
 
-	total := atomic.LoadUint32(&b.total)
-	failures := atomic.LoadUint32(&b.failures)
+	trip := b.shouldOpen(total, failures)
+	if b.maxConsecutive > 0 && atomic.LoadUint32(&b.consecutive) >= b.maxConsecutive {
+		trip = true
+	}
+	if b.multiWindow != nil && b.multiWindowTrips(b.nanotime()) {
+		trip = true
+	}
+
+	if trip {
+		now := b.nanotime()
+		b.trip(w, now)
+	}
+}
+
+// switchTo moves the circuit breaker into a given state with a new period
+// from now until next, resetting the counters.
+// Only the goroutine winning CompareAndSwap(word) does it, reports whether it won.
+func (b *Breaker) switchTo(state int32, w uint64, now int64, next int64) bool {
+	return b.transition(state, w, now, next, true)
+}
+
+// transition does switchTo, publishing a change of the state to the storage if asked.
+func (b *Breaker) transition(state int32, w uint64, now int64, next int64, publish bool) bool {
+	if w&settling != 0 {
+		// the period isn't set yet by the previous transition
+		return false
+	}
+	settled := pack(state, genOf(w)+1)
+	if !atomic.CompareAndSwapUint64(&b.word, w, settled|settling) {
+		return false
+	}
+
+	hooked := len(b.observers) > 0 || b.watchers.watched() || b.tuner != nil
+	var counts Counts
+	if hooked {
+		// the counters of the finished period
+		counts = b.snapshot()
+		counts.State = State(stateOf(w))
+	}
+
+	atomic.StoreInt64(&b.until, next)
+	atomic.StoreInt64(&b.start, now)
+	atomic.StoreUint32(&b.rejections, 0)
+	atomic.StoreUint32(&b.slowCalls, 0)
+	if b.latencies != nil {
+		b.latencies.reset()
+	}
+	b.resetCounters()
+	atomic.StoreUint64(&b.word, settled)
+
+	from := stateOf(w)
+	if from != state {
+		atomic.AddUint64(&b.transitions, 1)
+		atomic.StoreInt64(&b.rampStart, 0)
+		switch state {
+		case open:
+			atomic.AddUint32(&b.opens, 1)
+			if b.healthProbe != nil {
+				go b.probeHealth(genOf(settled))
+			}
+		case closed:
+			atomic.StoreUint32(&b.opens, 0)
+			if from == halfOpen {
+				atomic.StoreInt64(&b.recovered, now)
+			}
+		}
+		atomic.StoreUint32(&b.consecutive, 0)
+		if b.window != nil {
+			b.window.reset(now)
+		}
+		if b.multiWindow != nil {
+			b.resetMultiWindow(now)
+		}
+		if publish && b.storage != nil {
+			b.publishState(state, next)
+		}
+	}
 
-	if b.toOpenState(total, failures) {
-		now := b.now().UnixNano()
-		if atomic.CompareAndSwapInt64(&b.until, until, now+b.cooldown) {
-			atomic.StoreUint32(&b.failures, 0)
-			atomic.StoreUint32(&b.total, 0)
-			atomic.StoreInt32(&b.state, open)
+	if hooked && from != state {
+		counts.Transitions = atomic.LoadUint64(&b.transitions)
+		at := time.Unix(0, now)
+		b.emit(Event{Type: EventStateChanged, Time: at, Name: b.name, From: State(from), State: State(state), Counts: counts})
+		if b.watchers.watched() {
+			b.watchers.send(StateChange{From: State(from), To: State(state), Counts: counts, At: at})
 		}
 	}
+	if b.tuner != nil {
+		b.tune(State(from), State(state), counts, now)
+	}
+	if from != state && state == open && b.onOpen != nil {
+		b.onOpen()
+	}
+	return true
 }
@@ -1,6 +1,7 @@
 package circuit
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"testing"
@@ -19,7 +20,7 @@ func TestNewBreaker(t *testing.T) {
 
 	b, err := NewBreaker(time.Minute, time.Minute, 100, to, to)
 	assert.NoError(t, err)
-	assert.Equal(t, closed, b.state)
+	assert.Equal(t, closed, b.loadState())
 }
 
 func TestBreaker_OnFailure(t *testing.T) {
@@ -30,19 +31,19 @@ func TestBreaker_OnFailure(t *testing.T) {
 	toClosed := func(uint32, uint32) bool { return false }
 	b, err := withTimeNow(time.Minute, 2*time.Minute, 100, toOpen, toClosed, now(1520100000))
 	assert.NoError(t, err)
-	assert.Equal(t, closed, b.state)
+	assert.Equal(t, closed, b.loadState())
 	assert.Equal(t, int64(1520100060000000000), b.until)
 
 	b.total = 1
 	b.failures = 1
 	b.onFailure()
-	assert.Equal(t, closed, b.state)
+	assert.Equal(t, closed, b.loadState())
 	assert.Equal(t, int64(1520100060000000000), b.until)
 
 	b.total = 2
 	b.failures = 2
 	b.onFailure()
-	assert.Equal(t, open, b.state)
+	assert.Equal(t, open, b.loadState())
 	assert.Equal(t, int64(1520100120000000000), b.until)
 }
 
@@ -52,26 +53,26 @@ func TestBreaker_Execute_WhenClosed(t *testing.T) {
 	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1520100060000000000), b.until)
-	assert.Equal(t, uint32(0), b.total)
-	assert.Equal(t, uint32(0), b.failures)
+	assert.Equal(t, uint64(0), b.total)
+	assert.Equal(t, uint64(0), b.failures)
 
 	err = b.Execute(func() error { return nil })
 	assert.NoError(t, err)
-	assert.Equal(t, uint32(1), b.total)
-	assert.Equal(t, uint32(0), b.failures)
+	assert.Equal(t, uint64(1), b.total)
+	assert.Equal(t, uint64(0), b.failures)
 
 	err = b.Execute(func() error { return nil })
 	assert.NoError(t, err)
-	assert.Equal(t, uint32(2), b.total)
-	assert.Equal(t, uint32(0), b.failures)
+	assert.Equal(t, uint64(2), b.total)
+	assert.Equal(t, uint64(0), b.failures)
 
 	// passed interval period, 61 sec
 	b.now = now(1520100061)
 	err = b.Execute(func() error { return nil })
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1520100121000000000), b.until)
-	assert.Equal(t, uint32(1), b.total)
-	assert.Equal(t, uint32(0), b.failures)
+	assert.Equal(t, uint64(1), b.total)
+	assert.Equal(t, uint64(0), b.failures)
 }
 
 func TestBreaker_Execute_WhenOpen(t *testing.T) {
@@ -83,43 +84,189 @@ func TestBreaker_Execute_WhenOpen(t *testing.T) {
 	// open the breaker
 	err = b.Execute(func() error { return errors.New("failed") })
 	assert.Error(t, err, "failed")
-	assert.Equal(t, open, b.state)
+	assert.Equal(t, open, b.loadState())
 	assert.Equal(t, int64(1520100120000000000), b.until)
 
 	// cooldown period, still open
 	err = b.Execute(func() error { return nil })
-	assert.Equal(t, ErrBreakerOpen, err)
+	assert.ErrorIs(t, err, ErrBreakerOpen)
 
 	// after cooldown period (passed 121 sec)
 	b.now = now(1520100121)
 	err = b.Execute(func() error { return nil })
 	assert.Equal(t, nil, err)
-	assert.Equal(t, halfOpen, b.state)
+	assert.Equal(t, halfOpen, b.loadState())
 	assert.Equal(t, int64(1520100181000000000), b.until)
 
 	// atLeastReq exceeded, toClosed is invoked for the decision making
-	b.toClosedState = func(total uint32, failures uint32) bool { return false }
+	b.policy.Store(policy{toOpen: b.policies().toOpen, toClosed: func(total uint32, failures uint32) bool { return false }})
 	err = b.Execute(func() error { return nil })
-	assert.Equal(t, ErrBreakerOpen, err)
-	assert.Equal(t, open, b.state)
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+	assert.Equal(t, open, b.loadState())
 	assert.Equal(t, int64(1520100241000000000), b.until)
 
 	// after the second cooldown period
 	b.now = now(1520100242)
 	err = b.Execute(func() error { return nil })
 	assert.Equal(t, nil, err)
-	assert.Equal(t, halfOpen, b.state)
+	assert.Equal(t, halfOpen, b.loadState())
 	assert.Equal(t, int64(1520100302000000000), b.until)
 
 	// atLeastReq exceeded, toClosed is invoked for the decision making
 	b.now = now(1520100302)
-	b.toClosedState = func(total uint32, failures uint32) bool { return true }
+	b.policy.Store(policy{toOpen: b.policies().toOpen, toClosed: func(total uint32, failures uint32) bool { return true }})
 	err = b.Execute(func() error { return nil })
 	assert.Equal(t, nil, err)
-	assert.Equal(t, closed, b.state)
+	assert.Equal(t, closed, b.loadState())
 	assert.Equal(t, int64(1520100362000000000), b.until)
 }
 
+func TestBreaker_ExecuteContext(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	err = b.ExecuteContext(ctx, func(ctx context.Context) error {
+		assert.Equal(t, "value", ctx.Value(key{}))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), b.total)
+
+	// already cancelled context, the request is not run
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = b.ExecuteContext(cancelled, func(context.Context) error {
+		t.Fatal("request must not be run")
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, uint64(1), b.total)
+
+	// cancelled while running counts as a failure by default
+	ctx, cancel = context.WithCancel(context.Background())
+	err = b.ExecuteContext(ctx, func(ctx context.Context) error {
+		cancel()
+		return ctx.Err()
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, uint64(2), b.total)
+	assert.Equal(t, uint64(1), b.failures)
+	assert.Equal(t, closed, b.loadState())
+}
+
+func TestBreaker_ExecuteContext_IgnoreContextErrors(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithIgnoreContextErrors())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err = b.ExecuteContext(ctx, func(ctx context.Context) error {
+		cancel()
+		return ctx.Err()
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, uint64(1), b.total)
+	assert.Equal(t, uint64(0), b.failures)
+	assert.Equal(t, closed, b.loadState())
+
+	// a failure of the dependency still counts
+	err = b.ExecuteContext(context.Background(), func(context.Context) error {
+		return errors.New("failed")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, open, b.loadState())
+}
+
+func TestBreaker_ExecuteContext_WithContextErrorOutcome(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	cancelled := func(b *Breaker) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		return b.ExecuteContext(ctx, func(ctx context.Context) error {
+			cancel()
+			return ctx.Err()
+		})
+	}
+
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithContextErrorOutcome(OutcomeIgnored))
+	assert.NoError(t, err)
+	assert.Equal(t, context.Canceled, cancelled(b))
+	assert.Equal(t, uint64(0), b.total)
+	assert.Equal(t, uint64(0), b.failures)
+	assert.Equal(t, uint64(0), b.successes)
+	assert.Equal(t, closed, b.loadState())
+
+	b, err = b.With(WithContextErrorOutcome(OutcomeSuccess))
+	assert.NoError(t, err)
+	assert.Equal(t, context.Canceled, cancelled(b))
+	assert.Equal(t, uint64(1), b.successes)
+
+	b, err = b.With(WithContextErrorOutcome(OutcomeFailure))
+	assert.NoError(t, err)
+	assert.Equal(t, context.Canceled, cancelled(b))
+	assert.Equal(t, uint64(1), b.lifetimeFailures)
+	assert.Equal(t, open, b.loadState())
+}
+
+func TestBreaker_Execute_OutcomeOfPreviousPeriod(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	// the interval elapses while the request is in flight
+	err = b.Execute(func() error {
+		b.now = now(1520100061)
+		b.Execute(func() error { return errors.New("failed") })
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed")
+
+	// not counted in the new interval, where it would open the circuit breaker
+	counts := b.Counts()
+	assert.Equal(t, StateClosed, counts.State)
+	assert.Equal(t, uint32(1), counts.Total)
+	assert.Equal(t, uint32(1), counts.Failures)
+	assert.Equal(t, uint64(2), counts.LifetimeFailures)
+}
+
+func TestBreaker_ExecuteWithFallback(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	var fallbackErrs []error
+	fallback := func(err error) error {
+		fallbackErrs = append(fallbackErrs, err)
+		return nil
+	}
+
+	err = b.ExecuteWithFallback(func() error { return nil }, fallback)
+	assert.NoError(t, err)
+	assert.Empty(t, fallbackErrs)
+
+	failed := errors.New("failed")
+	err = b.ExecuteWithFallback(func() error { return failed }, fallback)
+	assert.NoError(t, err)
+	assert.Equal(t, []error{failed}, fallbackErrs)
+	assert.Equal(t, open, b.loadState())
+
+	err = b.ExecuteWithFallback(func() error { return nil }, fallback)
+	assert.NoError(t, err)
+	assert.Len(t, fallbackErrs, 2)
+	assert.ErrorIs(t, fallbackErrs[1], ErrBreakerOpen)
+
+	// the error from the fallback is returned
+	err = b.ExecuteWithFallback(func() error { return nil }, func(err error) error { return err })
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+}
+
 func TestBreaker_Execute_RequestsInParallel(t *testing.T) {
 	toOpen := func(uint32, uint32) bool { return true }
 	toClosed := func(uint32, uint32) bool { return false }
@@ -140,8 +287,8 @@ func TestBreaker_Execute_RequestsInParallel(t *testing.T) {
 	}
 
 	wg.Wait()
-	assert.Equal(t, uint32(20), b.total)
-	assert.Equal(t, uint32(0), b.failures)
+	assert.Equal(t, uint64(20), b.total)
+	assert.Equal(t, uint64(0), b.failures)
 	assert.Equal(t, int64(1520100060000000000), b.until)
 }
 
@@ -151,7 +298,7 @@ func TestBreaker_Execute_FailuresInParallel(t *testing.T) {
 	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1520100060000000000), b.until)
-	assert.Equal(t, closed, b.state)
+	assert.Equal(t, closed, b.loadState())
 
 	b.now = now(1520100001)
 
@@ -168,7 +315,7 @@ func TestBreaker_Execute_FailuresInParallel(t *testing.T) {
 	wg.Wait()
 	assert.True(t, b.total < 20)
 	assert.True(t, b.failures < 20)
-	assert.Equal(t, open, b.state)
+	assert.Equal(t, open, b.loadState())
 	assert.Equal(t, int64(1520100121000000000), b.until)
 }
 
@@ -179,7 +326,7 @@ func TestBreaker_Execute_AtLeastReqsInParallel(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1520100060000000000), b.until)
 
-	b.state = halfOpen
+	b.word = pack(halfOpen, 0)
 	b.now = now(1520100001)
 
 	var wg sync.WaitGroup
@@ -194,11 +341,92 @@ func TestBreaker_Execute_AtLeastReqsInParallel(t *testing.T) {
 
 	wg.Wait()
 	assert.True(t, b.total <= 10)
-	assert.Equal(t, uint32(0), b.failures)
-	assert.Equal(t, closed, b.state)
+	assert.Equal(t, uint64(0), b.failures)
+	assert.Equal(t, closed, b.loadState())
 	assert.Equal(t, int64(1520100061000000000), b.until)
 }
 
 func now(sec int64) func() time.Time {
 	return func() time.Time { return time.Unix(sec, 0) }
 }
+
+func TestBreaker_WithShadowMode(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithShadowMode())
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState())
+
+	// would be rejected, run anyway
+	var runs int
+	err = b.Execute(func() error { runs++; return errors.New("failed") })
+	assert.EqualError(t, err, "failed")
+	err = b.ExecuteContext(context.Background(), func(context.Context) error { runs++; return nil })
+	assert.NoError(t, err)
+	tok, err := b.Allow()
+	assert.NoError(t, err)
+	tok.Failure()
+	assert.Equal(t, 2, runs)
+
+	counts := b.Counts()
+	assert.Equal(t, uint32(3), counts.Rejections)
+	assert.Equal(t, uint32(0), counts.Total)
+	assert.Equal(t, uint32(0), counts.Failures)
+	assert.Equal(t, uint64(1), counts.Transitions)
+}
+
+func TestBreaker_ExecuteAllocs(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return false }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	// the successful requests in the closed state don't allocate
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		b.Execute(func() error { return nil })
+	}))
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		b.ExecuteContext(ctx, func(context.Context) error { return nil })
+	}))
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		Do(b, func() (int, error) { return 1000, nil })
+	}))
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		tok, _ := b.Allow()
+		tok.Success()
+	}))
+}
+
+func BenchmarkBreaker_Execute(b *testing.B) {
+	toOpen := func(total uint32, failures uint32) bool { return false }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	cb, err := NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(b, err)
+	req := func() error { return nil }
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cb.Execute(req)
+		}
+	})
+}
+
+func BenchmarkBreaker_ExecuteContext(b *testing.B) {
+	toOpen := func(total uint32, failures uint32) bool { return false }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	cb, err := NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(b, err)
+	ctx := context.Background()
+	req := func(context.Context) error { return nil }
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cb.ExecuteContext(ctx, req)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+// Package circuitadmin exposes the circuit breakers of a registry over HTTP
+// for the operators to inspect and control them at runtime.
+package circuitadmin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/djo/circuit"
+)
+
+// actions are the POST endpoints of the handler by name.
+var actions = map[string]func(b *circuit.Breaker){
+	"force-open":     (*circuit.Breaker).ForceOpen,
+	"force-close":    (*circuit.Breaker).ForceClose,
+	"clear-override": (*circuit.Breaker).ClearOverride,
+	"trip":           (*circuit.Breaker).Trip,
+	"reset":          (*circuit.Breaker).Reset,
+	"disable":        (*circuit.Breaker).Disable,
+	"enable":         (*circuit.Breaker).Enable,
+}
+
+// Handler returns an http.Handler exposing the circuit breakers of a given registry:
+//
+//	GET  /                list the circuit.Status of every circuit breaker
+//	GET  /{name}          the circuit.Status of the circuit breaker
+//	POST /{name}/{action} run an action and respond with the new circuit.Status,
+//	                      one of force-open, force-close, clear-override, trip, reset, disable, enable
+//
+// Mount it under a prefix with http.StripPrefix, e.g.
+//
+//	http.Handle("/circuit/", http.StripPrefix("/circuit", circuitadmin.Handler(r)))
+func Handler(r *circuit.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path := strings.Trim(req.URL.Path, "/")
+		if path == "" {
+			if req.Method != http.MethodGet {
+				methodNotAllowed(w, http.MethodGet)
+				return
+			}
+
+			list := []circuit.Status{}
+			for _, name := range r.Names() {
+				if b, ok := r.Get(name); ok {
+					list = append(list, status(name, b))
+				}
+			}
+			respond(w, list)
+			return
+		}
+
+		name, action, _ := strings.Cut(path, "/")
+		b, ok := r.Get(name)
+		if !ok {
+			http.Error(w, "circuit breaker not found", http.StatusNotFound)
+			return
+		}
+
+		if action == "" {
+			if req.Method != http.MethodGet {
+				methodNotAllowed(w, http.MethodGet)
+				return
+			}
+			respond(w, status(name, b))
+			return
+		}
+
+		run, ok := actions[action]
+		if !ok {
+			http.Error(w, "unknown action", http.StatusNotFound)
+			return
+		}
+		if req.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+
+		run(b)
+		respond(w, status(name, b))
+	})
+}
+
+// status returns the status of the circuit breaker named as in the registry.
+func status(name string, b *circuit.Breaker) circuit.Status {
+	s := b.Status()
+	s.Name = name
+	return s
+}
+
+func respond(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func methodNotAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
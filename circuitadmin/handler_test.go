@@ -0,0 +1,70 @@
+package circuitadmin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	r := circuit.NewRegistry()
+	payments, err := r.NewBreaker("payments", time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+	_, err = r.NewBreaker("accounts", time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+
+	h := Handler(r)
+	do := func(method, path string) (*httptest.ResponseRecorder, []byte) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w, w.Body.Bytes()
+	}
+
+	// list
+	w, body := do(http.MethodGet, "/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var list []circuit.Status
+	assert.NoError(t, json.Unmarshal(body, &list))
+	assert.Len(t, list, 2)
+	assert.Equal(t, "accounts", list[0].Name)
+	assert.Equal(t, "payments", list[1].Name)
+
+	// get
+	w, body = do(http.MethodGet, "/payments")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var s circuit.Status
+	assert.NoError(t, json.Unmarshal(body, &s))
+	assert.Equal(t, circuit.StateClosed, s.State)
+
+	// actions
+	w, body = do(http.MethodPost, "/payments/force-open")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(body, &s))
+	assert.Equal(t, circuit.StateOpen, s.State)
+	assert.Equal(t, circuit.OverrideOpen, s.Override)
+	assert.Equal(t, circuit.StateOpen, payments.State())
+
+	do(http.MethodPost, "/payments/reset")
+	assert.Equal(t, circuit.StateClosed, payments.State())
+
+	do(http.MethodPost, "/payments/disable/")
+	assert.True(t, payments.Disabled())
+
+	// errors
+	w, _ = do(http.MethodGet, "/payments/reset")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, http.MethodPost, w.Header().Get("Allow"))
+	w, _ = do(http.MethodPost, "/payments")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	w, _ = do(http.MethodGet, "/orders")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w, _ = do(http.MethodPost, "/payments/explode")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,302 @@
+// Package circuitconfig builds a registry of named circuit breakers
+// from a YAML or JSON document, so tuning them doesn't require code changes:
+//
+//	breakers:
+//	  payments:
+//	    interval: 1m
+//	    cooldown: 10s
+//	    atLeastReqs: 5
+//	    toOpen: {policy: rate, rate: 0.5, minRequests: 10}
+//	    toClosed: {policy: rate, rate: 0.1}
+//	    classifier: downstream
+//	    consecutiveFailures: 5
+//
+// The durations are formatted as by time.ParseDuration.
+// The policies and classifiers are referenced by name,
+// the custom ones are registered with WithPolicy and WithClassifier.
+package circuitconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/djo/circuit"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration of the circuit breakers by name.
+type Config struct {
+	Breakers map[string]Breaker `json:"breakers"`
+}
+
+// Breaker is the configuration of a circuit breaker, see circuit.NewBreaker.
+type Breaker struct {
+	Interval    Duration `json:"interval"`
+	Cooldown    Duration `json:"cooldown"`
+	AtLeastReqs uint32   `json:"atLeastReqs"`
+	ToOpen      Policy   `json:"toOpen"`
+	ToClosed    Policy   `json:"toClosed"`
+
+	Classifier          string   `json:"classifier,omitempty"`          // see circuit.WithFailureClassifier
+	ConsecutiveFailures uint32   `json:"consecutiveFailures,omitempty"` // see circuit.WithConsecutiveFailures
+	MinimumVolume       uint32   `json:"minimumVolume,omitempty"`       // see circuit.WithMinimumVolume
+	ExecutionTimeout    Duration `json:"executionTimeout,omitempty"`    // see circuit.WithExecutionTimeout
+}
+
+// Policy is a toOpen or toClosed function referenced by name:
+//
+//   - "rate" is circuit.RateThreshold(Rate, MinRequests) for toOpen,
+//     for toClosed it closes the circuit breaker while the failure rate is below Rate
+//   - "ewma" is circuit.EWMA(Alpha, Threshold), for toOpen only
+//   - any other name is a function registered with WithPolicy
+type Policy struct {
+	Policy      string  `json:"policy"`
+	Rate        float64 `json:"rate,omitempty"`
+	MinRequests uint32  `json:"minRequests,omitempty"`
+	Alpha       float64 `json:"alpha,omitempty"`
+	Threshold   float64 `json:"threshold,omitempty"`
+}
+
+// Duration is a time.Duration formatted as by time.ParseDuration, e.g. "1m30s".
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"1m30s\", got %s", data)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Option is an option of Build.
+type Option func(*builder)
+
+type builder struct {
+	policies    map[string]circuit.ToState
+	classifiers map[string]func(error) bool
+	opts        []circuit.Option
+}
+
+// WithPolicy registers a toOpen or toClosed function referenced by name in the document.
+func WithPolicy(name string, toState circuit.ToState) Option {
+	return func(b *builder) {
+		b.policies[name] = toState
+	}
+}
+
+// WithClassifier registers a failure classifier referenced by name in the document,
+// see circuit.WithFailureClassifier.
+func WithClassifier(name string, isFailure func(error) bool) Option {
+	return func(b *builder) {
+		b.classifiers[name] = isFailure
+	}
+}
+
+// WithBreakerOptions passes the options to every circuit breaker built,
+// e.g. circuit.WithLogger, they're applied before the ones from the document.
+func WithBreakerOptions(opts ...circuit.Option) Option {
+	return func(b *builder) {
+		b.opts = append(b.opts, opts...)
+	}
+}
+
+// Parse parses a YAML or JSON document, the unknown fields are rejected.
+func Parse(data []byte) (*Config, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("circuitconfig: %v", err)
+	}
+
+	// YAML is decoded into the JSON model to share the field names and the validation
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("circuitconfig: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+
+	var c Config
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("circuitconfig: %v", err)
+	}
+	return &c, nil
+}
+
+// Build returns a registry of the circuit breakers configured.
+//
+// Every invalid circuit breaker is reported in the returned error
+// with the path to its field, e.g.
+//
+//	circuitconfig: breakers.payments.toOpen: unknown policy "rat", want rate, ewma or one registered with WithPolicy
+func (c *Config) Build(opts ...Option) (*circuit.Registry, error) {
+	r := circuit.NewRegistry()
+	if err := c.Apply(r, opts...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Apply applies the configuration to the circuit breakers of a registry,
+// the existing ones are updated with circuit.Breaker.UpdateConfig,
+// keeping their state and counters, and the new ones are added.
+// The circuit breakers missing in the document are left as they are.
+//
+// Only the interval, cooldown, atLeastReqs and policies of the existing circuit breakers
+// are updated, the other fields apply to the new ones.
+// Nothing is applied if any circuit breaker is invalid.
+func (c *Config) Apply(r *circuit.Registry, opts ...Option) error {
+	b := &builder{
+		policies:    make(map[string]circuit.ToState),
+		classifiers: make(map[string]func(error) bool),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	names := make([]string, 0, len(c.Breakers))
+	for name := range c.Breakers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	configs := make([]circuit.Config, len(names))
+	options := make([][]circuit.Option, len(names))
+	var errs []error
+	for i, name := range names {
+		var err error
+		if configs[i], options[i], err = b.build(c.Breakers[name]); err != nil {
+			errs = append(errs, fmt.Errorf("circuitconfig: breakers.%s%v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for i, name := range names {
+		cfg := configs[i]
+		if br, ok := r.Get(name); ok {
+			errs = append(errs, br.UpdateConfig(cfg))
+			continue
+		}
+		_, err := r.NewBreaker(name, cfg.Interval, cfg.Cooldown, cfg.AtLeastReqs, cfg.ToOpen, cfg.ToClosed, options[i]...)
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Load parses a YAML or JSON document and builds a registry of the circuit breakers configured.
+func Load(data []byte, opts ...Option) (*circuit.Registry, error) {
+	c, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.Build(opts...)
+}
+
+// LoadFile does the same as Load reading the document from a file.
+func LoadFile(path string, opts ...Option) (*circuit.Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("circuitconfig: %v", err)
+	}
+	return Load(data, opts...)
+}
+
+// build returns the configuration and the options of the circuit breaker,
+// the error starts with the path to the invalid field relative to the breaker.
+func (b *builder) build(c Breaker) (circuit.Config, []circuit.Option, error) {
+	if c.Interval <= 0 {
+		return circuit.Config{}, nil, errors.New(`.interval: must be a positive duration, e.g. "1m"`)
+	}
+	if c.Cooldown <= 0 {
+		return circuit.Config{}, nil, errors.New(`.cooldown: must be a positive duration, e.g. "10s"`)
+	}
+	if c.AtLeastReqs == 0 {
+		return circuit.Config{}, nil, errors.New(".atLeastReqs: must be at least 1")
+	}
+
+	toOpen, err := b.policy(c.ToOpen, true)
+	if err != nil {
+		return circuit.Config{}, nil, fmt.Errorf(".toOpen: %v", err)
+	}
+	toClosed, err := b.policy(c.ToClosed, false)
+	if err != nil {
+		return circuit.Config{}, nil, fmt.Errorf(".toClosed: %v", err)
+	}
+
+	opts := append([]circuit.Option{}, b.opts...)
+	if c.Classifier != "" {
+		isFailure, ok := b.classifiers[c.Classifier]
+		if !ok {
+			return circuit.Config{}, nil, fmt.Errorf(".classifier: unknown classifier %q, want one registered with WithClassifier", c.Classifier)
+		}
+		opts = append(opts, circuit.WithFailureClassifier(isFailure))
+	}
+	if c.ConsecutiveFailures > 0 {
+		opts = append(opts, circuit.WithConsecutiveFailures(c.ConsecutiveFailures))
+	}
+	if c.MinimumVolume > 0 {
+		opts = append(opts, circuit.WithMinimumVolume(c.MinimumVolume))
+	}
+	if c.ExecutionTimeout > 0 {
+		opts = append(opts, circuit.WithExecutionTimeout(time.Duration(c.ExecutionTimeout)))
+	}
+
+	cfg := circuit.Config{
+		Interval:    time.Duration(c.Interval),
+		Cooldown:    time.Duration(c.Cooldown),
+		AtLeastReqs: c.AtLeastReqs,
+		ToOpen:      toOpen,
+		ToClosed:    toClosed,
+	}
+	return cfg, opts, nil
+}
+
+func (b *builder) policy(p Policy, toOpen bool) (circuit.ToState, error) {
+	switch p.Policy {
+	case "":
+		return nil, errors.New("missing policy, want rate, ewma or one registered with WithPolicy")
+	case "rate":
+		if p.Rate <= 0 || p.Rate > 1 {
+			return nil, fmt.Errorf("rate must be in (0, 1], got %v", p.Rate)
+		}
+		tooMany := circuit.RateThreshold(p.Rate, p.MinRequests)
+		if toOpen {
+			return tooMany, nil
+		}
+		return func(total, failures uint32) bool { return !tooMany(total, failures) }, nil
+	case "ewma":
+		if !toOpen {
+			return nil, errors.New("ewma is for toOpen only")
+		}
+		if p.Alpha <= 0 || p.Alpha > 1 {
+			return nil, fmt.Errorf("alpha must be in (0, 1], got %v", p.Alpha)
+		}
+		if p.Threshold <= 0 || p.Threshold > 1 {
+			return nil, fmt.Errorf("threshold must be in (0, 1], got %v", p.Threshold)
+		}
+		return circuit.EWMA(p.Alpha, p.Threshold), nil
+	}
+
+	toState, ok := b.policies[p.Policy]
+	if !ok {
+		return nil, fmt.Errorf("unknown policy %q, want rate, ewma or one registered with WithPolicy", p.Policy)
+	}
+	return toState, nil
+}
@@ -0,0 +1,161 @@
+package circuitconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+var errNotFound = errors.New("not found")
+
+const doc = `
+breakers:
+  payments:
+    interval: 1m
+    cooldown: 10s
+    atLeastReqs: 2
+    toOpen:
+      policy: rate
+      rate: 0.5
+      minRequests: 2
+    toClosed:
+      policy: rate
+      rate: 0.1
+    classifier: downstream
+  accounts:
+    interval: 30s
+    cooldown: 5s
+    atLeastReqs: 1
+    toOpen:
+      policy: always
+    toClosed:
+      policy: always
+    consecutiveFailures: 3
+`
+
+func TestLoad(t *testing.T) {
+	r, err := Load([]byte(doc),
+		WithPolicy("always", func(uint32, uint32) bool { return true }),
+		WithClassifier("downstream", func(err error) bool { return err != errNotFound }))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"accounts", "payments"}, r.Names())
+
+	payments, _ := r.Get("payments")
+	assert.Equal(t, "payments", payments.Name())
+	assert.Equal(t, circuit.Config{Interval: time.Minute, Cooldown: 10 * time.Second, AtLeastReqs: 2}, payments.Status().Config)
+
+	// not found isn't a failure
+	payments.Execute(func() error { return errNotFound })
+	payments.Execute(func() error { return errNotFound })
+	assert.Equal(t, circuit.StateClosed, payments.State())
+
+	payments.Execute(func() error { return errors.New("boom") })
+	assert.Equal(t, circuit.StateClosed, payments.State())
+	payments.Execute(func() error { return errors.New("boom") })
+	assert.Equal(t, circuit.StateOpen, payments.State())
+
+	accounts, _ := r.Get("accounts")
+	assert.Equal(t, circuit.Config{Interval: 30 * time.Second, Cooldown: 5 * time.Second, AtLeastReqs: 1}, accounts.Status().Config)
+	accounts.Execute(func() error { return errors.New("boom") })
+	assert.Equal(t, circuit.StateOpen, accounts.State())
+}
+
+func TestLoadJSON(t *testing.T) {
+	r, err := Load([]byte(`{"breakers": {"payments": {
+		"interval": "1m", "cooldown": "10s", "atLeastReqs": 1,
+		"toOpen": {"policy": "ewma", "alpha": 0.5, "threshold": 0.5},
+		"toClosed": {"policy": "rate", "rate": 0.5},
+		"executionTimeout": "2s"
+	}}}`))
+	assert.NoError(t, err)
+
+	payments, ok := r.Get("payments")
+	assert.True(t, ok)
+	payments.Execute(func() error { return errors.New("boom") })
+	assert.Equal(t, circuit.StateOpen, payments.State())
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "circuit.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(doc), 0o644))
+
+	r, err := LoadFile(path,
+		WithPolicy("always", func(uint32, uint32) bool { return true }),
+		WithClassifier("downstream", func(error) bool { return true }))
+	assert.NoError(t, err)
+	assert.Len(t, r.Names(), 2)
+
+	_, err = LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := Load([]byte(`{"breakers": {"payments": {"intervall": "1m"}}}`))
+	assert.EqualError(t, err, `circuitconfig: json: unknown field "intervall"`)
+
+	_, err = Load([]byte(`{"breakers": {"payments": {"interval": 60}}}`))
+	assert.Error(t, err)
+
+	// every invalid breaker is reported
+	_, err = Load([]byte(doc))
+	assert.EqualError(t, err, "circuitconfig: breakers.accounts.toOpen: unknown policy \"always\", want rate, ewma or one registered with WithPolicy\n"+
+		"circuitconfig: breakers.payments.classifier: unknown classifier \"downstream\", want one registered with WithClassifier")
+
+	tests := []struct {
+		breaker string
+		err     string
+	}{
+		{`{"cooldown": "1s", "atLeastReqs": 1}`, `circuitconfig: breakers.b.interval: must be a positive duration, e.g. "1m"`},
+		{`{"interval": "1m", "atLeastReqs": 1}`, `circuitconfig: breakers.b.cooldown: must be a positive duration, e.g. "10s"`},
+		{`{"interval": "1m", "cooldown": "1s"}`, `circuitconfig: breakers.b.atLeastReqs: must be at least 1`},
+		{`{"interval": "1m", "cooldown": "1s", "atLeastReqs": 1}`, `circuitconfig: breakers.b.toOpen: missing policy, want rate, ewma or one registered with WithPolicy`},
+		{`{"interval": "1m", "cooldown": "1s", "atLeastReqs": 1, "toOpen": {"policy": "rate", "rate": 2}}`, `circuitconfig: breakers.b.toOpen: rate must be in (0, 1], got 2`},
+		{`{"interval": "1m", "cooldown": "1s", "atLeastReqs": 1, "toOpen": {"policy": "ewma", "alpha": 0.5}}`, `circuitconfig: breakers.b.toOpen: threshold must be in (0, 1], got 0`},
+		{`{"interval": "1m", "cooldown": "1s", "atLeastReqs": 1, "toOpen": {"policy": "rate", "rate": 0.5}, "toClosed": {"policy": "ewma", "alpha": 0.5, "threshold": 0.5}}`, `circuitconfig: breakers.b.toClosed: ewma is for toOpen only`},
+	}
+	for _, tt := range tests {
+		_, err := Load([]byte(`{"breakers": {"b": ` + tt.breaker + `}}`))
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestConfigApply(t *testing.T) {
+	r, err := Load([]byte(doc),
+		WithPolicy("always", func(uint32, uint32) bool { return true }),
+		WithClassifier("downstream", func(error) bool { return true }))
+	assert.NoError(t, err)
+
+	payments, _ := r.Get("payments")
+	payments.Execute(func() error { return errors.New("boom") })
+
+	c, err := Parse([]byte(`{"breakers": {
+		"payments": {"interval": "2m", "cooldown": "20s", "atLeastReqs": 3,
+			"toOpen": {"policy": "rate", "rate": 0.9}, "toClosed": {"policy": "rate", "rate": 0.1}},
+		"orders": {"interval": "1m", "cooldown": "10s", "atLeastReqs": 1,
+			"toOpen": {"policy": "rate", "rate": 0.5}, "toClosed": {"policy": "rate", "rate": 0.1}}
+	}}`))
+	assert.NoError(t, err)
+	assert.NoError(t, c.Apply(r))
+
+	// updated in place keeping the counters
+	b, _ := r.Get("payments")
+	assert.Same(t, payments, b)
+	assert.Equal(t, circuit.Config{Interval: 2 * time.Minute, Cooldown: 20 * time.Second, AtLeastReqs: 3}, b.Status().Config)
+	assert.Equal(t, uint32(1), b.Counts().Failures)
+
+	// added, the missing ones are kept
+	assert.Equal(t, []string{"accounts", "orders", "payments"}, r.Names())
+
+	// nothing is applied if invalid
+	c.Breakers["payments"] = Breaker{Interval: Duration(time.Minute)}
+	c.Breakers["orders"] = Breaker{Interval: Duration(time.Minute), Cooldown: Duration(time.Second), AtLeastReqs: 1,
+		ToOpen: Policy{Policy: "rate", Rate: 0.5}, ToClosed: Policy{Policy: "rate", Rate: 0.5}}
+	assert.Error(t, c.Apply(r))
+	orders, _ := r.Get("orders")
+	assert.Equal(t, 10*time.Second, orders.Config().Cooldown)
+}
@@ -0,0 +1,68 @@
+package circuitconfig
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/djo/circuit"
+)
+
+// Watch reloads the configuration file into a registry whenever it changes,
+// checking its modification time and size every given period,
+// see Config.Apply for how the circuit breakers are updated.
+//
+// The errors of reading, parsing or applying the file are passed to onError if set,
+// the circuit breakers are left as they are then.
+// The returned function stops watching.
+func Watch(r *circuit.Registry, path string, every time.Duration, onError func(error), opts ...Option) (stop func()) {
+	last, _ := os.Stat(path)
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			fi, err := os.Stat(path)
+			if err != nil {
+				report(onError, fmt.Errorf("circuitconfig: %v", err))
+				continue
+			}
+			if last != nil && fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size() {
+				continue
+			}
+			last = fi
+
+			if err := reload(r, path, opts); err != nil {
+				report(onError, err)
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+func reload(r *circuit.Registry, path string, opts []Option) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("circuitconfig: %v", err)
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	return c.Apply(r, opts...)
+}
+
+func report(onError func(error), err error) {
+	if onError != nil {
+		onError(err)
+	}
+}
@@ -0,0 +1,50 @@
+package circuitconfig
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "circuit.json")
+	write := func(interval string) {
+		assert.NoError(t, os.WriteFile(path, []byte(`{"breakers": {"payments": {
+			"interval": "`+interval+`", "cooldown": "10s", "atLeastReqs": 1,
+			"toOpen": {"policy": "rate", "rate": 0.5}, "toClosed": {"policy": "rate", "rate": 0.1}
+		}}}`), 0o644))
+	}
+	write("1m")
+
+	r, err := LoadFile(path)
+	assert.NoError(t, err)
+	payments, _ := r.Get("payments")
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	stop := Watch(r, path, time.Millisecond, func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	})
+	defer stop()
+
+	write("2m0s") // the size changes along with the modification time
+	assert.Eventually(t, func() bool {
+		return payments.Config().Interval == 2*time.Minute
+	}, time.Second, time.Millisecond)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"breakers": {"payments": {}}}`), 0o644))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) > 0
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 2*time.Minute, payments.Config().Interval)
+}
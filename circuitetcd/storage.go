@@ -0,0 +1,249 @@
+// Package circuitetcd shares the states of circuit breakers across the instances
+// of an application through etcd.
+package circuitetcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/djo/circuit"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Storage is a circuit.Storage keeping the states in etcd under a key prefix.
+//
+// The states are watched and cached in the memory, so loading them on every request
+// doesn't hit etcd, the transitions are published in transactions
+// comparing the version of the key, so only one instance wins a transition.
+// The outcomes of the requests are counted by every circuit breaker locally
+// and not stored.
+//
+// A failed watch is re-established from the last seen revision, the states are reloaded
+// if it's compacted, see Err.
+type Storage struct {
+	client *clientv3.Client
+	prefix string
+
+	mu     sync.RWMutex
+	states map[string]circuit.StoredState
+	err    error // error of the watch until it's re-established
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New returns a new storage keeping the states under a given prefix,
+// e.g. "/circuit/", it loads the stored states and watches them until Close.
+func New(ctx context.Context, client *clientv3.Client, prefix string) (*Storage, error) {
+	resp, err := client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	s := &Storage{
+		client: client,
+		prefix: prefix,
+		states: make(map[string]circuit.StoredState),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	for _, kv := range resp.Kvs {
+		s.update(kv)
+	}
+
+	go s.watch(watchCtx, resp.Header.Revision)
+	return s, nil
+}
+
+// Err returns the error of the watch of the states while it's being re-established,
+// the states aren't updated by the other instances then.
+func (s *Storage) Err() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.err
+}
+
+// Close stops watching the states.
+func (s *Storage) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// LoadState implements circuit.Storage, the state is read from the memory.
+func (s *Storage) LoadState(_ context.Context, name string) (circuit.StoredState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.states[name], nil
+}
+
+// RecordOutcome implements circuit.Storage, it doesn't store the outcomes.
+func (s *Storage) RecordOutcome(context.Context, string, bool) error {
+	return nil
+}
+
+// CompareAndSetState implements circuit.Storage.
+func (s *Storage) CompareAndSetState(ctx context.Context, name string, old, new circuit.StoredState) (bool, error) {
+	value, err := encode(new)
+	if err != nil {
+		return false, err
+	}
+
+	// the version of the key is the version of the state as it's changed only here
+	key := s.prefix + name
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.Version(key), "=", int64(old.Version))).
+		Then(clientv3.OpPut(key, value)).
+		Commit()
+	if err != nil {
+		return false, err
+	}
+
+	if resp.Succeeded {
+		s.mu.Lock()
+		if s.states[name].Version < new.Version {
+			s.states[name] = new
+		}
+		s.mu.Unlock()
+	}
+	return resp.Succeeded, nil
+}
+
+// retryDelay is the delay of re-establishing a failed watch.
+const retryDelay = time.Second
+
+// watch watches the states after a given revision until ctx is done.
+func (s *Storage) watch(ctx context.Context, rev int64) {
+	defer close(s.done)
+
+	for {
+		var err error
+		rev, err = s.watchFrom(ctx, rev)
+		if ctx.Err() != nil {
+			return
+		}
+		s.setErr(err)
+
+		select {
+		case <-time.After(retryDelay):
+		case <-ctx.Done():
+			return
+		}
+
+		if errors.Is(err, errCompacted) {
+			reloaded, err := s.reload(ctx)
+			if err != nil {
+				s.setErr(err)
+				continue
+			}
+			rev = reloaded
+		}
+	}
+}
+
+// errCompacted is returned by watchFrom once the revisions to watch are compacted.
+var errCompacted = errors.New("circuitetcd: watched revision compacted")
+
+// watchFrom applies the changes after a given revision till the watch fails,
+// returns the last seen revision.
+func (s *Storage) watchFrom(ctx context.Context, rev int64) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for resp := range s.client.Watch(ctx, s.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1)) {
+		if err := resp.Err(); err != nil {
+			if resp.CompactRevision != 0 {
+				return rev, fmt.Errorf("%w: %v", errCompacted, err)
+			}
+			return rev, err
+		}
+		s.setErr(nil)
+
+		for _, ev := range resp.Events {
+			if ev.Type == clientv3.EventTypeDelete {
+				s.mu.Lock()
+				delete(s.states, strings.TrimPrefix(string(ev.Kv.Key), s.prefix))
+				s.mu.Unlock()
+				continue
+			}
+			s.update(ev.Kv)
+		}
+		rev = max(rev, resp.Header.Revision)
+	}
+	return rev, errors.New("circuitetcd: watch closed")
+}
+
+// reload loads the states again, returns their revision.
+func (s *Storage) reload(ctx context.Context) (int64, error) {
+	resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, err
+	}
+
+	seen := make(map[string]bool, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		seen[strings.TrimPrefix(string(kv.Key), s.prefix)] = true
+		s.update(kv)
+	}
+	s.mu.Lock()
+	for name := range s.states {
+		if !seen[name] {
+			delete(s.states, name)
+		}
+	}
+	s.mu.Unlock()
+	return resp.Header.Revision, nil
+}
+
+func (s *Storage) setErr(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+func (s *Storage) update(kv *mvccpb.KeyValue) {
+	st, err := decode(kv.Value)
+	if err != nil {
+		return
+	}
+	st.Version = uint64(kv.Version)
+
+	name := strings.TrimPrefix(string(kv.Key), s.prefix)
+	s.mu.Lock()
+	if s.states[name].Version < st.Version {
+		s.states[name] = st
+	}
+	s.mu.Unlock()
+}
+
+type stored struct {
+	State string    `json:"state"`
+	Until time.Time `json:"until"`
+}
+
+func encode(st circuit.StoredState) (string, error) {
+	b, err := json.Marshal(stored{State: st.State.String(), Until: st.Until})
+	return string(b), err
+}
+
+func decode(value []byte) (circuit.StoredState, error) {
+	var v stored
+	if err := json.Unmarshal(value, &v); err != nil {
+		return circuit.StoredState{}, err
+	}
+
+	st := circuit.StoredState{State: circuit.StateClosed, Until: v.Until}
+	switch v.State {
+	case circuit.StateOpen.String():
+		st.State = circuit.StateOpen
+	case circuit.StateHalfOpen.String():
+		st.State = circuit.StateHalfOpen
+	}
+	return st, nil
+}
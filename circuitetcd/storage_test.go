@@ -0,0 +1,64 @@
+package circuitetcd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestEncode(t *testing.T) {
+	st := circuit.StoredState{State: circuit.StateHalfOpen, Until: time.Unix(1520100060, 0).UTC(), Version: 3}
+	value, err := encode(st)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"state":"half-open","until":"2018-03-03T18:01:00Z"}`, value)
+
+	decoded, err := decode([]byte(value))
+	assert.NoError(t, err)
+	assert.Equal(t, circuit.StoredState{State: circuit.StateHalfOpen, Until: st.Until}, decoded)
+
+	_, err = decode([]byte("{"))
+	assert.Error(t, err)
+}
+
+// TestStorage runs against etcd at CIRCUIT_ETCD_ENDPOINTS, e.g. localhost:2379.
+func TestStorage(t *testing.T) {
+	endpoints := os.Getenv("CIRCUIT_ETCD_ENDPOINTS")
+	if endpoints == "" {
+		t.Skip("CIRCUIT_ETCD_ENDPOINTS is not set")
+	}
+
+	client, err := clientv3.New(clientv3.Config{Endpoints: strings.Split(endpoints, ","), DialTimeout: 5 * time.Second})
+	assert.NoError(t, err)
+	defer client.Close()
+
+	ctx := context.Background()
+	prefix := "/circuit-test/" + time.Now().Format(time.RFC3339Nano) + "/"
+	s1, err := New(ctx, client, prefix)
+	assert.NoError(t, err)
+	defer s1.Close()
+	s2, err := New(ctx, client, prefix)
+	assert.NoError(t, err)
+	defer s2.Close()
+
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b1, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed, circuit.WithName("payments"), circuit.WithStorage(s1))
+	assert.NoError(t, err)
+	b2, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed, circuit.WithName("payments"), circuit.WithStorage(s2))
+	assert.NoError(t, err)
+
+	b1.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, circuit.StateOpen, b1.State())
+
+	// propagated by the watch
+	assert.Eventually(t, func() bool {
+		return errors.Is(b2.Execute(func() error { return nil }), circuit.ErrBreakerOpen)
+	}, 5*time.Second, 10*time.Millisecond)
+}
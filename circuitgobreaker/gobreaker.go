@@ -0,0 +1,261 @@
+// Package circuitgobreaker provides the API of sony/gobreaker backed by the circuit breakers of this module,
+// so a codebase can switch the implementations incrementally by changing the import:
+//
+//	cb := circuitgobreaker.NewCircuitBreaker(circuitgobreaker.Settings{
+//		Name:        "payments",
+//		ReadyToTrip: func(c circuitgobreaker.Counts) bool { return c.ConsecutiveFailures > 3 },
+//	})
+//	v, err := cb.Execute(func() (interface{}, error) { return fetch() })
+//
+// The underlying circuit breaker is exposed by Breaker for the registries, metrics and admin APIs.
+package circuitgobreaker
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/djo/circuit"
+)
+
+var (
+	// ErrTooManyRequests is returned when the circuit breaker is half-open
+	// and the requests in flight reached MaxRequests.
+	ErrTooManyRequests = errors.New("too many requests")
+	// ErrOpenState is returned when the circuit breaker is open.
+	ErrOpenState = errors.New("circuit breaker is open")
+)
+
+// forever is the interval of the closed state which never clears the counts.
+const forever = 100 * 365 * 24 * time.Hour
+
+// State is the state of the circuit breaker.
+type State int
+
+// The states of the circuit breaker.
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	}
+	return fmt.Sprintf("unknown state: %d", s)
+}
+
+func fromState(s circuit.State) State {
+	switch s {
+	case circuit.StateHalfOpen:
+		return StateHalfOpen
+	case circuit.StateOpen:
+		return StateOpen
+	}
+	return StateClosed
+}
+
+// Counts holds the numbers of the requests and their outcomes of the current period.
+type Counts struct {
+	Requests             uint32
+	TotalSuccesses       uint32
+	TotalFailures        uint32
+	ConsecutiveSuccesses uint32
+	ConsecutiveFailures  uint32
+}
+
+// Settings configures the circuit breaker as in gobreaker:
+//
+//   - MaxRequests is the # of requests allowed in the half-open state, 1 if zero.
+//   - Interval is the cyclic period of the closed state clearing the counts,
+//     they are never cleared in the closed state if it's zero.
+//   - Timeout is the period of the open state, 60 seconds if zero.
+//   - ReadyToTrip is called on a failure in the closed state,
+//     ConsecutiveFailures > 5 if nil.
+//   - OnStateChange is called on every transition.
+//   - IsSuccessful reports whether an error counts as a success, err == nil if nil.
+type Settings struct {
+	Name          string
+	MaxRequests   uint32
+	Interval      time.Duration
+	Timeout       time.Duration
+	ReadyToTrip   func(counts Counts) bool
+	OnStateChange func(name string, from State, to State)
+	IsSuccessful  func(err error) bool
+}
+
+// CircuitBreaker is a circuit breaker with the API of gobreaker's CircuitBreaker.
+type CircuitBreaker struct {
+	b            *circuit.Breaker
+	name         string
+	maxRequests  uint32
+	isSuccessful func(err error) bool
+	successes    uint32 // # of requests succeeded in a row
+}
+
+// NewCircuitBreaker returns a new circuit breaker configured by the settings.
+//
+// As in gobreaker, a failure in the half-open state opens the circuit breaker
+// and MaxRequests successes in a row close it, though it's done by the next request.
+func NewCircuitBreaker(st Settings) *CircuitBreaker {
+	cb := &CircuitBreaker{name: st.Name, isSuccessful: st.IsSuccessful}
+	if cb.isSuccessful == nil {
+		cb.isSuccessful = func(err error) bool { return err == nil }
+	}
+
+	cb.maxRequests = st.MaxRequests
+	if cb.maxRequests == 0 {
+		cb.maxRequests = 1
+	}
+	interval := st.Interval
+	if interval <= 0 {
+		interval = forever
+	}
+	timeout := st.Timeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	readyToTrip := st.ReadyToTrip
+	if readyToTrip == nil {
+		readyToTrip = func(c Counts) bool { return c.ConsecutiveFailures > 5 }
+	}
+
+	toOpen := func(uint32, uint32) bool { return readyToTrip(cb.Counts()) }
+	toClosed := func(total, failures uint32) bool { return failures == 0 }
+	opts := []circuit.Option{
+		circuit.WithName(st.Name),
+		circuit.WithMaxHalfOpenProbes(cb.maxRequests),
+		circuit.WithOnStateChange(func(from, to circuit.State, _ circuit.Counts) {
+			atomic.StoreUint32(&cb.successes, 0)
+			if st.OnStateChange != nil {
+				st.OnStateChange(st.Name, fromState(from), fromState(to))
+			}
+		}),
+	}
+
+	// the settings are valid with the defaults applied
+	cb.b, _ = circuit.NewBreaker(interval, timeout, cb.maxRequests, toOpen, toClosed, opts...)
+	return cb
+}
+
+// Name returns the name of the circuit breaker.
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
+}
+
+// State returns the current state of the circuit breaker.
+func (cb *CircuitBreaker) State() State {
+	return fromState(cb.b.State())
+}
+
+// Counts returns the counts of the current period.
+func (cb *CircuitBreaker) Counts() Counts {
+	c := cb.b.Counts()
+	return Counts{
+		Requests:             c.Total,
+		TotalSuccesses:       c.Successes,
+		TotalFailures:        c.Failures,
+		ConsecutiveSuccesses: atomic.LoadUint32(&cb.successes),
+		ConsecutiveFailures:  c.ConsecutiveFailures,
+	}
+}
+
+// Breaker returns the underlying circuit breaker.
+func (cb *CircuitBreaker) Breaker() *circuit.Breaker {
+	return cb.b
+}
+
+// Execute runs a given request if the circuit breaker accepts it,
+// otherwise returns ErrOpenState or ErrTooManyRequests.
+// A panic of the request is counted as a failure and resumed.
+func (cb *CircuitBreaker) Execute(req func() (interface{}, error)) (interface{}, error) {
+	done, err := cb.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			done(false)
+			panic(e)
+		}
+	}()
+
+	v, err := req()
+	done(cb.isSuccessful(err))
+	return v, err
+}
+
+// allow returns a function recording the outcome of the request if the circuit breaker accepts it.
+func (cb *CircuitBreaker) allow() (func(success bool), error) {
+	if c := cb.b.Counts(); c.State == circuit.StateHalfOpen && c.Total >= cb.maxRequests && c.Successes < cb.maxRequests {
+		// the requests of the half-open state are still in flight
+		return nil, ErrTooManyRequests
+	}
+
+	tok, err := cb.b.Allow()
+	if err != nil {
+		if cb.b.State() == circuit.StateHalfOpen {
+			return nil, ErrTooManyRequests
+		}
+		return nil, ErrOpenState
+	}
+
+	return func(success bool) {
+		if success {
+			atomic.AddUint32(&cb.successes, 1)
+			tok.Success()
+			return
+		}
+
+		atomic.StoreUint32(&cb.successes, 0)
+		tok.Failure()
+		if cb.b.State() == circuit.StateHalfOpen {
+			cb.b.Trip()
+		}
+	}, nil
+}
+
+// TwoStepCircuitBreaker is a circuit breaker with the API of gobreaker's TwoStepCircuitBreaker.
+type TwoStepCircuitBreaker struct {
+	cb *CircuitBreaker
+}
+
+// NewTwoStepCircuitBreaker returns a new two-step circuit breaker configured by the settings.
+func NewTwoStepCircuitBreaker(st Settings) *TwoStepCircuitBreaker {
+	return &TwoStepCircuitBreaker{cb: NewCircuitBreaker(st)}
+}
+
+// Name returns the name of the circuit breaker.
+func (tscb *TwoStepCircuitBreaker) Name() string {
+	return tscb.cb.Name()
+}
+
+// State returns the current state of the circuit breaker.
+func (tscb *TwoStepCircuitBreaker) State() State {
+	return tscb.cb.State()
+}
+
+// Counts returns the counts of the current period.
+func (tscb *TwoStepCircuitBreaker) Counts() Counts {
+	return tscb.cb.Counts()
+}
+
+// Breaker returns the underlying circuit breaker.
+func (tscb *TwoStepCircuitBreaker) Breaker() *circuit.Breaker {
+	return tscb.cb.Breaker()
+}
+
+// Allow returns a function to report the outcome of the request with
+// if the circuit breaker accepts it, otherwise ErrOpenState or ErrTooManyRequests.
+func (tscb *TwoStepCircuitBreaker) Allow() (done func(success bool), err error) {
+	return tscb.cb.allow()
+}
@@ -0,0 +1,121 @@
+package circuitgobreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errFailed = errors.New("failed")
+
+func fail() (interface{}, error)    { return nil, errFailed }
+func succeed() (interface{}, error) { return "ok", nil }
+
+func TestCircuitBreaker(t *testing.T) {
+	var changes []string
+	cb := NewCircuitBreaker(Settings{
+		Name:        "payments",
+		MaxRequests: 2,
+		Timeout:     20 * time.Millisecond,
+		ReadyToTrip: func(c Counts) bool { return c.ConsecutiveFailures >= 2 },
+		OnStateChange: func(name string, from State, to State) {
+			changes = append(changes, name+": "+from.String()+" -> "+to.String())
+		},
+	})
+	assert.Equal(t, "payments", cb.Name())
+	assert.Equal(t, "payments", cb.Breaker().Name())
+
+	v, err := cb.Execute(succeed)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", v)
+	assert.Equal(t, Counts{Requests: 1, TotalSuccesses: 1, ConsecutiveSuccesses: 1}, cb.Counts())
+
+	_, err = cb.Execute(fail)
+	assert.Equal(t, errFailed, err)
+	assert.Equal(t, StateClosed, cb.State())
+	_, err = cb.Execute(fail)
+	assert.Equal(t, errFailed, err)
+	assert.Equal(t, StateOpen, cb.State())
+
+	_, err = cb.Execute(succeed)
+	assert.Equal(t, ErrOpenState, err)
+
+	// a failure in the half-open state opens it
+	time.Sleep(30 * time.Millisecond)
+	_, err = cb.Execute(fail)
+	assert.Equal(t, errFailed, err)
+	assert.Equal(t, StateOpen, cb.State())
+
+	// MaxRequests successes close it
+	time.Sleep(30 * time.Millisecond)
+	_, err = cb.Execute(succeed)
+	assert.NoError(t, err)
+	_, err = cb.Execute(succeed)
+	assert.NoError(t, err)
+	assert.Equal(t, StateHalfOpen, cb.State())
+	_, err = cb.Execute(succeed)
+	assert.NoError(t, err)
+	assert.Equal(t, StateClosed, cb.State())
+
+	assert.Equal(t, []string{
+		"payments: closed -> open",
+		"payments: open -> half-open",
+		"payments: half-open -> open",
+		"payments: open -> half-open",
+		"payments: half-open -> closed",
+	}, changes)
+}
+
+func TestCircuitBreaker_Defaults(t *testing.T) {
+	cb := NewCircuitBreaker(Settings{IsSuccessful: func(err error) bool { return err == nil || err == errFailed }})
+
+	for i := 0; i < 10; i++ {
+		cb.Execute(fail)
+	}
+	assert.Equal(t, StateClosed, cb.State())
+	assert.Equal(t, uint32(10), cb.Counts().TotalSuccesses)
+
+	assert.Panics(t, func() {
+		cb.Execute(func() (interface{}, error) { panic("boom") })
+	})
+	assert.Equal(t, uint32(1), cb.Counts().TotalFailures)
+	assert.Equal(t, time.Minute, cb.Breaker().Config().Cooldown)
+}
+
+func TestTwoStepCircuitBreaker(t *testing.T) {
+	tscb := NewTwoStepCircuitBreaker(Settings{
+		Name:        "payments",
+		Timeout:     time.Minute,
+		ReadyToTrip: func(c Counts) bool { return c.TotalFailures > 0 },
+	})
+
+	done, err := tscb.Allow()
+	assert.NoError(t, err)
+	done(false)
+	assert.Equal(t, StateOpen, tscb.State())
+
+	_, err = tscb.Allow()
+	assert.Equal(t, ErrOpenState, err)
+	assert.Equal(t, Counts{}, tscb.Counts())
+}
+
+func TestCircuitBreaker_TooManyRequests(t *testing.T) {
+	cb := NewCircuitBreaker(Settings{
+		Timeout:     10 * time.Millisecond,
+		ReadyToTrip: func(c Counts) bool { return true },
+	})
+	cb.Execute(fail)
+	time.Sleep(20 * time.Millisecond)
+
+	release := make(chan struct{})
+	go cb.Execute(func() (interface{}, error) {
+		<-release
+		return nil, nil
+	})
+	assert.Eventually(t, func() bool { return cb.State() == StateHalfOpen }, time.Second, time.Millisecond)
+	_, err := cb.Execute(succeed)
+	assert.Equal(t, ErrTooManyRequests, err)
+	close(release)
+}
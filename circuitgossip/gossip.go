@@ -0,0 +1,158 @@
+// Package circuitgossip shares the trips of circuit breakers between the instances
+// of an application peer-to-peer with hashicorp/memberlist, without a central store.
+package circuitgossip
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/djo/circuit"
+	"github.com/hashicorp/memberlist"
+)
+
+// Gossip broadcasts the transitions of the circuit breakers in a registry
+// to the other members of the cluster, which open their circuit breakers
+// of the same name with Trip once one of them opens. The local recovery is not shared,
+// every instance probes the dependency on its own.
+type Gossip struct {
+	r     *circuit.Registry
+	queue *memberlist.TransmitLimitedQueue
+	ml    *memberlist.Memberlist
+
+	mu       sync.Mutex
+	adopting map[string]bool // names of the circuit breakers being tripped by a message
+}
+
+// New returns a new gossip for the circuit breakers in a given registry
+// joining the cluster with a given memberlist configuration,
+// its Delegate is set by New. The circuit breakers must be created
+// with WithObserver(g.Observer()) to broadcast their transitions.
+func New(r *circuit.Registry, conf *memberlist.Config) (*Gossip, error) {
+	g := newGossip(r)
+	conf.Delegate = g
+
+	ml, err := memberlist.Create(conf)
+	if err != nil {
+		return nil, err
+	}
+	g.ml = ml
+	g.queue.NumNodes = ml.NumMembers
+	return g, nil
+}
+
+func newGossip(r *circuit.Registry) *Gossip {
+	return &Gossip{
+		r:        r,
+		queue:    &memberlist.TransmitLimitedQueue{NumNodes: func() int { return 1 }, RetransmitMult: 3},
+		adopting: make(map[string]bool),
+	}
+}
+
+// Join joins the cluster through the existing members at given addresses,
+// returns the number of the members contacted.
+func (g *Gossip) Join(addrs []string) (int, error) {
+	return g.ml.Join(addrs)
+}
+
+// Close leaves the cluster and stops the gossip.
+func (g *Gossip) Close() error {
+	if err := g.ml.Leave(0); err != nil {
+		return err
+	}
+	return g.ml.Shutdown()
+}
+
+// Observer returns an observer broadcasting the transitions of a circuit breaker.
+func (g *Gossip) Observer() circuit.Observer {
+	return circuit.ObserverFunc(func(e circuit.Event) {
+		if e.Type != circuit.EventStateChanged || e.State != circuit.StateOpen {
+			return
+		}
+
+		g.mu.Lock()
+		adopted := g.adopting[e.Name]
+		g.mu.Unlock()
+		if adopted {
+			// tripped by a message, not broadcast again
+			return
+		}
+
+		g.broadcast(message{Name: e.Name, State: e.State.String()})
+	})
+}
+
+type message struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+func (g *Gossip) broadcast(m message) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	g.queue.QueueBroadcast(&broadcast{name: m.Name, data: data})
+}
+
+// NodeMeta implements memberlist.Delegate.
+func (g *Gossip) NodeMeta(limit int) []byte {
+	return nil
+}
+
+// NotifyMsg implements memberlist.Delegate, it trips the circuit breaker
+// of the name in the message if it's opened by another member.
+func (g *Gossip) NotifyMsg(data []byte) {
+	var m message
+	if err := json.Unmarshal(data, &m); err != nil {
+		return
+	}
+	if m.State != circuit.StateOpen.String() {
+		return
+	}
+
+	b, ok := g.r.Get(m.Name)
+	if !ok {
+		return
+	}
+
+	g.mu.Lock()
+	g.adopting[m.Name] = true
+	g.mu.Unlock()
+
+	b.Trip()
+
+	g.mu.Lock()
+	delete(g.adopting, m.Name)
+	g.mu.Unlock()
+}
+
+// GetBroadcasts implements memberlist.Delegate.
+func (g *Gossip) GetBroadcasts(overhead, limit int) [][]byte {
+	return g.queue.GetBroadcasts(overhead, limit)
+}
+
+// LocalState implements memberlist.Delegate, the state is not synced on join.
+func (g *Gossip) LocalState(join bool) []byte {
+	return nil
+}
+
+// MergeRemoteState implements memberlist.Delegate.
+func (g *Gossip) MergeRemoteState(buf []byte, join bool) {}
+
+// broadcast is a message about a circuit breaker,
+// it invalidates the previous ones about the same circuit breaker.
+type broadcast struct {
+	name string
+	data []byte
+}
+
+func (b *broadcast) Invalidates(other memberlist.Broadcast) bool {
+	o, ok := other.(*broadcast)
+	return ok && o.name == b.name
+}
+
+func (b *broadcast) Message() []byte {
+	return b.data
+}
+
+func (b *broadcast) Finished() {}
@@ -0,0 +1,43 @@
+package circuitgossip
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGossip(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+
+	// two instances
+	r1, r2 := circuit.NewRegistry(), circuit.NewRegistry()
+	g1, g2 := newGossip(r1), newGossip(r2)
+	b1, err := r1.NewBreaker("payments", time.Minute, time.Minute, 1, toOpen, toClosed, circuit.WithObserver(g1.Observer()))
+	assert.NoError(t, err)
+	b2, err := r2.NewBreaker("payments", time.Minute, time.Minute, 1, toOpen, toClosed, circuit.WithObserver(g2.Observer()))
+	assert.NoError(t, err)
+
+	b1.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, circuit.StateOpen, b1.State())
+
+	msgs := g1.GetBroadcasts(0, 1024)
+	assert.Equal(t, [][]byte{[]byte(`{"name":"payments","state":"open"}`)}, msgs)
+
+	// delivered to the other instance
+	for _, m := range msgs {
+		g2.NotifyMsg(m)
+	}
+	assert.Equal(t, circuit.StateOpen, b2.State())
+	assert.Equal(t, circuit.OverrideNone, b2.Override())
+
+	// not broadcast again
+	assert.Empty(t, g2.GetBroadcasts(0, 1024))
+
+	// unknown and malformed messages are ignored
+	g2.NotifyMsg([]byte(`{"name":"accounts","state":"open"}`))
+	g2.NotifyMsg([]byte(`{`))
+}
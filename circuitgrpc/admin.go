@@ -0,0 +1,280 @@
+// Package circuitgrpc exposes the circuit breakers of a registry as a gRPC admin service
+// to be registered on an existing server and guards the gRPC clients with circuit breakers.
+//
+// The admin service is described by adminpb/admin.proto, its messages and stubs
+// are generated into adminpb, so it's called with Client, adminpb.AdminClient
+// or the gRPC tools, e.g. grpcurl, once the server reflection is registered.
+package circuitgrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative adminpb/admin.proto
+
+import (
+	"context"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/djo/circuit/circuitgrpc/adminpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ServiceName is the full name of the admin service.
+const ServiceName = "circuit.Admin"
+
+// Server implements the admin service over the circuit breakers of a registry.
+type Server struct {
+	adminpb.UnimplementedAdminServer
+	r *circuit.Registry
+}
+
+// NewServer returns the admin service of a given registry.
+func NewServer(r *circuit.Registry) *Server {
+	return &Server{r: r}
+}
+
+// Register registers the admin service on a gRPC server,
+// the server reflection makes it callable with grpcurl, e.g.
+//
+//	s := grpc.NewServer()
+//	circuitgrpc.Register(s, circuitgrpc.NewServer(r))
+//	reflection.Register(s)
+func Register(s grpc.ServiceRegistrar, srv *Server) {
+	adminpb.RegisterAdminServer(s, srv)
+}
+
+// ListBreakers returns the status of every circuit breaker.
+func (s *Server) ListBreakers(ctx context.Context, req *adminpb.ListBreakersRequest) (*adminpb.ListBreakersResponse, error) {
+	resp := &adminpb.ListBreakersResponse{}
+	for _, name := range s.r.Names() {
+		if b, ok := s.r.Get(name); ok {
+			resp.Breakers = append(resp.Breakers, breakerStatus(name, b))
+		}
+	}
+	return resp, nil
+}
+
+// GetBreaker returns the status of the circuit breaker.
+func (s *Server) GetBreaker(ctx context.Context, req *adminpb.GetBreakerRequest) (*adminpb.Breaker, error) {
+	b, err := s.get(req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return breakerStatus(req.GetName(), b), nil
+}
+
+// SetOverride forces the state of the circuit breaker or clears the override
+// and returns its new status.
+func (s *Server) SetOverride(ctx context.Context, req *adminpb.SetOverrideRequest) (*adminpb.Breaker, error) {
+	b, err := s.get(req.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GetOverride() {
+	case adminpb.Override_OVERRIDE_NONE:
+		b.ClearOverride()
+	case adminpb.Override_OVERRIDE_FORCED_OPEN:
+		b.ForceOpen()
+	case adminpb.Override_OVERRIDE_FORCED_CLOSED:
+		b.ForceClose()
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown override %v", req.GetOverride())
+	}
+	return breakerStatus(req.GetName(), b), nil
+}
+
+// Reset resets the circuit breaker and returns its new status.
+func (s *Server) Reset(ctx context.Context, req *adminpb.ResetRequest) (*adminpb.Breaker, error) {
+	b, err := s.get(req.GetName())
+	if err != nil {
+		return nil, err
+	}
+	b.Reset()
+	return breakerStatus(req.GetName(), b), nil
+}
+
+// WatchEvents streams the transitions of the circuit breaker,
+// or of every circuit breaker registered when the stream starts,
+// until the client cancels it.
+//
+// As with circuit.Breaker.Watch, the transitions are dropped
+// for a client which doesn't keep up receiving them.
+func (s *Server) WatchEvents(req *adminpb.WatchEventsRequest, stream grpc.ServerStreamingServer[adminpb.Event]) error {
+	names := []string{req.GetName()}
+	if req.GetName() == "" {
+		names = s.r.Names()
+	}
+
+	ctx := stream.Context()
+	events := make(chan *adminpb.Event)
+	done := make(chan struct{})
+	defer close(done)
+
+	for _, name := range names {
+		b, err := s.get(name)
+		if err != nil {
+			return err
+		}
+
+		ch, stop := b.Watch()
+		defer stop()
+
+		go func(name string) {
+			for c := range ch {
+				e := &adminpb.Event{Name: name, From: states[c.From], To: states[c.To], At: timestamppb.New(c.At)}
+				select {
+				case events <- e:
+				case <-done:
+					return
+				}
+			}
+		}(name)
+	}
+
+	for {
+		select {
+		case e := <-events:
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *Server) get(name string) (*circuit.Breaker, error) {
+	b, ok := s.r.Get(name)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "circuit breaker %q not found", name)
+	}
+	return b, nil
+}
+
+// breakerStatus returns the status of the circuit breaker named as in the registry.
+func breakerStatus(name string, b *circuit.Breaker) *adminpb.Breaker {
+	st := b.Status()
+	st.Name = name
+	return toProto(st)
+}
+
+var (
+	states = map[circuit.State]adminpb.State{
+		circuit.StateClosed:   adminpb.State_STATE_CLOSED,
+		circuit.StateHalfOpen: adminpb.State_STATE_HALF_OPEN,
+		circuit.StateOpen:     adminpb.State_STATE_OPEN,
+	}
+	overrides = map[circuit.Override]adminpb.Override{
+		circuit.OverrideNone:   adminpb.Override_OVERRIDE_NONE,
+		circuit.OverrideOpen:   adminpb.Override_OVERRIDE_FORCED_OPEN,
+		circuit.OverrideClosed: adminpb.Override_OVERRIDE_FORCED_CLOSED,
+	}
+)
+
+// toProto converts a status into its message.
+func toProto(st circuit.Status) *adminpb.Breaker {
+	c := st.Counts
+	return &adminpb.Breaker{
+		Name:     st.Name,
+		State:    states[st.State],
+		Override: overrides[st.Override],
+		Disabled: st.Disabled,
+		Until:    timestamppb.New(st.Until),
+		Counts: &adminpb.Counts{
+			Total:               c.Total,
+			Failures:            c.Failures,
+			Successes:           c.Successes,
+			Rejections:          c.Rejections,
+			SlowCalls:           c.SlowCalls,
+			WindowStart:         timestamppb.New(c.WindowStart),
+			ConsecutiveFailures: c.ConsecutiveFailures,
+			FailureScore:        c.FailureScore,
+			Latency: &adminpb.Latency{
+				P50: durationpb.New(c.Latency.P50),
+				P95: durationpb.New(c.Latency.P95),
+				P99: durationpb.New(c.Latency.P99),
+			},
+			FailuresByCategory: c.FailuresByCategory,
+			LifetimeTotal:      c.LifetimeTotal,
+			LifetimeFailures:   c.LifetimeFailures,
+			LifetimeRejections: c.LifetimeRejections,
+			LifetimeBypasses:   c.LifetimeBypasses,
+			Transitions:        c.Transitions,
+		},
+		Config: &adminpb.Config{
+			Interval:    durationpb.New(st.Config.Interval),
+			Cooldown:    durationpb.New(st.Config.Cooldown),
+			AtLeastReqs: st.Config.AtLeastReqs,
+		},
+	}
+}
+
+// fromProto converts a message into its status.
+func fromProto(b *adminpb.Breaker) circuit.Status {
+	state, override := fromState(b.GetState()), fromOverride(b.GetOverride())
+	c := b.GetCounts()
+	return circuit.Status{
+		Name:     b.GetName(),
+		State:    state,
+		Override: override,
+		Disabled: b.GetDisabled(),
+		Until:    fromTimestamp(b.GetUntil()),
+		Counts: circuit.Counts{
+			State:               state,
+			Override:            override,
+			Total:               c.GetTotal(),
+			Failures:            c.GetFailures(),
+			Successes:           c.GetSuccesses(),
+			Rejections:          c.GetRejections(),
+			SlowCalls:           c.GetSlowCalls(),
+			WindowStart:         fromTimestamp(c.GetWindowStart()),
+			ConsecutiveFailures: c.GetConsecutiveFailures(),
+			FailureScore:        c.GetFailureScore(),
+			Latency: circuit.Latency{
+				P50: c.GetLatency().GetP50().AsDuration(),
+				P95: c.GetLatency().GetP95().AsDuration(),
+				P99: c.GetLatency().GetP99().AsDuration(),
+			},
+			FailuresByCategory: c.GetFailuresByCategory(),
+			LifetimeTotal:      c.GetLifetimeTotal(),
+			LifetimeFailures:   c.GetLifetimeFailures(),
+			LifetimeRejections: c.GetLifetimeRejections(),
+			LifetimeBypasses:   c.GetLifetimeBypasses(),
+			Transitions:        c.GetTransitions(),
+		},
+		Config: circuit.Config{
+			Interval:    b.GetConfig().GetInterval().AsDuration(),
+			Cooldown:    b.GetConfig().GetCooldown().AsDuration(),
+			AtLeastReqs: b.GetConfig().GetAtLeastReqs(),
+		},
+	}
+}
+
+func fromState(s adminpb.State) circuit.State {
+	for state, pb := range states {
+		if pb == s {
+			return state
+		}
+	}
+	return circuit.StateClosed
+}
+
+func fromOverride(o adminpb.Override) circuit.Override {
+	for override, pb := range overrides {
+		if pb == o {
+			return override
+		}
+	}
+	return circuit.OverrideNone
+}
+
+// fromTimestamp returns the time of a timestamp, the zero time if it's not set.
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
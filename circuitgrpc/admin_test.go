@@ -0,0 +1,152 @@
+package circuitgrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/djo/circuit/circuitgrpc/adminpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// dial serves the admin service of a given registry in-process and returns its client.
+func dial(t *testing.T, r *circuit.Registry) *Client {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	Register(s, NewServer(r))
+	reflection.Register(s)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return NewClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	r := circuit.NewRegistry()
+	payments, err := r.NewBreaker("payments", time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+	_, err = r.NewBreaker("accounts", time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+
+	c := dial(t, r)
+	ctx := context.Background()
+
+	list, err := c.ListBreakers(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, "accounts", list[0].Name)
+	assert.Equal(t, "payments", list[1].Name)
+
+	st, err := c.GetBreaker(ctx, "payments")
+	assert.NoError(t, err)
+	assert.Equal(t, "payments", st.Name)
+	assert.Equal(t, circuit.StateClosed, st.State)
+
+	_, err = c.GetBreaker(ctx, "unknown")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	st, err = c.SetOverride(ctx, "payments", circuit.OverrideOpen)
+	assert.NoError(t, err)
+	assert.Equal(t, circuit.StateOpen, st.State)
+	assert.Equal(t, circuit.OverrideOpen, st.Override)
+	assert.Equal(t, circuit.StateOpen, payments.State())
+
+	_, err = c.SetOverride(ctx, "payments", circuit.Override(42))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	st, err = c.Reset(ctx, "payments")
+	assert.NoError(t, err)
+	assert.Equal(t, circuit.StateClosed, st.State)
+	assert.Equal(t, circuit.OverrideNone, st.Override)
+}
+
+func TestServerWatchEvents(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	r := circuit.NewRegistry()
+	payments, err := r.NewBreaker("payments", time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+
+	c := dial(t, r)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := c.WatchEvents(ctx, "")
+	assert.NoError(t, err)
+
+	// the server subscribes asynchronously, retry until the trip is streamed
+	var opened *Event
+	assert.Eventually(t, func() bool {
+		payments.Trip()
+		select {
+		case e := <-events:
+			if e.To == circuit.StateOpen {
+				opened = e
+				return true
+			}
+		case <-time.After(10 * time.Millisecond):
+		}
+		payments.Reset()
+		return false
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, "payments", opened.Name)
+	assert.Equal(t, circuit.StateClosed, opened.From)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestStatusProto(t *testing.T) {
+	st := circuit.Status{
+		Name:     "payments",
+		State:    circuit.StateOpen,
+		Override: circuit.OverrideOpen,
+		Until:    time.Unix(1520100060, 0).UTC(),
+		Counts: circuit.Counts{
+			State:              circuit.StateOpen,
+			Override:           circuit.OverrideOpen,
+			Total:              10,
+			Failures:           6,
+			Successes:          4,
+			WindowStart:        time.Unix(1520100000, 0).UTC(),
+			Latency:            circuit.Latency{P50: 12 * time.Millisecond, P95: 80 * time.Millisecond, P99: 1200 * time.Millisecond},
+			FailuresByCategory: map[string]uint32{"timeout": 4, "unavailable": 2},
+			LifetimeBypasses:   1,
+			Transitions:        2,
+		},
+		Config: circuit.Config{Interval: time.Minute, Cooldown: 10 * time.Second, AtLeastReqs: 1},
+	}
+
+	data, err := proto.Marshal(toProto(st))
+	assert.NoError(t, err)
+	b := new(adminpb.Breaker)
+	assert.NoError(t, proto.Unmarshal(data, b))
+	assert.Equal(t, st, fromProto(b))
+}
+
+func TestServiceDescriptor(t *testing.T) {
+	// served by the reflection for grpcurl
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(ServiceName)
+	assert.NoError(t, err)
+	methods := d.(protoreflect.ServiceDescriptor).Methods()
+	assert.Equal(t, 5, methods.Len())
+	assert.True(t, methods.ByName("WatchEvents").IsStreamingServer())
+}
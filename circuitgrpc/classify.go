@@ -0,0 +1,95 @@
+package circuitgrpc
+
+import (
+	"context"
+
+	"github.com/djo/circuit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CodeClassifier maps the status codes of the calls to the outcomes of the circuit breaker,
+// the codes missing in it are failures. It's customized by changing the default one:
+//
+//	c := circuitgrpc.DefaultCodeClassifier()
+//	c[codes.NotFound] = circuit.OutcomeSuccess
+//	conn, err := grpc.NewClient(target, grpc.WithUnaryInterceptor(circuitgrpc.UnaryClientInterceptor(b, c.Classify)))
+type CodeClassifier map[codes.Code]circuit.Outcome
+
+// DefaultCodeClassifier returns a new code classifier counting the codes telling
+// about a failing or overloaded dependency, e.g. Unavailable and DeadlineExceeded, as failures
+// and ignoring the ones of the caller's fault, e.g. InvalidArgument and NotFound, or cancellation.
+func DefaultCodeClassifier() CodeClassifier {
+	return CodeClassifier{
+		codes.OK:                 circuit.OutcomeSuccess,
+		codes.Canceled:           circuit.OutcomeIgnored,
+		codes.Unknown:            circuit.OutcomeFailure,
+		codes.InvalidArgument:    circuit.OutcomeIgnored,
+		codes.DeadlineExceeded:   circuit.OutcomeFailure,
+		codes.NotFound:           circuit.OutcomeIgnored,
+		codes.AlreadyExists:      circuit.OutcomeIgnored,
+		codes.PermissionDenied:   circuit.OutcomeIgnored,
+		codes.ResourceExhausted:  circuit.OutcomeFailure,
+		codes.FailedPrecondition: circuit.OutcomeIgnored,
+		codes.Aborted:            circuit.OutcomeIgnored,
+		codes.OutOfRange:         circuit.OutcomeIgnored,
+		codes.Unimplemented:      circuit.OutcomeIgnored,
+		codes.Internal:           circuit.OutcomeFailure,
+		codes.Unavailable:        circuit.OutcomeFailure,
+		codes.DataLoss:           circuit.OutcomeFailure,
+		codes.Unauthenticated:    circuit.OutcomeIgnored,
+	}
+}
+
+// Classify returns the outcome of a call returned err by its status code.
+func (c CodeClassifier) Classify(err error) circuit.Outcome {
+	if o, ok := c[status.Code(err)]; ok {
+		return o
+	}
+	return circuit.OutcomeFailure
+}
+
+// UnaryClientInterceptor returns a client interceptor guarding the unary calls
+// with a given circuit breaker, it returns circuit.ErrBreakerOpen without making the call
+// when the circuit breaker doesn't accept it.
+//
+// The outcomes are decided by classify, DefaultCodeClassifier if it's nil.
+// A call given up by the caller, its context is done, is not counted.
+func UnaryClientInterceptor(b *circuit.Breaker, classify func(err error) circuit.Outcome) grpc.UnaryClientInterceptor {
+	return unaryClientInterceptor(func(context.Context, string) *circuit.Breaker { return b }, classify)
+}
+
+// GroupUnaryClientInterceptor returns a client interceptor like UnaryClientInterceptor does
+// guarding the unary calls with the circuit breakers of a given group for the keys of the calls,
+// e.g. per method with KeyByMethod.
+func GroupUnaryClientInterceptor(g *circuit.Group, classify func(err error) circuit.Outcome) grpc.UnaryClientInterceptor {
+	return unaryClientInterceptor(func(ctx context.Context, method string) *circuit.Breaker { return g.For(ctx, method) }, classify)
+}
+
+// KeyByMethod is a circuit.KeyFunc returning the full method name of a call.
+func KeyByMethod(ctx context.Context, req any) string {
+	method, _ := req.(string)
+	return method
+}
+
+func unaryClientInterceptor(breaker func(ctx context.Context, method string) *circuit.Breaker, classify func(err error) circuit.Outcome) grpc.UnaryClientInterceptor {
+	if classify == nil {
+		classify = DefaultCodeClassifier().Classify
+	}
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		tok, err := breaker(ctx, method).Allow()
+		if err != nil {
+			return err
+		}
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		if err != nil && ctx.Err() != nil {
+			tok.Ignore()
+		} else {
+			tok.Record(classify(err))
+		}
+		return err
+	}
+}
@@ -0,0 +1,95 @@
+package circuitgrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCodeClassifier_Classify(t *testing.T) {
+	c := DefaultCodeClassifier()
+	assert.Equal(t, circuit.OutcomeSuccess, c.Classify(nil))
+	assert.Equal(t, circuit.OutcomeFailure, c.Classify(status.Errorf(codes.Unavailable, "unavailable")))
+	assert.Equal(t, circuit.OutcomeFailure, c.Classify(status.Errorf(codes.DeadlineExceeded, "deadline")))
+	assert.Equal(t, circuit.OutcomeIgnored, c.Classify(status.Errorf(codes.InvalidArgument, "invalid")))
+	assert.Equal(t, circuit.OutcomeIgnored, c.Classify(status.Errorf(codes.NotFound, "not found")))
+	assert.Equal(t, circuit.OutcomeFailure, c.Classify(errors.New("not a status")))
+
+	// customized, the missing codes are failures
+	c[codes.NotFound] = circuit.OutcomeSuccess
+	delete(c, codes.PermissionDenied)
+	assert.Equal(t, circuit.OutcomeSuccess, c.Classify(status.Errorf(codes.NotFound, "not found")))
+	assert.Equal(t, circuit.OutcomeFailure, c.Classify(status.Errorf(codes.PermissionDenied, "denied")))
+	assert.Equal(t, circuit.OutcomeIgnored, DefaultCodeClassifier().Classify(status.Errorf(codes.PermissionDenied, "denied")))
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	var calls int
+	code := codes.OK
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		if code == codes.OK {
+			return nil
+		}
+		return status.Errorf(code, "failed")
+	}
+	intercept := UnaryClientInterceptor(b, nil)
+	call := func(ctx context.Context) error {
+		return intercept(ctx, "/payments.Payments/Charge", nil, nil, nil, invoker)
+	}
+
+	assert.NoError(t, call(context.Background()))
+	code = codes.NotFound
+	assert.Error(t, call(context.Background()))
+	counts := b.Counts()
+	assert.Equal(t, uint32(1), counts.Total)
+	assert.Equal(t, uint32(1), counts.Successes)
+
+	// given up by the caller
+	code = codes.Unavailable
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, call(ctx))
+	assert.Equal(t, uint32(0), b.Counts().Failures)
+
+	assert.Error(t, call(context.Background()))
+	assert.Error(t, call(context.Background()))
+	assert.Equal(t, circuit.StateOpen, b.State())
+
+	// doesn't make the call
+	assert.ErrorIs(t, call(context.Background()), circuit.ErrBreakerOpen)
+	assert.Equal(t, 5, calls)
+}
+
+func TestGroupUnaryClientInterceptor(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	g, err := circuit.NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+	g.SetKeyFunc(KeyByMethod)
+
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if method == "/payments.Payments/Charge" {
+			return status.Errorf(codes.Unavailable, "failed")
+		}
+		return nil
+	}
+	intercept := GroupUnaryClientInterceptor(g, nil)
+
+	assert.Error(t, intercept(context.Background(), "/payments.Payments/Charge", nil, nil, nil, invoker))
+	assert.ErrorIs(t, intercept(context.Background(), "/payments.Payments/Charge", nil, nil, nil, invoker), circuit.ErrBreakerOpen)
+	assert.NoError(t, intercept(context.Background(), "/payments.Payments/Refund", nil, nil, nil, invoker))
+	assert.Equal(t, []string{"/payments.Payments/Charge", "/payments.Payments/Refund"}, g.Keys())
+}
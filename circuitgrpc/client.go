@@ -0,0 +1,95 @@
+package circuitgrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/djo/circuit/circuitgrpc/adminpb"
+	"google.golang.org/grpc"
+)
+
+// Client calls the admin service converting its messages into the statuses of the circuit breakers.
+type Client struct {
+	c adminpb.AdminClient
+}
+
+// Event is a transition of a circuit breaker received by WatchEvents.
+type Event struct {
+	Name string
+	From circuit.State
+	To   circuit.State
+	At   time.Time
+}
+
+// NewClient returns a client of the admin service over a given connection.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{c: adminpb.NewAdminClient(cc)}
+}
+
+// ListBreakers returns the status of every circuit breaker.
+func (c *Client) ListBreakers(ctx context.Context, opts ...grpc.CallOption) ([]circuit.Status, error) {
+	resp, err := c.c.ListBreakers(ctx, &adminpb.ListBreakersRequest{}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sts := make([]circuit.Status, 0, len(resp.GetBreakers()))
+	for _, b := range resp.GetBreakers() {
+		sts = append(sts, fromProto(b))
+	}
+	return sts, nil
+}
+
+// GetBreaker returns the status of the circuit breaker.
+func (c *Client) GetBreaker(ctx context.Context, name string, opts ...grpc.CallOption) (circuit.Status, error) {
+	return toStatus(c.c.GetBreaker(ctx, &adminpb.GetBreakerRequest{Name: name}, opts...))
+}
+
+// SetOverride forces the state of the circuit breaker or clears the override
+// and returns its new status.
+func (c *Client) SetOverride(ctx context.Context, name string, o circuit.Override, opts ...grpc.CallOption) (circuit.Status, error) {
+	return toStatus(c.c.SetOverride(ctx, &adminpb.SetOverrideRequest{Name: name, Override: overrides[o]}, opts...))
+}
+
+// Reset resets the circuit breaker and returns its new status.
+func (c *Client) Reset(ctx context.Context, name string, opts ...grpc.CallOption) (circuit.Status, error) {
+	return toStatus(c.c.Reset(ctx, &adminpb.ResetRequest{Name: name}, opts...))
+}
+
+// WatchEvents returns a channel receiving the transitions of the circuit breaker,
+// or of every circuit breaker if the name is empty.
+// The channel is closed once the stream ends, e.g. when ctx is canceled.
+func (c *Client) WatchEvents(ctx context.Context, name string, opts ...grpc.CallOption) (<-chan *Event, error) {
+	stream, err := c.c.WatchEvents(ctx, &adminpb.WatchEventsRequest{Name: name}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *Event)
+	go func() {
+		defer close(ch)
+		for {
+			e, err := stream.Recv()
+			if err != nil {
+				return
+			}
+
+			ev := &Event{Name: e.GetName(), From: fromState(e.GetFrom()), To: fromState(e.GetTo()), At: fromTimestamp(e.GetAt())}
+			select {
+			case ch <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// toStatus converts the response of a call returning the status of a circuit breaker.
+func toStatus(b *adminpb.Breaker, err error) (circuit.Status, error) {
+	if err != nil {
+		return circuit.Status{}, err
+	}
+	return fromProto(b), nil
+}
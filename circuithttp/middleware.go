@@ -0,0 +1,84 @@
+package circuithttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/djo/circuit"
+)
+
+// Middleware returns a function wrapping an http.Handler with a given circuit breaker
+// to shed the inbound load of an overloaded service.
+//
+// The 5xx responses and panics of the handler are counted as failures.
+// When the circuit breaker doesn't accept the request,
+// it's responded with 503 Service Unavailable and the Retry-After header
+// set to the time left until the cooldown ends if known, see circuit.OpenError.
+func Middleware(b *circuit.Breaker, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tok, err := b.Allow()
+			if err != nil {
+				retryAfter := o.retryAfter
+				var oe *circuit.OpenError
+				if errors.As(err, &oe) && oe.RetryAfter > 0 {
+					retryAfter = oe.RetryAfter
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(seconds(retryAfter)))
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				if !completed || sw.status >= http.StatusInternalServerError {
+					tok.Failure()
+				} else {
+					tok.Success()
+				}
+			}()
+
+			next.ServeHTTP(sw, r)
+			completed = true
+		})
+	}
+}
+
+// seconds returns d in whole seconds rounded up, at least one.
+func seconds(d time.Duration) int {
+	s := int((d + time.Second - 1) / time.Second)
+	if s < 1 {
+		return 1
+	}
+	return s
+}
+
+// statusWriter records the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the original http.ResponseWriter for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,77 @@
+package circuithttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	status := http.StatusOK
+	var hits int
+	h := Middleware(b, WithRetryAfter(1500*time.Millisecond))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if status == http.StatusOK {
+			w.Write([]byte("ok"))
+			return
+		}
+		w.WriteHeader(status)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+	assert.Equal(t, uint32(1), b.Counts().Successes)
+
+	status = http.StatusInternalServerError
+	for i := 0; i < 2; i++ {
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	}
+	assert.Equal(t, circuit.StateOpen, b.State())
+
+	// shed without calling the handler
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, 3, hits)
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	h := Middleware(b)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.Panics(t, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Equal(t, circuit.StateOpen, b.State())
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// unknown while forced open
+	b.ForceOpen()
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
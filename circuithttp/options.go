@@ -0,0 +1,58 @@
+package circuithttp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/djo/circuit"
+)
+
+type options struct {
+	isFailure  func(*http.Response, error) bool
+	classify   func(*http.Response, error) circuit.Outcome
+	retryAfter time.Duration
+}
+
+// Option configures the optional behavior of the transport and the middleware.
+type Option func(*options)
+
+func newOptions(opts []Option) options {
+	o := options{
+		isFailure:  isFailure,
+		retryAfter: time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithFailure sets the function deciding whether a round trip of the transport failed,
+// by default the network errors and 5xx responses are failures.
+func WithFailure(isFailure func(resp *http.Response, err error) bool) Option {
+	return func(o *options) {
+		o.isFailure = isFailure
+	}
+}
+
+// WithClassifier sets the function deciding on the outcome of a round trip of the transport,
+// e.g. StatusClassifier.Classify, it takes precedence over WithFailure.
+func WithClassifier(classify func(resp *http.Response, err error) circuit.Outcome) Option {
+	return func(o *options) {
+		o.classify = classify
+	}
+}
+
+// WithRetryAfter sets the value of the Retry-After header
+// the middleware responds with when the circuit breaker rejects a request
+// and the time left until the cooldown ends is unknown,
+// e.g. in the half-open state, one second by default.
+func WithRetryAfter(d time.Duration) Option {
+	return func(o *options) {
+		o.retryAfter = d
+	}
+}
+
+func isFailure(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,96 @@
+package circuithttp
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/djo/circuit"
+)
+
+// StatusClassifier decides on the outcomes of the round trips by the status codes of the responses:
+// the network errors, 5xx and 429 Too Many Requests are failures, the other statuses,
+// 4xx included, are successes, the caller's fault doesn't tell about the dependency.
+//
+// It follows the Retry-After header of the 429 and 503 responses
+// with the cooldown of the circuit breaker, see Cooldown:
+//
+//	c := circuithttp.NewStatusClassifier(circuithttp.WithStatusOutcome(http.StatusTooManyRequests, circuit.OutcomeCritical))
+//	b, err := circuit.NewBreaker(time.Minute, 10*time.Second, 1, toOpen, toClosed, circuit.WithCooldownFunc(c.Cooldown))
+//	client := &http.Client{Transport: circuithttp.NewTransport(nil, b, circuithttp.WithClassifier(c.Classify))}
+type StatusClassifier struct {
+	outcomes map[int]circuit.Outcome
+	retryAt  int64 // unix nanoseconds until which the dependency asked to retry after, zero if not known
+	now      func() time.Time
+}
+
+// StatusOption configures the status classifier.
+type StatusOption func(*StatusClassifier)
+
+// WithStatusOutcome overrides the outcome of the responses with a given status code,
+// e.g. circuit.OutcomeCritical for 429 to open the circuit breaker on the first one
+// or circuit.OutcomeIgnored for 404.
+func WithStatusOutcome(status int, o circuit.Outcome) StatusOption {
+	return func(c *StatusClassifier) {
+		c.outcomes[status] = o
+	}
+}
+
+// NewStatusClassifier returns a new status classifier with the outcomes
+// overridden by the given options.
+func NewStatusClassifier(opts ...StatusOption) *StatusClassifier {
+	c := &StatusClassifier{outcomes: make(map[int]circuit.Outcome), now: time.Now}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Classify returns the outcome of a round trip returned resp and err
+// and records the Retry-After header of the response if any.
+func (c *StatusClassifier) Classify(resp *http.Response, err error) circuit.Outcome {
+	if err != nil {
+		return circuit.OutcomeFailure
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After"), c.now()); ok {
+			atomic.StoreInt64(&c.retryAt, c.now().Add(d).UnixNano())
+		}
+	}
+
+	if o, ok := c.outcomes[resp.StatusCode]; ok {
+		return o
+	}
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return circuit.OutcomeFailure
+	}
+	return circuit.OutcomeSuccess
+}
+
+// Cooldown returns the time left until the dependency asked to retry after
+// with the last Retry-After header, non-positive if it has passed or is unknown,
+// to be used with circuit.WithCooldownFunc, which keeps the fixed cooldown then.
+func (c *StatusClassifier) Cooldown(openCount int, lastCounts circuit.Counts) time.Duration {
+	at := atomic.LoadInt64(&c.retryAt)
+	if at == 0 {
+		return 0
+	}
+	return time.Unix(0, at).Sub(c.now())
+}
+
+// retryAfter parses the value of the Retry-After header,
+// either the delay in seconds or the HTTP date.
+func retryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		return time.Duration(s) * time.Second, s >= 0
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t.Sub(now), true
+	}
+	return 0, false
+}
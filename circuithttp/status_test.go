@@ -0,0 +1,82 @@
+package circuithttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+func response(status int, retryAfter string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: make(http.Header)}
+	if retryAfter != "" {
+		resp.Header.Set("Retry-After", retryAfter)
+	}
+	return resp
+}
+
+func TestStatusClassifier_Classify(t *testing.T) {
+	c := NewStatusClassifier(WithStatusOutcome(http.StatusNotFound, circuit.OutcomeIgnored))
+
+	assert.Equal(t, circuit.OutcomeFailure, c.Classify(nil, errors.New("connection refused")))
+	assert.Equal(t, circuit.OutcomeSuccess, c.Classify(response(http.StatusOK, ""), nil))
+	assert.Equal(t, circuit.OutcomeSuccess, c.Classify(response(http.StatusBadRequest, ""), nil))
+	assert.Equal(t, circuit.OutcomeIgnored, c.Classify(response(http.StatusNotFound, ""), nil))
+	assert.Equal(t, circuit.OutcomeFailure, c.Classify(response(http.StatusTooManyRequests, ""), nil))
+	assert.Equal(t, circuit.OutcomeFailure, c.Classify(response(http.StatusBadGateway, ""), nil))
+
+	c = NewStatusClassifier(WithStatusOutcome(http.StatusTooManyRequests, circuit.OutcomeCritical))
+	assert.Equal(t, circuit.OutcomeCritical, c.Classify(response(http.StatusTooManyRequests, ""), nil))
+}
+
+func TestStatusClassifier_Cooldown(t *testing.T) {
+	now := time.Date(2018, 3, 3, 18, 0, 0, 0, time.UTC)
+	c := NewStatusClassifier()
+	c.now = func() time.Time { return now }
+	assert.Zero(t, c.Cooldown(1, circuit.Counts{}))
+
+	// in seconds
+	c.Classify(response(http.StatusTooManyRequests, "120"), nil)
+	assert.Equal(t, 2*time.Minute, c.Cooldown(1, circuit.Counts{}))
+
+	// as the HTTP date
+	c.Classify(response(http.StatusServiceUnavailable, now.Add(30*time.Second).Format(http.TimeFormat)), nil)
+	assert.Equal(t, 30*time.Second, c.Cooldown(1, circuit.Counts{}))
+
+	// not on the other statuses, invalid ones are skipped
+	c.Classify(response(http.StatusInternalServerError, "600"), nil)
+	c.Classify(response(http.StatusTooManyRequests, "soon"), nil)
+	c.Classify(response(http.StatusTooManyRequests, "-1"), nil)
+	assert.Equal(t, 30*time.Second, c.Cooldown(1, circuit.Counts{}))
+
+	// passed
+	now = now.Add(time.Minute)
+	assert.True(t, c.Cooldown(1, circuit.Counts{}) <= 0)
+}
+
+func TestTransport_WithClassifier(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "300")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := NewStatusClassifier(WithStatusOutcome(http.StatusTooManyRequests, circuit.OutcomeCritical))
+	toOpen := func(total uint32, failures uint32) bool { return false }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed, circuit.WithCooldownFunc(c.Cooldown))
+	assert.NoError(t, err)
+
+	client := &http.Client{Transport: NewTransport(nil, b, WithClassifier(c.Classify))}
+	resp, err := client.Get(srv.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	// opened on the first one for the time the server asked for
+	assert.Equal(t, circuit.StateOpen, b.State())
+	assert.InDelta(t, 300, b.RetryAfter().Seconds(), 1)
+}
@@ -0,0 +1,83 @@
+// Package circuithttp guards HTTP clients and servers with circuit breakers.
+package circuithttp
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/djo/circuit"
+)
+
+// Transport is an http.RoundTripper guarded by a circuit breaker,
+// it returns circuit.ErrBreakerOpen without hitting the wire
+// when the circuit breaker doesn't accept the request.
+type Transport struct {
+	base http.RoundTripper
+	b    *circuit.Breaker
+	g    *circuit.Group // circuit breakers by the keys of the requests if set instead of b
+	opts options
+}
+
+// NewTransport returns a new transport making the round trips with base
+// guarded by a given circuit breaker, http.DefaultTransport is used if base is nil.
+func NewTransport(base http.RoundTripper, b *circuit.Breaker, opts ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{base: base, b: b, opts: newOptions(opts)}
+}
+
+// NewGroupTransport returns a new transport like NewTransport does
+// guarded by the circuit breakers of a given group for the keys of the requests,
+// e.g. per host with KeyByHost:
+//
+//	g.SetKeyFunc(circuithttp.KeyByHost)
+//	client := &http.Client{Transport: circuithttp.NewGroupTransport(nil, g)}
+func NewGroupTransport(base http.RoundTripper, g *circuit.Group, opts ...Option) *Transport {
+	t := NewTransport(base, nil, opts...)
+	t.g = g
+	return t
+}
+
+// KeyByHost is a circuit.KeyFunc returning the host of an *http.Request.
+func KeyByHost(ctx context.Context, req any) string {
+	if r, ok := req.(*http.Request); ok {
+		return r.URL.Host
+	}
+	return ""
+}
+
+// RoundTrip implements http.RoundTripper.
+//
+// A round trip given up by the caller, its request's context is done,
+// is not counted by the circuit breaker.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	b := t.b
+	if t.g != nil {
+		b = t.g.For(req.Context(), req)
+	}
+
+	tok, err := b.Allow()
+	if err != nil {
+		// the RoundTripper closes the body even on errors
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		tok.Ignore()
+	case t.opts.classify != nil:
+		tok.Record(t.opts.classify(resp, err))
+	case t.opts.isFailure(resp, err):
+		tok.Failure()
+	default:
+		tok.Success()
+	}
+	return resp, err
+}
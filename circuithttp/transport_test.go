@@ -0,0 +1,156 @@
+package circuithttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport(t *testing.T) {
+	status := http.StatusOK
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	client := &http.Client{Transport: NewTransport(nil, b)}
+
+	resp, err := client.Get(srv.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, circuit.Counts{State: circuit.StateClosed, Total: 1, Successes: 1, LifetimeTotal: 1}, withoutStart(b.Counts()))
+
+	// 4xx is not a failure of the server
+	status = http.StatusNotFound
+	resp, err = client.Get(srv.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, uint32(0), b.Counts().Failures)
+
+	status = http.StatusServiceUnavailable
+	for i := 0; i < 2; i++ {
+		resp, err = client.Get(srv.URL)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, circuit.StateOpen, b.State())
+	assert.Equal(t, 4, hits)
+
+	// doesn't hit the wire
+	_, err = client.Get(srv.URL)
+	assert.True(t, errors.Is(err, circuit.ErrBreakerOpen))
+	assert.Equal(t, 4, hits)
+
+	// closes the body of the rejected request
+	body := &closeBody{}
+	req, err := http.NewRequest(http.MethodPost, srv.URL, body)
+	assert.NoError(t, err)
+	_, err = client.Do(req)
+	assert.True(t, errors.Is(err, circuit.ErrBreakerOpen))
+	assert.True(t, body.closed)
+}
+
+type closeBody struct {
+	closed bool
+}
+
+func (b *closeBody) Read(p []byte) (int, error) { return 0, io.EOF }
+
+func (b *closeBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestTransport_NetworkError(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	failed := errors.New("connection refused")
+	base := roundTripper(func(*http.Request) (*http.Response, error) { return nil, failed })
+	client := &http.Client{Transport: NewTransport(base, b)}
+
+	// given up by the caller
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	_, err = client.Do(req)
+	assert.Error(t, err)
+	assert.Equal(t, uint32(0), b.Counts().Total)
+
+	_, err = client.Get("http://example.com")
+	assert.True(t, errors.Is(err, failed))
+	assert.Equal(t, circuit.StateOpen, b.State())
+}
+
+func TestTransport_WithFailure(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	base := roundTripper(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Body: http.NoBody}, nil
+	})
+	isFailure := func(resp *http.Response, err error) bool {
+		return err != nil || resp.StatusCode == http.StatusTooManyRequests
+	}
+	client := &http.Client{Transport: NewTransport(base, b, WithFailure(isFailure))}
+
+	resp, err := client.Get("http://example.com")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, circuit.StateOpen, b.State())
+}
+
+func TestGroupTransport(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	g, err := circuit.NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+	g.SetKeyFunc(KeyByHost)
+
+	base := roundTripper(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "a.example.com" {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	client := &http.Client{Transport: NewGroupTransport(base, g)}
+
+	_, err = client.Get("http://a.example.com")
+	assert.Error(t, err)
+	_, err = client.Get("http://a.example.com")
+	assert.ErrorIs(t, err, circuit.ErrBreakerOpen)
+
+	resp, err := client.Get("http://b.example.com")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, g.Keys())
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func withoutStart(c circuit.Counts) circuit.Counts {
+	c.WindowStart = time.Time{}
+	return c
+}
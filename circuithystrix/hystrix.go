@@ -0,0 +1,143 @@
+// Package circuithystrix translates the Hystrix settings of the commands
+// into the circuit breakers and bulkheads of this module,
+// easing the migration from hystrix-go.
+//
+// A Hystrix command maps to a circuit breaker wrapped in a bulkhead:
+//
+//	cfg := circuithystrix.CommandConfig{Timeout: 500, ErrorPercentThreshold: 25}
+//	b, err := circuithystrix.ConfigureCommand(r, "payments", cfg)
+//	bh, err := circuithystrix.NewBulkhead(cfg)
+//	command := circuit.Chain(bh, b)
+//
+//	err = command.Execute(req)
+package circuithystrix
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/djo/circuit"
+)
+
+// The defaults of hystrix-go used for the zero fields of CommandConfig.
+const (
+	DefaultTimeout                  = 1000 // ms
+	DefaultMaxConcurrentRequests    = 10
+	DefaultRequestVolumeThreshold   = 20
+	DefaultSleepWindow              = 5000  // ms
+	DefaultErrorPercentThreshold    = 50    // %
+	DefaultRollingStatisticalWindow = 10000 // ms
+)
+
+// rollingBuckets is the # of the buckets of the rolling window as in Hystrix.
+const rollingBuckets = 10
+
+// CommandConfig is the configuration of a Hystrix command
+// with the fields of hystrix-go's CommandConfig and the rolling statistical window,
+// the zero fields take the hystrix-go defaults.
+type CommandConfig struct {
+	Timeout                  int `json:"timeout"`                    // ms, max duration of a request
+	MaxConcurrentRequests    int `json:"max_concurrent_requests"`    // # of requests in flight
+	RequestVolumeThreshold   int `json:"request_volume_threshold"`   // min # of requests in the window to open
+	SleepWindow              int `json:"sleep_window"`               // ms, the cooldown before a trial request
+	ErrorPercentThreshold    int `json:"error_percent_threshold"`    // % of failed requests in the window to open
+	RollingStatisticalWindow int `json:"rolling_statistical_window"` // ms, the window of the statistics
+}
+
+func (c CommandConfig) withDefaults() CommandConfig {
+	def := func(v *int, d int) {
+		if *v == 0 {
+			*v = d
+		}
+	}
+	def(&c.Timeout, DefaultTimeout)
+	def(&c.MaxConcurrentRequests, DefaultMaxConcurrentRequests)
+	def(&c.RequestVolumeThreshold, DefaultRequestVolumeThreshold)
+	def(&c.SleepWindow, DefaultSleepWindow)
+	def(&c.ErrorPercentThreshold, DefaultErrorPercentThreshold)
+	def(&c.RollingStatisticalWindow, DefaultRollingStatisticalWindow)
+	return c
+}
+
+func (c CommandConfig) validate() error {
+	for _, f := range []struct {
+		name string
+		v    int
+	}{
+		{"Timeout", c.Timeout},
+		{"MaxConcurrentRequests", c.MaxConcurrentRequests},
+		{"RequestVolumeThreshold", c.RequestVolumeThreshold},
+		{"SleepWindow", c.SleepWindow},
+		{"ErrorPercentThreshold", c.ErrorPercentThreshold},
+		{"RollingStatisticalWindow", c.RollingStatisticalWindow},
+	} {
+		if f.v < 0 {
+			return fmt.Errorf("circuithystrix: %s must not be negative, got %d", f.name, f.v)
+		}
+	}
+	if c.ErrorPercentThreshold > 100 {
+		return fmt.Errorf("circuithystrix: ErrorPercentThreshold must be at most 100, got %d", c.ErrorPercentThreshold)
+	}
+	return nil
+}
+
+// NewBreaker returns a circuit breaker behaving as the circuit breaker of a Hystrix command:
+//
+//   - it opens once ErrorPercentThreshold of the requests failed
+//     and there are at least RequestVolumeThreshold of them
+//     in the RollingStatisticalWindow rolling in 10 buckets
+//   - it lets a single trial request through after the SleepWindow
+//     and closes if it succeeded
+//   - a request is failed with circuit.ErrExecutionTimeout after the Timeout
+//
+// The MaxConcurrentRequests are limited by the bulkhead of NewBulkhead.
+// The options are applied on top, e.g. circuit.WithName.
+func NewBreaker(cfg CommandConfig, opts ...circuit.Option) (*circuit.Breaker, error) {
+	c, all, err := translate(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	return circuit.NewBreaker(c.Interval, c.Cooldown, c.AtLeastReqs, c.ToOpen, c.ToClosed, all...)
+}
+
+// ConfigureCommand does the same as NewBreaker adding the circuit breaker to a registry by name,
+// as hystrix.ConfigureCommand does.
+func ConfigureCommand(r *circuit.Registry, name string, cfg CommandConfig, opts ...circuit.Option) (*circuit.Breaker, error) {
+	c, all, err := translate(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	return r.NewBreaker(name, c.Interval, c.Cooldown, c.AtLeastReqs, c.ToOpen, c.ToClosed, all...)
+}
+
+// NewBulkhead returns a bulkhead limiting the requests in flight
+// to the MaxConcurrentRequests of a Hystrix command,
+// the excess ones are rejected with circuit.ErrBulkheadFull without queueing.
+func NewBulkhead(cfg CommandConfig) (*circuit.Bulkhead, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return circuit.NewBulkhead(cfg.withDefaults().MaxConcurrentRequests)
+}
+
+// translate returns the configuration and the options of the circuit breaker of a Hystrix command.
+func translate(cfg CommandConfig, opts []circuit.Option) (circuit.Config, []circuit.Option, error) {
+	if err := cfg.validate(); err != nil {
+		return circuit.Config{}, nil, err
+	}
+	cfg = cfg.withDefaults()
+
+	c := circuit.Config{
+		Interval:    time.Duration(cfg.RollingStatisticalWindow) * time.Millisecond,
+		Cooldown:    time.Duration(cfg.SleepWindow) * time.Millisecond,
+		AtLeastReqs: 1,
+		ToOpen:      circuit.RateThreshold(float64(cfg.ErrorPercentThreshold)/100, uint32(cfg.RequestVolumeThreshold)),
+		ToClosed:    func(total, failures uint32) bool { return failures == 0 },
+	}
+	all := []circuit.Option{
+		circuit.WithRollingWindow(rollingBuckets),
+		circuit.WithMaxHalfOpenProbes(1),
+		circuit.WithExecutionTimeout(time.Duration(cfg.Timeout) * time.Millisecond),
+	}
+	return c, append(all, opts...), nil
+}
@@ -0,0 +1,79 @@
+package circuithystrix
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBreaker(t *testing.T) {
+	b, err := NewBreaker(CommandConfig{RequestVolumeThreshold: 4, ErrorPercentThreshold: 50, SleepWindow: 20})
+	assert.NoError(t, err)
+
+	cfg := b.Config()
+	assert.Equal(t, 10*time.Second, cfg.Interval)
+	assert.Equal(t, 20*time.Millisecond, cfg.Cooldown)
+	assert.Equal(t, uint32(1), cfg.AtLeastReqs)
+
+	// below the request volume
+	failed := errors.New("failed")
+	b.Execute(func() error { return nil })
+	b.Execute(func() error { return failed })
+	b.Execute(func() error { return failed })
+	assert.Equal(t, circuit.StateClosed, b.State())
+
+	// 3 of 4 failed
+	b.Execute(func() error { return failed })
+	assert.Equal(t, circuit.StateOpen, b.State())
+
+	// a single trial request after the sleep window, closed by the next request
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, circuit.StateHalfOpen, b.State())
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, circuit.StateClosed, b.State())
+}
+
+func TestNewBreaker_Timeout(t *testing.T) {
+	b, err := NewBreaker(CommandConfig{Timeout: 10}, circuit.WithName("payments"))
+	assert.NoError(t, err)
+	assert.Equal(t, "payments", b.Name())
+
+	err = b.Execute(func() error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	assert.Equal(t, circuit.ErrExecutionTimeout, err)
+	assert.Equal(t, uint32(1), b.Counts().Failures)
+}
+
+func TestConfigureCommand(t *testing.T) {
+	r := circuit.NewRegistry()
+	b, err := ConfigureCommand(r, "payments", CommandConfig{})
+	assert.NoError(t, err)
+	got, ok := r.Get("payments")
+	assert.True(t, ok)
+	assert.Same(t, b, got)
+
+	_, err = ConfigureCommand(r, "accounts", CommandConfig{ErrorPercentThreshold: 120})
+	assert.EqualError(t, err, "circuithystrix: ErrorPercentThreshold must be at most 100, got 120")
+	_, err = ConfigureCommand(r, "accounts", CommandConfig{SleepWindow: -1})
+	assert.EqualError(t, err, "circuithystrix: SleepWindow must not be negative, got -1")
+}
+
+func TestNewBulkhead(t *testing.T) {
+	bh, err := NewBulkhead(CommandConfig{MaxConcurrentRequests: 1})
+	assert.NoError(t, err)
+
+	release := make(chan struct{})
+	go bh.Execute(func() error {
+		<-release
+		return nil
+	})
+	assert.Eventually(t, func() bool { return bh.InFlight() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, circuit.ErrBulkheadFull, bh.Execute(func() error { return nil }))
+	close(release)
+}
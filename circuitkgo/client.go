@@ -0,0 +1,225 @@
+// Package circuitkgo guards the franz-go Kafka clients with circuit breakers.
+package circuitkgo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Client guards the produces and the fetches of a kgo.Client with the circuit breakers
+// of a group keyed by topic. While the circuit breaker of a topic is open
+// its records fail with circuit.ErrBreakerOpen without being produced
+// and its fetches are paused till it leaves the open state, instead of spinning on a dead broker.
+// The client must be closed with Close.
+type Client struct {
+	cl client
+	g  *circuit.Group
+
+	mu      sync.Mutex
+	watched map[string]bool // topics watched by their circuit breakers, whether they're paused
+
+	done  chan struct{} // closed by Close
+	close sync.Once
+	wg    sync.WaitGroup // watches of the topics
+}
+
+// client is the part of *kgo.Client guarded by Client.
+type client interface {
+	Produce(ctx context.Context, r *kgo.Record, promise func(*kgo.Record, error))
+	PollFetches(ctx context.Context) kgo.Fetches
+	PollRecords(ctx context.Context, maxPollRecords int) kgo.Fetches
+	PauseFetchTopics(topics ...string) []string
+	ResumeFetchTopics(topics ...string)
+	Close()
+}
+
+// NewClient returns a new client guarding a given kgo.Client
+// with the circuit breakers of a given group keyed by topic:
+//
+//	cl := circuitkgo.NewClient(kcl, g)
+//	cl.Produce(ctx, &kgo.Record{Topic: "orders", Value: v}, promise)
+//	fetches := cl.PollFetches(ctx)
+func NewClient(cl *kgo.Client, g *circuit.Group) *Client {
+	return newClient(cl, g)
+}
+
+func newClient(cl client, g *circuit.Group) *Client {
+	return &Client{cl: cl, g: g, watched: make(map[string]bool), done: make(chan struct{})}
+}
+
+// Close stops watching the circuit breakers of the topics and closes the kgo.Client.
+func (c *Client) Close() {
+	c.close.Do(func() {
+		close(c.done)
+	})
+	c.wg.Wait()
+	c.cl.Close()
+}
+
+// Produce produces a record like kgo.Client.Produce does through the circuit breaker of its topic,
+// the promise is called with circuit.ErrBreakerOpen right away when it doesn't accept the record.
+//
+// The produce errors are counted as failures except for the records given up by the caller,
+// its context is done, and the ones of a closed client, those are not counted.
+func (c *Client) Produce(ctx context.Context, r *kgo.Record, promise func(*kgo.Record, error)) {
+	tok, err := c.g.Get(r.Topic).Allow()
+	if err != nil {
+		if promise != nil {
+			promise(r, err)
+		}
+		return
+	}
+
+	c.cl.Produce(ctx, r, func(r *kgo.Record, err error) {
+		tok.Record(outcome(ctx, err))
+		if promise != nil {
+			promise(r, err)
+		}
+	})
+}
+
+// ProduceSync produces the records like kgo.Client.ProduceSync does, see Produce.
+func (c *Client) ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(kgo.ProduceResults, 0, len(rs))
+	)
+
+	wg.Add(len(rs))
+	for _, r := range rs {
+		c.Produce(ctx, r, func(r *kgo.Record, err error) {
+			mu.Lock()
+			results = append(results, kgo.ProduceResult{Record: r, Err: err})
+			mu.Unlock()
+			wg.Done()
+		})
+	}
+	wg.Wait()
+	return results
+}
+
+// PollFetches polls the fetches like kgo.Client.PollFetches does.
+//
+// Every fetched partition is counted by the circuit breaker of its topic,
+// a failure if its fetch failed. The topic is paused once its circuit breaker opens
+// and resumed once it leaves the open state, see watch.
+func (c *Client) PollFetches(ctx context.Context) kgo.Fetches {
+	return c.fetched(ctx, c.cl.PollFetches(ctx))
+}
+
+// PollRecords polls the fetches like kgo.Client.PollRecords does, see PollFetches.
+func (c *Client) PollRecords(ctx context.Context, maxPollRecords int) kgo.Fetches {
+	return c.fetched(ctx, c.cl.PollRecords(ctx, maxPollRecords))
+}
+
+func (c *Client) fetched(ctx context.Context, fs kgo.Fetches) kgo.Fetches {
+	fs.EachPartition(func(p kgo.FetchTopicPartition) {
+		if p.Topic == "" {
+			return // an error of the client, e.g. kgo.ErrClientClosed
+		}
+
+		if c.watch(p.Topic) {
+			return // fetched before the topic was paused, not counted
+		}
+		// fetched already, so not rejected
+		c.g.Get(p.Topic).Observe(outcome(ctx, p.Err))
+	})
+	return fs
+}
+
+// watch starts watching the circuit breaker of a given topic unless it's watched already,
+// reports whether the topic is paused.
+func (c *Client) watch(topic string) (paused bool) {
+	c.mu.Lock()
+	paused, ok := c.watched[topic]
+	if !ok {
+		c.watched[topic] = false
+	}
+	c.mu.Unlock()
+	if ok {
+		return paused
+	}
+
+	b := c.g.Get(topic)
+	changes, stop := b.Watch()
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer stop()
+		c.pauseWhileOpen(topic, b, changes)
+	}()
+	return false
+}
+
+// pauseWhileOpen pauses a given topic once its circuit breaker opens and resumes it
+// once it leaves the open state till the client is closed.
+//
+// The open state is left lazily by the next request after the cooldown,
+// so an ignored outcome is observed once it ends for the circuit breaker
+// to move into the half-open state, the fetches of the topic are its trial requests then.
+func (c *Client) pauseWhileOpen(topic string, b *circuit.Breaker, changes <-chan circuit.StateChange) {
+	paused := b.State() == circuit.StateOpen
+	if paused {
+		c.setPaused(topic, true)
+	}
+
+	var nudged bool
+	for {
+		var cooldown <-chan time.Time
+		if paused {
+			d := b.RetryAfter()
+			if nudged {
+				// still open, e.g. forced
+				d = max(d, time.Second)
+			}
+			cooldown = time.After(d)
+		}
+
+		select {
+		case change := <-changes:
+			nudged = false
+			switch {
+			case change.To == circuit.StateOpen && !paused:
+				paused = true
+				c.setPaused(topic, true)
+			case change.To != circuit.StateOpen && paused:
+				paused = false
+				c.setPaused(topic, false)
+			}
+		case <-cooldown:
+			nudged = true
+			b.Observe(circuit.OutcomeIgnored)
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *Client) setPaused(topic string, paused bool) {
+	c.mu.Lock()
+	c.watched[topic] = paused
+	c.mu.Unlock()
+
+	if paused {
+		c.cl.PauseFetchTopics(topic)
+	} else {
+		c.cl.ResumeFetchTopics(topic)
+	}
+}
+
+// outcome returns the outcome of a produce or a fetch.
+func outcome(ctx context.Context, err error) circuit.Outcome {
+	switch {
+	case err == nil:
+		return circuit.OutcomeSuccess
+	case ctx.Err() != nil, errors.Is(err, kgo.ErrClientClosed):
+		return circuit.OutcomeIgnored
+	}
+	return circuit.OutcomeFailure
+}
@@ -0,0 +1,117 @@
+package circuitkgo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type fakeClient struct {
+	produce func(r *kgo.Record) error
+	fetches kgo.Fetches
+
+	mu     sync.Mutex
+	paused map[string]bool
+}
+
+func (f *fakeClient) Produce(ctx context.Context, r *kgo.Record, promise func(*kgo.Record, error)) {
+	promise(r, f.produce(r))
+}
+
+func (f *fakeClient) PollFetches(ctx context.Context) kgo.Fetches { return f.fetches }
+
+func (f *fakeClient) PollRecords(ctx context.Context, maxPollRecords int) kgo.Fetches {
+	return f.fetches
+}
+
+func (f *fakeClient) PauseFetchTopics(topics ...string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range topics {
+		f.paused[t] = true
+	}
+	return nil
+}
+
+func (f *fakeClient) ResumeFetchTopics(topics ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range topics {
+		delete(f.paused, t)
+	}
+}
+
+func (f *fakeClient) Close() {}
+
+func (f *fakeClient) isPaused(topic string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.paused[topic]
+}
+
+func newTestClient(t *testing.T, cooldown time.Duration, f *fakeClient) *Client {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	g, err := circuit.NewGroup(time.Minute, cooldown, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	f.paused = make(map[string]bool)
+	c := newClient(f, g)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestClient_Produce(t *testing.T) {
+	var produced []string
+	f := &fakeClient{produce: func(r *kgo.Record) error {
+		produced = append(produced, r.Topic)
+		if r.Topic == "payments" {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	}}
+	c := newTestClient(t, time.Minute, f)
+
+	results := c.ProduceSync(context.Background(), &kgo.Record{Topic: "orders"}, &kgo.Record{Topic: "payments"})
+	assert.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.EqualError(t, results[1].Err, "broker unavailable")
+
+	var errs []error
+	c.Produce(context.Background(), &kgo.Record{Topic: "payments"}, func(r *kgo.Record, err error) { errs = append(errs, err) })
+	c.Produce(context.Background(), &kgo.Record{Topic: "orders"}, func(r *kgo.Record, err error) { errs = append(errs, err) })
+	assert.Len(t, errs, 2)
+	assert.ErrorIs(t, errs[0], circuit.ErrBreakerOpen)
+	assert.NoError(t, errs[1])
+	assert.Equal(t, []string{"orders", "payments", "orders"}, produced)
+}
+
+func TestClient_PollFetches(t *testing.T) {
+	f := &fakeClient{fetches: kgo.Fetches{{Topics: []kgo.FetchTopic{
+		{Topic: "orders", Partitions: []kgo.FetchPartition{{Partition: 0, Records: []*kgo.Record{{Topic: "orders"}}}}},
+		{Topic: "payments", Partitions: []kgo.FetchPartition{{Partition: 1, Err: errors.New("not leader for partition")}}},
+		{Topic: "", Partitions: []kgo.FetchPartition{{Partition: -1, Err: kgo.ErrClientClosed}}},
+	}}}}
+	c := newTestClient(t, 20*time.Millisecond, f)
+
+	fs := c.PollFetches(context.Background())
+	assert.Equal(t, f.fetches, fs)
+	assert.Equal(t, circuit.StateClosed, c.g.Get("orders").State())
+	assert.Equal(t, circuit.StateOpen, c.g.Get("payments").State())
+	assert.Equal(t, []string{"orders", "payments"}, c.g.Keys())
+
+	assert.Eventually(t, func() bool { return f.isPaused("payments") }, time.Second, time.Millisecond, "paused once open")
+	assert.False(t, f.isPaused("orders"))
+
+	c.PollFetches(context.Background())
+	assert.Zero(t, c.g.Get("payments").Counts().Rejections, "no rejections counted while paused")
+
+	assert.Eventually(t, func() bool { return !f.isPaused("payments") }, time.Second, time.Millisecond, "resumed after the cooldown")
+	assert.Equal(t, circuit.StateHalfOpen, c.g.Get("payments").State())
+}
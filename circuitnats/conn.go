@@ -0,0 +1,109 @@
+// Package circuitnats guards the NATS connections with circuit breakers.
+package circuitnats
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/nats-io/nats.go"
+)
+
+// Conn guards the publishes and the requests of a nats.Conn with the circuit breakers
+// of a group keyed by subject, it returns circuit.ErrBreakerOpen without sending the message
+// when the circuit breaker of its subject doesn't accept it.
+type Conn struct {
+	nc       conn
+	g        *circuit.Group
+	classify func(err error, reconnecting bool) circuit.Outcome
+}
+
+// conn is the part of *nats.Conn guarded by Conn.
+type conn interface {
+	Publish(subj string, data []byte) error
+	PublishMsg(m *nats.Msg) error
+	Request(subj string, data []byte, timeout time.Duration) (*nats.Msg, error)
+	RequestWithContext(ctx context.Context, subj string, data []byte) (*nats.Msg, error)
+	IsReconnecting() bool
+}
+
+// NewConn returns a new connection guarding a given nats.Conn with the circuit breakers
+// of a given group keyed by subject, the errors are classified with classify,
+// Classify is used if it's nil:
+//
+//	nc := circuitnats.NewConn(conn, g, nil)
+//	msg, err := nc.Request("users.get", data, time.Second)
+func NewConn(nc *nats.Conn, g *circuit.Group, classify func(err error, reconnecting bool) circuit.Outcome) *Conn {
+	if classify == nil {
+		classify = Classify
+	}
+
+	return &Conn{nc: nc, g: g, classify: classify}
+}
+
+// Classify is the default classifier of the errors of the publishes and the requests:
+// nats.ErrNoResponders, the timeouts and the other errors are failures of the subject,
+// the errors of the connection, e.g. nats.ErrConnectionReconnecting or nats.ErrConnectionClosed,
+// and the ones of the caller's fault, e.g. nats.ErrBadSubject or nats.ErrMaxPayload, are ignored.
+// While the connection is reconnecting, the responders can't be reached,
+// so the timeouts and nats.ErrNoResponders are ignored as well.
+func Classify(err error, reconnecting bool) circuit.Outcome {
+	switch {
+	case err == nil:
+		return circuit.OutcomeSuccess
+	case errors.Is(err, nats.ErrConnectionReconnecting), errors.Is(err, nats.ErrReconnectBufExceeded),
+		errors.Is(err, nats.ErrConnectionClosed), errors.Is(err, nats.ErrConnectionDraining),
+		errors.Is(err, context.Canceled):
+		return circuit.OutcomeIgnored
+	case errors.Is(err, nats.ErrBadSubject), errors.Is(err, nats.ErrMaxPayload), errors.Is(err, nats.ErrInvalidMsg),
+		errors.Is(err, nats.ErrBadTimeout), errors.Is(err, nats.ErrInvalidContext):
+		return circuit.OutcomeIgnored
+	case reconnecting:
+		return circuit.OutcomeIgnored
+	}
+	return circuit.OutcomeFailure
+}
+
+// Publish publishes data to a subject like nats.Conn.Publish does.
+func (c *Conn) Publish(subj string, data []byte) error {
+	return c.do(subj, func() error { return c.nc.Publish(subj, data) })
+}
+
+// PublishMsg publishes a message to its subject like nats.Conn.PublishMsg does.
+func (c *Conn) PublishMsg(m *nats.Msg) error {
+	return c.do(m.Subject, func() error { return c.nc.PublishMsg(m) })
+}
+
+// Request sends a request to a subject and waits for a reply like nats.Conn.Request does.
+func (c *Conn) Request(subj string, data []byte, timeout time.Duration) (*nats.Msg, error) {
+	var msg *nats.Msg
+	err := c.do(subj, func() (err error) {
+		msg, err = c.nc.Request(subj, data, timeout)
+		return err
+	})
+	return msg, err
+}
+
+// RequestWithContext sends a request to a subject and waits for a reply
+// like nats.Conn.RequestWithContext does.
+func (c *Conn) RequestWithContext(ctx context.Context, subj string, data []byte) (*nats.Msg, error) {
+	var msg *nats.Msg
+	err := c.do(subj, func() (err error) {
+		msg, err = c.nc.RequestWithContext(ctx, subj, data)
+		return err
+	})
+	return msg, err
+}
+
+// do runs a given call through the circuit breaker of a given subject.
+func (c *Conn) do(subj string, call func() error) error {
+	tok, err := c.g.Get(subj).Allow()
+	if err != nil {
+		return err
+	}
+
+	err = call()
+	tok.Record(c.classify(err, err != nil && c.nc.IsReconnecting()))
+	return err
+}
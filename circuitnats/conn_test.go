@@ -0,0 +1,84 @@
+package circuitnats
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeConn struct {
+	err          error
+	reconnecting bool
+	sent         []string
+}
+
+func (f *fakeConn) Publish(subj string, data []byte) error {
+	f.sent = append(f.sent, subj)
+	return f.err
+}
+
+func (f *fakeConn) PublishMsg(m *nats.Msg) error {
+	return f.Publish(m.Subject, m.Data)
+}
+
+func (f *fakeConn) Request(subj string, data []byte, timeout time.Duration) (*nats.Msg, error) {
+	return f.RequestWithContext(context.Background(), subj, data)
+}
+
+func (f *fakeConn) RequestWithContext(ctx context.Context, subj string, data []byte) (*nats.Msg, error) {
+	f.sent = append(f.sent, subj)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &nats.Msg{Subject: "_INBOX.1", Data: []byte("pong")}, nil
+}
+
+func (f *fakeConn) IsReconnecting() bool { return f.reconnecting }
+
+func TestClassify(t *testing.T) {
+	assert.Equal(t, circuit.OutcomeSuccess, Classify(nil, false))
+	assert.Equal(t, circuit.OutcomeFailure, Classify(nats.ErrNoResponders, false))
+	assert.Equal(t, circuit.OutcomeFailure, Classify(fmt.Errorf("get user: %w", nats.ErrTimeout), false))
+	assert.Equal(t, circuit.OutcomeFailure, Classify(context.DeadlineExceeded, false))
+	assert.Equal(t, circuit.OutcomeIgnored, Classify(nats.ErrTimeout, true), "unreachable while reconnecting")
+	assert.Equal(t, circuit.OutcomeIgnored, Classify(nats.ErrNoResponders, true), "unreachable while reconnecting")
+	assert.Equal(t, circuit.OutcomeIgnored, Classify(nats.ErrConnectionClosed, false))
+	assert.Equal(t, circuit.OutcomeIgnored, Classify(nats.ErrReconnectBufExceeded, false))
+	assert.Equal(t, circuit.OutcomeIgnored, Classify(nats.ErrMaxPayload, false))
+	assert.Equal(t, circuit.OutcomeIgnored, Classify(context.Canceled, false))
+}
+
+func TestConn(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	g, err := circuit.NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	f := &fakeConn{}
+	c := &Conn{nc: f, g: g, classify: Classify}
+
+	msg, err := c.Request("users.get", []byte("ping"), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("pong"), msg.Data)
+	assert.NoError(t, c.PublishMsg(&nats.Msg{Subject: "users.updated"}))
+
+	f.err, f.reconnecting = nats.ErrTimeout, true
+	_, err = c.RequestWithContext(context.Background(), "users.get", nil)
+	assert.ErrorIs(t, err, nats.ErrTimeout)
+	assert.Equal(t, circuit.StateClosed, g.Get("users.get").State(), "not counted while reconnecting")
+
+	f.err, f.reconnecting = nats.ErrNoResponders, false
+	_, err = c.Request("users.get", nil, time.Second)
+	assert.ErrorIs(t, err, nats.ErrNoResponders)
+	_, err = c.Request("users.get", nil, time.Second)
+	assert.ErrorIs(t, err, circuit.ErrBreakerOpen)
+
+	f.err = nil
+	assert.NoError(t, c.Publish("users.updated", nil))
+	assert.Equal(t, []string{"users.get", "users.updated", "users.get", "users.get", "users.updated"}, f.sent)
+}
@@ -0,0 +1,62 @@
+// Package circuitnet guards the network connections with circuit breakers.
+package circuitnet
+
+import (
+	"context"
+	"net"
+
+	"github.com/djo/circuit"
+)
+
+// DialFunc dials a connection like net.Dialer.DialContext does.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// Dialer dials the connections guarded by the circuit breakers of a group per address,
+// it returns circuit.ErrBreakerOpen without dialing when the circuit breaker
+// of the address doesn't accept the dial, so the connection storms against a dead host
+// are cut off for any protocol built on net.Conn.
+type Dialer struct {
+	dial DialFunc
+	g    *circuit.Group
+}
+
+// NewDialer returns a new dialer dialing with dial guarded by the circuit breakers
+// of a given group keyed by the addresses, a zero net.Dialer is used if dial is nil:
+//
+//	d := circuitnet.NewDialer(nil, g)
+//	client := &http.Client{Transport: &http.Transport{DialContext: d.DialContext}}
+func NewDialer(dial DialFunc, g *circuit.Group) *Dialer {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	return &Dialer{dial: dial, g: g}
+}
+
+// Dial dials an address on a network, see DialContext.
+func (d *Dialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext dials an address on a network through the circuit breaker of the address.
+//
+// The dial errors are counted as failures,
+// a dial given up by the caller, its context is done, is not counted.
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	tok, err := d.g.Get(address).Allow()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := d.dial(ctx, network, address)
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		tok.Ignore()
+	case err != nil:
+		tok.Failure()
+	default:
+		tok.Success()
+	}
+	return conn, err
+}
@@ -0,0 +1,60 @@
+package circuitnet
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialer(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	g, err := circuit.NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	up, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer up.Close()
+	down, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	down.Close()
+
+	d := NewDialer(nil, g)
+
+	conn, err := d.Dial("tcp", up.Addr().String())
+	assert.NoError(t, err)
+	conn.Close()
+
+	_, err = d.Dial("tcp", down.Addr().String())
+	assert.Error(t, err)
+	_, err = d.Dial("tcp", down.Addr().String())
+	assert.ErrorIs(t, err, circuit.ErrBreakerOpen)
+
+	conn, err = d.Dial("tcp", up.Addr().String())
+	assert.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, circuit.StateClosed, g.Get(up.Addr().String()).State())
+}
+
+func TestDialer_Canceled(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	g, err := circuit.NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	d := NewDialer(func(ctx context.Context, network, address string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, g)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = d.DialContext(ctx, "tcp", "db:5432")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, circuit.StateClosed, g.Get("db:5432").State())
+	assert.Zero(t, g.Get("db:5432").Counts().Total, "not counted")
+}
@@ -0,0 +1,107 @@
+package circuitnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/djo/circuit"
+)
+
+// Resolver looks up the names with a net.Resolver guarded by the circuit breakers of a group
+// for the keys of the names, e.g. per zone with KeyByZone. When the circuit breaker
+// doesn't accept the lookup or the lookup fails, the last good answer for the name
+// is returned instead unless it's stale, so a DNS outage doesn't cascade
+// into the failure of every dependency.
+type Resolver struct {
+	g     *circuit.Group
+	hosts *circuit.Cache[[]string]
+	addrs *circuit.Cache[[]net.IPAddr]
+
+	lookupHost   func(ctx context.Context, host string) ([]string, error)     // net.Resolver.LookupHost
+	lookupIPAddr func(ctx context.Context, host string) ([]net.IPAddr, error) // net.Resolver.LookupIPAddr
+}
+
+// NewResolver returns a new resolver looking up with r guarded by the circuit breakers
+// of a given group and answering with the last good answers for up to stale,
+// net.DefaultResolver is used if r is nil:
+//
+//	g.SetKeyFunc(circuitnet.KeyByZone)
+//	r := circuitnet.NewResolver(nil, g, time.Hour)
+//	addrs, err := r.LookupHost(ctx, "db.internal.example.com")
+func NewResolver(r *net.Resolver, g *circuit.Group, stale time.Duration) *Resolver {
+	if r == nil {
+		r = net.DefaultResolver
+	}
+
+	return &Resolver{
+		g:            g,
+		hosts:        circuit.NewCache[[]string](stale),
+		addrs:        circuit.NewCache[[]net.IPAddr](stale),
+		lookupHost:   r.LookupHost,
+		lookupIPAddr: r.LookupIPAddr,
+	}
+}
+
+// KeyByZone is a circuit.KeyFunc returning the zone of a name looked up by Resolver,
+// the name without its first label, e.g. example.com for www.example.com.
+func KeyByZone(ctx context.Context, req any) string {
+	host, _ := req.(string)
+	host = strings.TrimSuffix(host, ".")
+	if i := strings.IndexByte(host, '.'); i >= 0 {
+		return host[i+1:]
+	}
+	return host
+}
+
+// LookupHost looks up the addresses of a given host like net.Resolver.LookupHost does.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return lookup(ctx, r, r.hosts, host, r.lookupHost)
+}
+
+// LookupIPAddr looks up the IP addresses of a given host like net.Resolver.LookupIPAddr does.
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return lookup(ctx, r, r.addrs, host, r.lookupIPAddr)
+}
+
+// lookup runs a lookup of a given host through its circuit breaker.
+//
+// The lookup errors are counted as failures except for the names not found,
+// they are answers of a healthy DNS, and the lookups given up by the caller,
+// those are not counted.
+func lookup[T any](ctx context.Context, r *Resolver, c *circuit.Cache[T], host string, f func(context.Context, string) (T, error)) (T, error) {
+	tok, err := r.g.For(ctx, host).Allow()
+	if err != nil {
+		return fallback(c, host, err)
+	}
+
+	v, err := f(ctx, host)
+
+	var dnsErr *net.DNSError
+	switch {
+	case err == nil:
+		tok.Success()
+		c.Set(host, v)
+		return v, nil
+	case ctx.Err() != nil:
+		tok.Ignore()
+		return v, err
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		tok.Success()
+		return v, err
+	}
+
+	tok.Failure()
+	return fallback(c, host, err)
+}
+
+// fallback returns the last good answer for a given host or err if there's none.
+func fallback[T any](c *circuit.Cache[T], host string, err error) (T, error) {
+	if v, ok := c.Get(host); ok {
+		return v, nil
+	}
+	var zero T
+	return zero, err
+}
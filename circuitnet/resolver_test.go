@@ -0,0 +1,93 @@
+package circuitnet
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyByZone(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "example.com", KeyByZone(ctx, "www.example.com"))
+	assert.Equal(t, "example.com", KeyByZone(ctx, "www.example.com."))
+	assert.Equal(t, "localhost", KeyByZone(ctx, "localhost"))
+	assert.Equal(t, "", KeyByZone(ctx, nil))
+}
+
+func TestResolver(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(uint32, uint32) bool { return false }
+	g, err := circuit.NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+	g.SetKeyFunc(KeyByZone)
+
+	var lookups int
+	dnsErr := &net.DNSError{Err: "server misbehaving", Name: "db.example.com", IsTemporary: true}
+	r := NewResolver(nil, g, time.Hour)
+	r.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		lookups++
+		switch {
+		case host == "gone.example.com":
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		case lookups > 1:
+			return nil, dnsErr
+		}
+		return []string{"10.0.0.1"}, nil
+	}
+
+	addrs, err := r.LookupHost(context.Background(), "db.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+
+	_, err = r.LookupHost(context.Background(), "gone.example.com")
+	var notFound *net.DNSError
+	assert.ErrorAs(t, err, &notFound)
+	assert.True(t, notFound.IsNotFound)
+	assert.Equal(t, circuit.Counts{State: circuit.StateClosed, Total: 2, Successes: 2, LifetimeTotal: 2},
+		withoutStart(g.Get("example.com").Counts()), "not found is an answer")
+
+	addrs, err = r.LookupHost(context.Background(), "db.example.com")
+	assert.NoError(t, err, "stale answer")
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+
+	_, err = r.LookupHost(context.Background(), "cache.example.com")
+	assert.Equal(t, dnsErr, err)
+	assert.Equal(t, circuit.StateOpen, g.Get("example.com").State())
+
+	addrs, err = r.LookupHost(context.Background(), "db.example.com")
+	assert.NoError(t, err, "stale answer while open")
+	assert.Equal(t, []string{"10.0.0.1"}, addrs)
+	_, err = r.LookupHost(context.Background(), "cache.example.com")
+	assert.ErrorIs(t, err, circuit.ErrBreakerOpen)
+	assert.Equal(t, 4, lookups)
+}
+
+func TestResolver_Canceled(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	g, err := circuit.NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	r := NewResolver(nil, g, time.Hour)
+	r.lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		<-ctx.Done()
+		return nil, errors.New("lookup canceled")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = r.LookupIPAddr(ctx, "db.example.com")
+	assert.Error(t, err)
+	assert.Equal(t, circuit.StateClosed, g.Get("").State())
+	assert.Zero(t, g.Get("").Counts().Total, "not counted")
+}
+
+func withoutStart(c circuit.Counts) circuit.Counts {
+	c.WindowStart = time.Time{}
+	return c
+}
@@ -0,0 +1,115 @@
+// Package circuitprom exports the states and counters of circuit breakers as Prometheus metrics.
+package circuitprom
+
+import (
+	"github.com/djo/circuit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var states = []circuit.State{circuit.StateClosed, circuit.StateHalfOpen, circuit.StateOpen}
+
+type collector struct {
+	each func(f func(labelValues []string, b *circuit.Breaker))
+
+	state              *prometheus.Desc
+	forced             *prometheus.Desc
+	total              *prometheus.Desc
+	failures           *prometheus.Desc
+	categories         *prometheus.Desc
+	rejections         *prometheus.Desc
+	lifetimeTotal      *prometheus.Desc
+	lifetimeFailures   *prometheus.Desc
+	lifetimeRejections *prometheus.Desc
+	lifetimeBypasses   *prometheus.Desc
+	transitions        *prometheus.Desc
+}
+
+// Collector returns a collector exporting the metrics of a given circuit breaker
+// with the constant labels, e.g. {"dependency": "payments"}.
+func Collector(b *circuit.Breaker, labels prometheus.Labels) prometheus.Collector {
+	return newCollector(nil, labels, func(f func([]string, *circuit.Breaker)) {
+		f(nil, b)
+	})
+}
+
+// RegistryCollector returns a collector exporting the metrics
+// of every circuit breaker in a given registry labeled by its name
+// along with the constant labels.
+func RegistryCollector(r *circuit.Registry, labels prometheus.Labels) prometheus.Collector {
+	return newCollector([]string{"name"}, labels, func(f func([]string, *circuit.Breaker)) {
+		r.Each(func(name string, b *circuit.Breaker) {
+			f([]string{name}, b)
+		})
+	})
+}
+
+func newCollector(variableLabels []string, labels prometheus.Labels, each func(func([]string, *circuit.Breaker))) *collector {
+	desc := func(name, help string, extraLabels ...string) *prometheus.Desc {
+		vl := append(append([]string{}, variableLabels...), extraLabels...)
+		return prometheus.NewDesc("circuit_breaker_"+name, help, vl, labels)
+	}
+
+	return &collector{
+		each: each,
+
+		state:              desc("state", "Whether the circuit breaker is in the state.", "state"),
+		forced:             desc("forced", "Whether the state of the circuit breaker is forced manually."),
+		total:              desc("requests", "Number of requests in the current period."),
+		failures:           desc("failures", "Number of failed requests in the current period."),
+		categories:         desc("failures_by_category", "Number of failed requests in the current period by the category.", "category"),
+		rejections:         desc("rejections", "Number of rejected requests in the current period."),
+		lifetimeTotal:      desc("requests_total", "Number of requests in total."),
+		lifetimeFailures:   desc("failures_total", "Number of failed requests in total."),
+		lifetimeRejections: desc("rejections_total", "Number of rejected requests in total."),
+		lifetimeBypasses:   desc("bypasses_total", "Number of requests bypassing the circuit breaker in total."),
+		transitions:        desc("transitions_total", "Number of transitions between the states."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.state
+	ch <- c.forced
+	ch <- c.total
+	ch <- c.failures
+	ch <- c.categories
+	ch <- c.rejections
+	ch <- c.lifetimeTotal
+	ch <- c.lifetimeFailures
+	ch <- c.lifetimeRejections
+	ch <- c.lifetimeBypasses
+	ch <- c.transitions
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	c.each(func(lv []string, b *circuit.Breaker) {
+		counts := b.Counts()
+
+		for _, s := range states {
+			v := 0.0
+			if counts.State == s {
+				v = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, v, append(lv, s.String())...)
+		}
+
+		forced := 0.0
+		if counts.Override != circuit.OverrideNone {
+			forced = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.forced, prometheus.GaugeValue, forced, lv...)
+
+		ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(counts.Total), lv...)
+		ch <- prometheus.MustNewConstMetric(c.failures, prometheus.GaugeValue, float64(counts.Failures), lv...)
+		for category, n := range counts.FailuresByCategory {
+			ch <- prometheus.MustNewConstMetric(c.categories, prometheus.GaugeValue, float64(n), append(lv, category)...)
+		}
+		ch <- prometheus.MustNewConstMetric(c.rejections, prometheus.GaugeValue, float64(counts.Rejections), lv...)
+		ch <- prometheus.MustNewConstMetric(c.lifetimeTotal, prometheus.CounterValue, float64(counts.LifetimeTotal), lv...)
+		ch <- prometheus.MustNewConstMetric(c.lifetimeFailures, prometheus.CounterValue, float64(counts.LifetimeFailures), lv...)
+		ch <- prometheus.MustNewConstMetric(c.lifetimeRejections, prometheus.CounterValue, float64(counts.LifetimeRejections), lv...)
+		ch <- prometheus.MustNewConstMetric(c.lifetimeBypasses, prometheus.CounterValue, float64(counts.LifetimeBypasses), lv...)
+		ch <- prometheus.MustNewConstMetric(c.transitions, prometheus.CounterValue, float64(counts.Transitions), lv...)
+	})
+}
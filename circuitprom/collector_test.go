@@ -0,0 +1,139 @@
+package circuitprom
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return nil })
+	b.Execute(func() error { return errors.New("failed") })
+
+	c := Collector(b, prometheus.Labels{"dependency": "payments"})
+	err = testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP circuit_breaker_bypasses_total Number of requests bypassing the circuit breaker in total.
+# TYPE circuit_breaker_bypasses_total counter
+circuit_breaker_bypasses_total{dependency="payments"} 0
+# HELP circuit_breaker_failures Number of failed requests in the current period.
+# TYPE circuit_breaker_failures gauge
+circuit_breaker_failures{dependency="payments"} 1
+# HELP circuit_breaker_failures_total Number of failed requests in total.
+# TYPE circuit_breaker_failures_total counter
+circuit_breaker_failures_total{dependency="payments"} 1
+# HELP circuit_breaker_forced Whether the state of the circuit breaker is forced manually.
+# TYPE circuit_breaker_forced gauge
+circuit_breaker_forced{dependency="payments"} 0
+# HELP circuit_breaker_rejections Number of rejected requests in the current period.
+# TYPE circuit_breaker_rejections gauge
+circuit_breaker_rejections{dependency="payments"} 0
+# HELP circuit_breaker_rejections_total Number of rejected requests in total.
+# TYPE circuit_breaker_rejections_total counter
+circuit_breaker_rejections_total{dependency="payments"} 0
+# HELP circuit_breaker_requests Number of requests in the current period.
+# TYPE circuit_breaker_requests gauge
+circuit_breaker_requests{dependency="payments"} 2
+# HELP circuit_breaker_requests_total Number of requests in total.
+# TYPE circuit_breaker_requests_total counter
+circuit_breaker_requests_total{dependency="payments"} 2
+# HELP circuit_breaker_state Whether the circuit breaker is in the state.
+# TYPE circuit_breaker_state gauge
+circuit_breaker_state{dependency="payments",state="closed"} 1
+circuit_breaker_state{dependency="payments",state="half-open"} 0
+circuit_breaker_state{dependency="payments",state="open"} 0
+# HELP circuit_breaker_transitions_total Number of transitions between the states.
+# TYPE circuit_breaker_transitions_total counter
+circuit_breaker_transitions_total{dependency="payments"} 0
+`))
+	assert.NoError(t, err)
+}
+
+func TestRegistryCollector(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	r := circuit.NewRegistry()
+	payments, err := r.NewBreaker("payments", time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+	_, err = r.NewBreaker("accounts", time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	payments.Execute(func() error { return errors.New("failed") })
+	payments.Execute(func() error { return nil })
+	payments.Execute(func() error { return nil })
+
+	c := RegistryCollector(r, prometheus.Labels{"service": "api"})
+	err = testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP circuit_breaker_rejections Number of rejected requests in the current period.
+# TYPE circuit_breaker_rejections gauge
+circuit_breaker_rejections{name="accounts",service="api"} 0
+circuit_breaker_rejections{name="payments",service="api"} 2
+# HELP circuit_breaker_rejections_total Number of rejected requests in total.
+# TYPE circuit_breaker_rejections_total counter
+circuit_breaker_rejections_total{name="accounts",service="api"} 0
+circuit_breaker_rejections_total{name="payments",service="api"} 2
+# HELP circuit_breaker_state Whether the circuit breaker is in the state.
+# TYPE circuit_breaker_state gauge
+circuit_breaker_state{name="accounts",service="api",state="closed"} 1
+circuit_breaker_state{name="accounts",service="api",state="half-open"} 0
+circuit_breaker_state{name="accounts",service="api",state="open"} 0
+circuit_breaker_state{name="payments",service="api",state="closed"} 0
+circuit_breaker_state{name="payments",service="api",state="half-open"} 0
+circuit_breaker_state{name="payments",service="api",state="open"} 1
+# HELP circuit_breaker_transitions_total Number of transitions between the states.
+# TYPE circuit_breaker_transitions_total counter
+circuit_breaker_transitions_total{name="accounts",service="api"} 0
+circuit_breaker_transitions_total{name="payments",service="api"} 1
+`), "circuit_breaker_rejections", "circuit_breaker_rejections_total", "circuit_breaker_state", "circuit_breaker_transitions_total")
+	assert.NoError(t, err)
+}
+
+func TestCollector_FailuresByCategory(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return false }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed,
+		circuit.WithFailureCategories(func(err error) string { return err.Error() }))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("timeout") })
+	b.Execute(func() error { return errors.New("timeout") })
+	b.Execute(func() error { return errors.New("refused") })
+
+	c := Collector(b, prometheus.Labels{"dependency": "payments"})
+	err = testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP circuit_breaker_failures_by_category Number of failed requests in the current period by the category.
+# TYPE circuit_breaker_failures_by_category gauge
+circuit_breaker_failures_by_category{category="refused",dependency="payments"} 1
+circuit_breaker_failures_by_category{category="timeout",dependency="payments"} 2
+`), "circuit_breaker_failures_by_category")
+	assert.NoError(t, err)
+}
+
+func TestCollector_Bypasses(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return true }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	b.Trip()
+	b.ExecuteContext(circuit.WithBypass(context.Background()), func(context.Context) error { return nil })
+
+	c := Collector(b, prometheus.Labels{"dependency": "payments"})
+	err = testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP circuit_breaker_bypasses_total Number of requests bypassing the circuit breaker in total.
+# TYPE circuit_breaker_bypasses_total counter
+circuit_breaker_bypasses_total{dependency="payments"} 1
+`), "circuit_breaker_bypasses_total")
+	assert.NoError(t, err)
+}
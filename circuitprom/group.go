@@ -0,0 +1,72 @@
+package circuitprom
+
+import (
+	"github.com/djo/circuit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type groupCollector struct {
+	g *circuit.Group
+
+	breakers           *prometheus.Desc
+	total              *prometheus.Desc
+	failures           *prometheus.Desc
+	rejections         *prometheus.Desc
+	lifetimeTotal      *prometheus.Desc
+	lifetimeFailures   *prometheus.Desc
+	lifetimeRejections *prometheus.Desc
+}
+
+// GroupCollector returns a collector exporting the roll-up of the metrics
+// of the circuit breakers of a given group with the constant labels,
+// the number of the circuit breakers by state and the summed counters,
+// without a series per key.
+func GroupCollector(g *circuit.Group, labels prometheus.Labels) prometheus.Collector {
+	desc := func(name, help string, variableLabels ...string) *prometheus.Desc {
+		return prometheus.NewDesc("circuit_breaker_group_"+name, help, variableLabels, labels)
+	}
+
+	return &groupCollector{
+		g: g,
+
+		breakers:           desc("breakers", "Number of circuit breakers in the state.", "state"),
+		total:              desc("requests", "Number of requests in the current periods."),
+		failures:           desc("failures", "Number of failed requests in the current periods."),
+		rejections:         desc("rejections", "Number of rejected requests in the current periods."),
+		lifetimeTotal:      desc("requests_total", "Number of requests in total."),
+		lifetimeFailures:   desc("failures_total", "Number of failed requests in total."),
+		lifetimeRejections: desc("rejections_total", "Number of rejected requests in total."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *groupCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.breakers
+	ch <- c.total
+	ch <- c.failures
+	ch <- c.rejections
+	ch <- c.lifetimeTotal
+	ch <- c.lifetimeFailures
+	ch <- c.lifetimeRejections
+}
+
+// Collect implements prometheus.Collector.
+func (c *groupCollector) Collect(ch chan<- prometheus.Metric) {
+	counts := c.g.Counts()
+
+	byState := map[circuit.State]int{
+		circuit.StateClosed:   counts.Closed,
+		circuit.StateHalfOpen: counts.HalfOpen,
+		circuit.StateOpen:     counts.Open,
+	}
+	for _, s := range states {
+		ch <- prometheus.MustNewConstMetric(c.breakers, prometheus.GaugeValue, float64(byState[s]), s.String())
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(counts.Total))
+	ch <- prometheus.MustNewConstMetric(c.failures, prometheus.GaugeValue, float64(counts.Failures))
+	ch <- prometheus.MustNewConstMetric(c.rejections, prometheus.GaugeValue, float64(counts.Rejections))
+	ch <- prometheus.MustNewConstMetric(c.lifetimeTotal, prometheus.CounterValue, float64(counts.LifetimeTotal))
+	ch <- prometheus.MustNewConstMetric(c.lifetimeFailures, prometheus.CounterValue, float64(counts.LifetimeFailures))
+	ch <- prometheus.MustNewConstMetric(c.lifetimeRejections, prometheus.CounterValue, float64(counts.LifetimeRejections))
+}
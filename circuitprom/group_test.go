@@ -0,0 +1,52 @@
+package circuitprom
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupCollector(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	g, err := circuit.NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	g.Execute("a.example.com", func() error { return nil })
+	g.Execute("b.example.com", func() error { return errors.New("failed") })
+	g.Execute("b.example.com", func() error { return nil })
+
+	c := GroupCollector(g, prometheus.Labels{"dependency": "api"})
+	err = testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP circuit_breaker_group_breakers Number of circuit breakers in the state.
+# TYPE circuit_breaker_group_breakers gauge
+circuit_breaker_group_breakers{dependency="api",state="closed"} 1
+circuit_breaker_group_breakers{dependency="api",state="half-open"} 0
+circuit_breaker_group_breakers{dependency="api",state="open"} 1
+# HELP circuit_breaker_group_failures Number of failed requests in the current periods.
+# TYPE circuit_breaker_group_failures gauge
+circuit_breaker_group_failures{dependency="api"} 0
+# HELP circuit_breaker_group_failures_total Number of failed requests in total.
+# TYPE circuit_breaker_group_failures_total counter
+circuit_breaker_group_failures_total{dependency="api"} 1
+# HELP circuit_breaker_group_rejections Number of rejected requests in the current periods.
+# TYPE circuit_breaker_group_rejections gauge
+circuit_breaker_group_rejections{dependency="api"} 1
+# HELP circuit_breaker_group_rejections_total Number of rejected requests in total.
+# TYPE circuit_breaker_group_rejections_total counter
+circuit_breaker_group_rejections_total{dependency="api"} 1
+# HELP circuit_breaker_group_requests Number of requests in the current periods.
+# TYPE circuit_breaker_group_requests gauge
+circuit_breaker_group_requests{dependency="api"} 1
+# HELP circuit_breaker_group_requests_total Number of requests in total.
+# TYPE circuit_breaker_group_requests_total counter
+circuit_breaker_group_requests_total{dependency="api"} 2
+`))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,102 @@
+// Package circuitredis guards the go-redis clients with circuit breakers.
+package circuitredis
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/djo/circuit"
+	"github.com/redis/go-redis/v9"
+)
+
+// Hook is a redis.Hook guarding the commands of a client with a circuit breaker,
+// it returns circuit.ErrBreakerOpen without sending the commands
+// when the circuit breaker doesn't accept them.
+type Hook struct {
+	b        *circuit.Breaker
+	classify func(error) circuit.Outcome
+}
+
+// NewHook returns a new hook guarding the commands with a given circuit breaker,
+// their errors are classified with classify, Classify is used if it's nil:
+//
+//	rdb.AddHook(circuitredis.NewHook(b, nil))
+func NewHook(b *circuit.Breaker, classify func(err error) circuit.Outcome) *Hook {
+	if classify == nil {
+		classify = Classify
+	}
+
+	return &Hook{b: b, classify: classify}
+}
+
+// InstrumentCluster guards the commands sent to the nodes of a cluster client
+// with the circuit breakers of a given group keyed by the node addresses, see NewHook.
+func InstrumentCluster(c *redis.ClusterClient, g *circuit.Group, classify func(err error) circuit.Outcome) {
+	c.OnNewNode(func(rdb *redis.Client) {
+		rdb.AddHook(NewHook(g.Get(rdb.Options().Addr), classify))
+	})
+}
+
+// retryable are the prefixes of the error replies of a node unable to serve the commands for now.
+var retryable = []string{"LOADING ", "READONLY ", "MASTERDOWN ", "CLUSTERDOWN ", "TRYAGAIN ", "BUSY "}
+
+// Classify is the default classifier of the command errors: redis.Nil is a success,
+// the error replies are successes unless the node is unable to serve the commands
+// for now, e.g. LOADING or CLUSTERDOWN, and the other errors are failures.
+func Classify(err error) circuit.Outcome {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return circuit.OutcomeSuccess
+	}
+
+	var re redis.Error
+	if !errors.As(err, &re) {
+		return circuit.OutcomeFailure
+	}
+	for _, prefix := range retryable {
+		if strings.HasPrefix(re.Error(), prefix) {
+			return circuit.OutcomeFailure
+		}
+	}
+	return circuit.OutcomeSuccess
+}
+
+// DialHook implements redis.Hook, the dials aren't guarded.
+func (h *Hook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook implements redis.Hook.
+//
+// A command given up by the caller, its context is done, is not counted by the circuit breaker.
+func (h *Hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		return h.process(ctx, []redis.Cmder{cmd}, func() error { return next(ctx, cmd) })
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook, a pipeline is counted as one request.
+func (h *Hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		return h.process(ctx, cmds, func() error { return next(ctx, cmds) })
+	}
+}
+
+func (h *Hook) process(ctx context.Context, cmds []redis.Cmder, next func() error) error {
+	tok, err := h.b.Allow()
+	if err != nil {
+		for _, cmd := range cmds {
+			cmd.SetErr(err)
+		}
+		return err
+	}
+
+	err = next()
+
+	if err != nil && ctx.Err() != nil {
+		tok.Ignore()
+	} else {
+		tok.Record(h.classify(err))
+	}
+	return err
+}
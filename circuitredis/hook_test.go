@@ -0,0 +1,67 @@
+package circuitredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+type replyError string
+
+func (e replyError) Error() string { return string(e) }
+func (replyError) RedisError()     {}
+
+func TestClassify(t *testing.T) {
+	assert.Equal(t, circuit.OutcomeSuccess, Classify(nil))
+	assert.Equal(t, circuit.OutcomeSuccess, Classify(redis.Nil))
+	assert.Equal(t, circuit.OutcomeSuccess, Classify(fmt.Errorf("get: %w", redis.Nil)))
+	assert.Equal(t, circuit.OutcomeSuccess, Classify(replyError("WRONGTYPE Operation against a key holding the wrong kind of value")))
+	assert.Equal(t, circuit.OutcomeFailure, Classify(replyError("LOADING Redis is loading the dataset in memory")))
+	assert.Equal(t, circuit.OutcomeFailure, Classify(replyError("CLUSTERDOWN The cluster is down")))
+	assert.Equal(t, circuit.OutcomeFailure, Classify(errors.New("dial tcp 10.0.0.1:6379: connect: connection refused")))
+}
+
+func TestHook(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	var sent int
+	var result error = redis.Nil
+	h := NewHook(b, nil)
+	process := h.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		sent++
+		return result
+	})
+
+	ctx := context.Background()
+	assert.Equal(t, redis.Nil, process(ctx, redis.NewStatusCmd(ctx, "get", "k")))
+	assert.Equal(t, circuit.StateClosed, b.State(), "redis.Nil is a success")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	result = context.Canceled
+	assert.Equal(t, context.Canceled, process(canceled, redis.NewStatusCmd(ctx, "get", "k")))
+	assert.Equal(t, circuit.StateClosed, b.State(), "not counted")
+
+	result = errors.New("i/o timeout")
+	assert.Equal(t, result, process(ctx, redis.NewStatusCmd(ctx, "get", "k")))
+	assert.Equal(t, circuit.StateOpen, b.State())
+
+	cmd := redis.NewStatusCmd(ctx, "get", "k")
+	assert.ErrorIs(t, process(ctx, cmd), circuit.ErrBreakerOpen)
+	assert.ErrorIs(t, cmd.Err(), circuit.ErrBreakerOpen)
+	assert.Equal(t, 3, sent)
+
+	cmds := []redis.Cmder{redis.NewStatusCmd(ctx, "get", "a"), redis.NewStatusCmd(ctx, "get", "b")}
+	pipeline := h.ProcessPipelineHook(func(ctx context.Context, cmds []redis.Cmder) error { return nil })
+	assert.ErrorIs(t, pipeline(ctx, cmds), circuit.ErrBreakerOpen)
+	assert.ErrorIs(t, cmds[1].Err(), circuit.ErrBreakerOpen)
+}
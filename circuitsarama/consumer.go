@@ -0,0 +1,249 @@
+package circuitsarama
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/djo/circuit"
+)
+
+// ConsumerGroup is a sarama.ConsumerGroup guarded by the circuit breakers of a group keyed by topic.
+// Every consumed message is counted as a success of its topic and every sarama.ConsumerError,
+// they're returned when Consumer.Return.Errors is enabled, as a failure.
+// Once the circuit breaker of a topic opens its partitions are paused
+// till it leaves the open state, instead of spinning on a dead broker.
+type ConsumerGroup struct {
+	sarama.ConsumerGroup
+	g      *circuit.Group
+	errors chan error
+
+	mu     sync.Mutex
+	topics map[string]*topic // topics watched by their circuit breakers
+
+	done  chan struct{} // closed by Close
+	close sync.Once
+	wg    sync.WaitGroup // watches of the topics
+}
+
+// topic is a topic watched by its circuit breaker.
+type topic struct {
+	paused     bool
+	partitions map[int32]struct{} // partitions claimed or failed so far
+}
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// errorsBuffer is the capacity of the errors channel of ConsumerGroup.
+const errorsBuffer = 256
+
+// NewConsumerGroup returns a new consumer group guarding a given one
+// with the circuit breakers of a given group keyed by topic.
+// Its errors are passed on to the returned consumer group's Errors,
+// they're dropped when its buffer is full to keep counting the next ones.
+func NewConsumerGroup(cg sarama.ConsumerGroup, g *circuit.Group) *ConsumerGroup {
+	c := &ConsumerGroup{
+		ConsumerGroup: cg,
+		g:             g,
+		errors:        make(chan error, errorsBuffer),
+		topics:        make(map[string]*topic),
+		done:          make(chan struct{}),
+	}
+	go c.forward()
+	return c
+}
+
+// Consume joins the consumer group like sarama.ConsumerGroup.Consume does,
+// counting the messages of the claims of a given handler.
+func (c *ConsumerGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	return c.ConsumerGroup.Consume(ctx, topics, &consumerHandler{ConsumerGroupHandler: handler, c: c})
+}
+
+// Errors returns the errors of the consumer group like sarama.ConsumerGroup.Errors does.
+func (c *ConsumerGroup) Errors() <-chan error {
+	return c.errors
+}
+
+// Close stops watching the circuit breakers of the topics and closes the consumer group
+// like sarama.ConsumerGroup.Close does.
+func (c *ConsumerGroup) Close() error {
+	c.close.Do(func() {
+		close(c.done)
+	})
+	c.wg.Wait()
+	return c.ConsumerGroup.Close()
+}
+
+// forward counts the errors of the consumer group and passes them on till it's closed.
+func (c *ConsumerGroup) forward() {
+	defer close(c.errors)
+
+	for err := range c.ConsumerGroup.Errors() {
+		var ce *sarama.ConsumerError
+		if errors.As(err, &ce) {
+			c.failed(topicPartition{ce.Topic, ce.Partition})
+		}
+
+		select {
+		case c.errors <- err:
+		default:
+		}
+	}
+}
+
+// failed counts a failure of a given partition unless its topic is paused.
+func (c *ConsumerGroup) failed(tp topicPartition) {
+	if c.watch(tp) {
+		return
+	}
+	c.g.Get(tp.topic).Observe(circuit.OutcomeFailure)
+}
+
+// consumed counts a success of a given partition unless its topic is paused.
+func (c *ConsumerGroup) consumed(tp topicPartition) {
+	if c.watch(tp) {
+		return // fetched before the topic was paused, not counted
+	}
+	// delivered already, so not rejected
+	c.g.Get(tp.topic).Observe(circuit.OutcomeSuccess)
+}
+
+// watch starts watching the circuit breaker of the topic of a given partition
+// unless it's watched already, pauses the partition if it's new to a paused topic,
+// reports whether the topic is paused.
+func (c *ConsumerGroup) watch(tp topicPartition) (paused bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.topics[tp.topic]
+	if !ok {
+		t = &topic{partitions: make(map[int32]struct{})}
+		c.topics[tp.topic] = t
+
+		b := c.g.Get(tp.topic)
+		changes, stop := b.Watch()
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			defer stop()
+			c.pauseWhileOpen(tp.topic, b, changes)
+		}()
+	}
+
+	if _, ok := t.partitions[tp.partition]; !ok {
+		t.partitions[tp.partition] = struct{}{}
+		if t.paused {
+			c.ConsumerGroup.Pause(map[string][]int32{tp.topic: {tp.partition}})
+		}
+	}
+	return t.paused
+}
+
+// pauseWhileOpen pauses the partitions of a given topic once its circuit breaker opens
+// and resumes them once it leaves the open state till the consumer group is closed.
+//
+// The open state is left lazily by the next request after the cooldown,
+// so an ignored outcome is observed once it ends for the circuit breaker
+// to move into the half-open state, the consumed messages of the topic are its trial requests then.
+func (c *ConsumerGroup) pauseWhileOpen(topic string, b *circuit.Breaker, changes <-chan circuit.StateChange) {
+	paused := b.State() == circuit.StateOpen
+	if paused {
+		c.setPaused(topic, true)
+	}
+
+	var nudged bool
+	for {
+		var cooldown <-chan time.Time
+		if paused {
+			d := b.RetryAfter()
+			if nudged {
+				// still open, e.g. forced
+				d = max(d, time.Second)
+			}
+			cooldown = time.After(d)
+		}
+
+		select {
+		case change := <-changes:
+			nudged = false
+			switch {
+			case change.To == circuit.StateOpen && !paused:
+				paused = true
+				c.setPaused(topic, true)
+			case change.To != circuit.StateOpen && paused:
+				paused = false
+				c.setPaused(topic, false)
+			}
+		case <-cooldown:
+			nudged = true
+			b.Observe(circuit.OutcomeIgnored)
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// setPaused pauses or resumes the partitions of a given topic.
+func (c *ConsumerGroup) setPaused(topic string, paused bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := c.topics[topic]
+	t.paused = paused
+	partitions := make([]int32, 0, len(t.partitions))
+	for p := range t.partitions {
+		partitions = append(partitions, p)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+
+	if paused {
+		c.ConsumerGroup.Pause(map[string][]int32{topic: partitions})
+	} else {
+		c.ConsumerGroup.Resume(map[string][]int32{topic: partitions})
+	}
+}
+
+// consumerHandler counts the consumed messages of the claims as successes.
+type consumerHandler struct {
+	sarama.ConsumerGroupHandler
+	c *ConsumerGroup
+}
+
+func (h *consumerHandler) ConsumeClaim(s sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	messages := make(chan *sarama.ConsumerMessage)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(messages)
+
+		tp := topicPartition{claim.Topic(), claim.Partition()}
+		for msg := range claim.Messages() {
+			h.c.consumed(tp)
+
+			select {
+			case messages <- msg:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return h.ConsumerGroupHandler.ConsumeClaim(s, &consumerClaim{ConsumerGroupClaim: claim, messages: messages})
+}
+
+// consumerClaim is a claim delivering the messages counted by consumerHandler.
+type consumerClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *consumerClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messages
+}
@@ -0,0 +1,137 @@
+package circuitsarama
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeConsumerGroup struct {
+	sarama.ConsumerGroup
+	errors chan error
+	claim  *fakeClaim
+
+	mu     sync.Mutex
+	paused map[string][]int32
+}
+
+func (f *fakeConsumerGroup) Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error {
+	return handler.ConsumeClaim(nil, f.claim)
+}
+
+func (f *fakeConsumerGroup) Errors() <-chan error { return f.errors }
+
+func (f *fakeConsumerGroup) Close() error { return nil }
+
+func (f *fakeConsumerGroup) Pause(partitions map[string][]int32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for topic, ps := range partitions {
+		f.paused[topic] = append(f.paused[topic], ps...)
+	}
+}
+
+func (f *fakeConsumerGroup) Resume(partitions map[string][]int32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for topic := range partitions {
+		delete(f.paused, topic)
+	}
+}
+
+func (f *fakeConsumerGroup) pausedPartitions() map[string][]int32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	paused := make(map[string][]int32, len(f.paused))
+	for topic, ps := range f.paused {
+		paused[topic] = ps
+	}
+	return paused
+}
+
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (f *fakeClaim) Topic() string                            { return "orders" }
+func (f *fakeClaim) Partition() int32                         { return 0 }
+func (f *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return f.messages }
+
+type handler struct {
+	sarama.ConsumerGroupHandler
+	consumed []int64
+}
+
+func (h *handler) ConsumeClaim(s sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		h.consumed = append(h.consumed, msg.Offset)
+	}
+	return nil
+}
+
+func TestConsumerGroup_Consume(t *testing.T) {
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "orders", Offset: 1}
+	claim.messages <- &sarama.ConsumerMessage{Topic: "orders", Offset: 2}
+	close(claim.messages)
+
+	g := newGroup(t, time.Minute)
+	cg := NewConsumerGroup(&fakeConsumerGroup{errors: make(chan error), claim: claim}, g)
+	defer cg.Close()
+
+	h := &handler{}
+	assert.NoError(t, cg.Consume(context.Background(), []string{"orders"}, h))
+	assert.Equal(t, []int64{1, 2}, h.consumed)
+	assert.Equal(t, uint32(2), g.Get("orders").Counts().Successes)
+}
+
+func TestConsumerGroup_Errors(t *testing.T) {
+	f := &fakeConsumerGroup{errors: make(chan error), paused: make(map[string][]int32)}
+	g := newGroup(t, 20*time.Millisecond)
+	cg := NewConsumerGroup(f, g)
+	defer cg.Close()
+
+	ce := &sarama.ConsumerError{Topic: "payments", Partition: 3, Err: errors.New("not leader for partition")}
+	f.errors <- ce
+	assert.Equal(t, ce, <-cg.Errors())
+	f.errors <- errors.New("kafka: tried to use a consumer group that was closed")
+	assert.Error(t, <-cg.Errors())
+
+	assert.Equal(t, circuit.StateOpen, g.Get("payments").State())
+	assert.Eventually(t, func() bool { return len(f.pausedPartitions()) == 1 }, time.Second, time.Millisecond, "paused once open")
+	assert.Equal(t, map[string][]int32{"payments": {3}}, f.pausedPartitions())
+
+	f.errors <- &sarama.ConsumerError{Topic: "payments", Partition: 4, Err: errors.New("not leader for partition")}
+	<-cg.Errors()
+	assert.Zero(t, g.Get("payments").Counts().Rejections, "no rejections counted while paused")
+	assert.Equal(t, map[string][]int32{"payments": {3, 4}}, f.pausedPartitions())
+
+	assert.Eventually(t, func() bool { return len(f.pausedPartitions()) == 0 }, time.Second, time.Millisecond, "resumed after the cooldown")
+	assert.Equal(t, circuit.StateHalfOpen, g.Get("payments").State())
+
+	close(f.errors)
+	_, ok := <-cg.Errors()
+	assert.False(t, ok)
+}
+
+func TestConsumerGroup_Errors_Unread(t *testing.T) {
+	f := &fakeConsumerGroup{errors: make(chan error), paused: make(map[string][]int32)}
+	g := newGroup(t, time.Minute)
+	cg := NewConsumerGroup(f, g)
+	defer cg.Close()
+
+	for i := 0; i < errorsBuffer+1; i++ {
+		f.errors <- errors.New("kafka: tried to use a consumer group that was closed")
+	}
+	f.errors <- &sarama.ConsumerError{Topic: "payments", Partition: 3, Err: errors.New("not leader for partition")}
+	assert.Eventually(t, func() bool { return g.Get("payments").State() == circuit.StateOpen }, time.Second, time.Millisecond,
+		"counted while the errors aren't read")
+	assert.Len(t, cg.Errors(), errorsBuffer)
+}
@@ -0,0 +1,81 @@
+// Package circuitsarama guards the sarama Kafka producers and consumers with circuit breakers.
+package circuitsarama
+
+import (
+	"errors"
+
+	"github.com/IBM/sarama"
+	"github.com/djo/circuit"
+)
+
+// SyncProducer is a sarama.SyncProducer guarded by the circuit breakers of a group keyed by topic,
+// it returns circuit.ErrBreakerOpen without producing the messages
+// when the circuit breaker of their topic doesn't accept them.
+type SyncProducer struct {
+	sarama.SyncProducer
+	g *circuit.Group
+}
+
+// NewSyncProducer returns a new producer guarding a given one
+// with the circuit breakers of a given group keyed by topic.
+func NewSyncProducer(p sarama.SyncProducer, g *circuit.Group) *SyncProducer {
+	return &SyncProducer{SyncProducer: p, g: g}
+}
+
+// SendMessage produces a message through the circuit breaker of its topic,
+// the errors are counted as failures.
+func (p *SyncProducer) SendMessage(msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
+	tok, err := p.g.Get(msg.Topic).Allow()
+	if err != nil {
+		return -1, -1, err
+	}
+
+	partition, offset, err = p.SyncProducer.SendMessage(msg)
+	if err != nil {
+		tok.Failure()
+	} else {
+		tok.Success()
+	}
+	return partition, offset, err
+}
+
+// SendMessages produces a batch of messages through the circuit breakers of their topics,
+// the batch is counted as one request per topic and is produced only if all of them accept it.
+// A topic failed when one of its messages is in sarama.ProducerErrors,
+// every topic of the batch failed for any other error.
+func (p *SyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	toks := make(map[string]circuit.Token)
+	for _, msg := range msgs {
+		if _, ok := toks[msg.Topic]; ok {
+			continue
+		}
+
+		tok, err := p.g.Get(msg.Topic).Allow()
+		if err != nil {
+			for _, tok := range toks {
+				tok.Ignore()
+			}
+			return err
+		}
+		toks[msg.Topic] = tok
+	}
+
+	err := p.SyncProducer.SendMessages(msgs)
+
+	var failed map[string]bool
+	var pes sarama.ProducerErrors
+	if errors.As(err, &pes) {
+		failed = make(map[string]bool, len(pes))
+		for _, pe := range pes {
+			failed[pe.Msg.Topic] = true
+		}
+	}
+	for topic, tok := range toks {
+		if err != nil && (failed == nil || failed[topic]) {
+			tok.Failure()
+		} else {
+			tok.Success()
+		}
+	}
+	return err
+}
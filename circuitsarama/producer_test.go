@@ -0,0 +1,86 @@
+package circuitsarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProducer struct {
+	sarama.SyncProducer
+	send func(msgs []*sarama.ProducerMessage) error
+	sent []string
+}
+
+func (f *fakeProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	if err := f.SendMessages([]*sarama.ProducerMessage{msg}); err != nil {
+		return -1, -1, err
+	}
+	return 0, 42, nil
+}
+
+func (f *fakeProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	for _, msg := range msgs {
+		f.sent = append(f.sent, msg.Topic)
+	}
+	return f.send(msgs)
+}
+
+func newGroup(t *testing.T, cooldown time.Duration) *circuit.Group {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	g, err := circuit.NewGroup(time.Minute, cooldown, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+	return g
+}
+
+func TestSyncProducer_SendMessage(t *testing.T) {
+	f := &fakeProducer{send: func(msgs []*sarama.ProducerMessage) error {
+		if msgs[0].Topic == "payments" {
+			return errors.New("kafka: client has run out of available brokers")
+		}
+		return nil
+	}}
+	p := NewSyncProducer(f, newGroup(t, time.Minute))
+
+	_, offset, err := p.SendMessage(&sarama.ProducerMessage{Topic: "orders"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), offset)
+
+	_, _, err = p.SendMessage(&sarama.ProducerMessage{Topic: "payments"})
+	assert.Error(t, err)
+	_, _, err = p.SendMessage(&sarama.ProducerMessage{Topic: "payments"})
+	assert.ErrorIs(t, err, circuit.ErrBreakerOpen)
+	assert.Equal(t, []string{"orders", "payments"}, f.sent)
+}
+
+func TestSyncProducer_SendMessages(t *testing.T) {
+	f := &fakeProducer{send: func(msgs []*sarama.ProducerMessage) error {
+		var pes sarama.ProducerErrors
+		for _, msg := range msgs {
+			if msg.Topic == "payments" {
+				pes = append(pes, &sarama.ProducerError{Msg: msg, Err: errors.New("not leader for partition")})
+			}
+		}
+		if pes != nil {
+			return pes
+		}
+		return nil
+	}}
+	g := newGroup(t, time.Minute)
+	p := NewSyncProducer(f, g)
+
+	err := p.SendMessages([]*sarama.ProducerMessage{{Topic: "orders"}, {Topic: "payments"}, {Topic: "orders"}})
+	assert.Error(t, err)
+	assert.Equal(t, circuit.StateClosed, g.Get("orders").State())
+	assert.Equal(t, circuit.StateOpen, g.Get("payments").State())
+
+	err = p.SendMessages([]*sarama.ProducerMessage{{Topic: "orders"}, {Topic: "payments"}})
+	assert.ErrorIs(t, err, circuit.ErrBreakerOpen)
+	assert.Equal(t, uint32(1), g.Get("orders").Counts().Total, "the rejected batch is not counted")
+	assert.Len(t, f.sent, 3)
+}
@@ -0,0 +1,215 @@
+// Package circuitstatsd emits the counters and state changes of circuit breakers
+// to a StatsD or DogStatsD endpoint.
+package circuitstatsd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/djo/circuit"
+)
+
+// maxPacketSize keeps a packet within the common MTU.
+const maxPacketSize = 1432
+
+// Emitter flushes the counters of the added circuit breakers
+// every flush interval: the executions, failures and rejections
+// since the previous flush as counters, the state as a gauge.
+type Emitter struct {
+	conn      net.Conn
+	prefix    string
+	interval  time.Duration
+	dogstatsd bool
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type breaker struct {
+	b    *circuit.Breaker
+	last circuit.Counts // counts at the previous flush
+}
+
+// Option configures the optional behavior of the emitter.
+type Option func(*Emitter)
+
+// WithPrefix sets the prefix of the metric names, "circuit" by default.
+func WithPrefix(prefix string) Option {
+	return func(e *Emitter) {
+		e.prefix = prefix
+	}
+}
+
+// WithFlushInterval sets how often the metrics are flushed, 10 seconds by default.
+func WithFlushInterval(d time.Duration) Option {
+	return func(e *Emitter) {
+		e.interval = d
+	}
+}
+
+// WithDogStatsD makes the emitter tag the metrics with the breaker's name
+// instead of putting it into the metric names, and send an event on the state changes.
+func WithDogStatsD() Option {
+	return func(e *Emitter) {
+		e.dogstatsd = true
+	}
+}
+
+// New returns a new emitter sending the metrics to a given UDP address
+// and starts flushing them in the background until Close.
+func New(addr string, opts ...Option) (*Emitter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Emitter{
+		conn:     conn,
+		prefix:   "circuit",
+		interval: 10 * time.Second,
+		breakers: make(map[string]*breaker),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	go e.run()
+	return e, nil
+}
+
+// Add starts emitting the metrics of a given circuit breaker under the name,
+// replacing a circuit breaker previously added with the same name.
+func (e *Emitter) Add(name string, b *circuit.Breaker) {
+	e.mu.Lock()
+	e.breakers[name] = &breaker{b: b, last: b.Counts()}
+	e.mu.Unlock()
+}
+
+// AddRegistry adds every circuit breaker of a given registry under its name.
+func (e *Emitter) AddRegistry(r *circuit.Registry) {
+	r.Each(e.Add)
+}
+
+// Remove stops emitting the metrics of the circuit breaker with a given name.
+func (e *Emitter) Remove(name string) {
+	e.mu.Lock()
+	delete(e.breakers, name)
+	e.mu.Unlock()
+}
+
+// Flush sends the metrics of every added circuit breaker now.
+func (e *Emitter) Flush() error {
+	e.mu.Lock()
+	names := make([]string, 0, len(e.breakers))
+	for name := range e.breakers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines [][]byte
+	for _, name := range names {
+		br := e.breakers[name]
+		counts := br.b.Counts()
+		lines = append(lines, e.lines(name, br.last, counts)...)
+		br.last = counts
+	}
+	e.mu.Unlock()
+
+	return e.send(lines)
+}
+
+// Close stops flushing the metrics, flushes them the last time and closes the connection.
+func (e *Emitter) Close() error {
+	close(e.stop)
+	<-e.done
+
+	err := e.Flush()
+	if cerr := e.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (e *Emitter) run() {
+	defer close(e.done)
+
+	t := time.NewTicker(e.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			// the emitter is best effort, a lost packet is a lost flush
+			e.Flush()
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+func (e *Emitter) lines(name string, last, counts circuit.Counts) [][]byte {
+	metric, tags := e.prefix+"."+name+".", ""
+	if e.dogstatsd {
+		metric, tags = e.prefix+".", "|#breaker:"+name
+	}
+
+	lines := [][]byte{
+		[]byte(fmt.Sprintf("%sexecutions:%d|c%s", metric, counts.LifetimeTotal-last.LifetimeTotal, tags)),
+		[]byte(fmt.Sprintf("%sfailures:%d|c%s", metric, counts.LifetimeFailures-last.LifetimeFailures, tags)),
+		[]byte(fmt.Sprintf("%srejections:%d|c%s", metric, counts.LifetimeRejections-last.LifetimeRejections, tags)),
+		[]byte(fmt.Sprintf("%stransitions:%d|c%s", metric, counts.Transitions-last.Transitions, tags)),
+		[]byte(fmt.Sprintf("%sstate:%d|g%s", metric, counts.State, tags)),
+	}
+
+	if e.dogstatsd && counts.Transitions != last.Transitions {
+		title := "circuit breaker " + name + " is " + counts.State.String()
+		text := fmt.Sprintf("%d transition(s) since the previous flush, from %s", counts.Transitions-last.Transitions, last.State)
+		lines = append(lines, []byte(fmt.Sprintf("_e{%d,%d}:%s|%s|t:%s%s", len(title), len(text), title, text, alertType(counts.State), tags)))
+	}
+	return lines
+}
+
+func alertType(s circuit.State) string {
+	switch s {
+	case circuit.StateOpen:
+		return "error"
+	case circuit.StateHalfOpen:
+		return "warning"
+	}
+	return "success"
+}
+
+// send sends the lines packed into as few packets as possible.
+func (e *Emitter) send(lines [][]byte) error {
+	var packet bytes.Buffer
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := e.conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxPacketSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.Write(line)
+	}
+	return flush()
+}
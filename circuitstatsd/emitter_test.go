@@ -0,0 +1,90 @@
+package circuitstatsd
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmitter(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	e, err := New(conn.LocalAddr().String(), WithPrefix("app"), WithFlushInterval(time.Hour))
+	assert.NoError(t, err)
+	defer e.Close()
+
+	b.Execute(func() error { return nil })
+	e.Add("payments", b)
+
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return nil })
+
+	assert.NoError(t, e.Flush())
+	assert.Equal(t, []string{
+		"app.payments.executions:2|c",
+		"app.payments.failures:2|c",
+		"app.payments.rejections:1|c",
+		"app.payments.transitions:1|c",
+		"app.payments.state:2|g",
+	}, read(t, conn))
+
+	// only the changes since the previous flush
+	b.Execute(func() error { return nil })
+	assert.NoError(t, e.Flush())
+	assert.Equal(t, []string{
+		"app.payments.executions:0|c",
+		"app.payments.failures:0|c",
+		"app.payments.rejections:1|c",
+		"app.payments.transitions:0|c",
+		"app.payments.state:2|g",
+	}, read(t, conn))
+}
+
+func TestEmitter_WithDogStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	r := circuit.NewRegistry()
+	b, err := r.NewBreaker("payments", time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	e, err := New(conn.LocalAddr().String(), WithDogStatsD(), WithFlushInterval(time.Hour))
+	assert.NoError(t, err)
+	e.AddRegistry(r)
+
+	b.Execute(func() error { return errors.New("failed") })
+
+	assert.NoError(t, e.Close())
+	assert.Equal(t, []string{
+		"circuit.executions:1|c|#breaker:payments",
+		"circuit.failures:1|c|#breaker:payments",
+		"circuit.rejections:0|c|#breaker:payments",
+		"circuit.transitions:1|c|#breaker:payments",
+		"circuit.state:2|g|#breaker:payments",
+		"_e{32,53}:circuit breaker payments is open|1 transition(s) since the previous flush, from closed|t:error|#breaker:payments",
+	}, read(t, conn))
+}
+
+func read(t *testing.T, conn net.PacketConn) []string {
+	buf := make([]byte, maxPacketSize)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	return strings.Split(string(buf[:n]), "\n")
+}
@@ -0,0 +1,115 @@
+// Package circuittest provides the utilities to unit-test the configurations
+// of the circuit breakers without sleeping in the tests:
+// a clock moved by hand, helpers to drive the circuit breakers into a state
+// and the assertions on their state and counters.
+//
+//	clock := circuittest.NewClock(time.Unix(0, 0))
+//	b, _ := circuit.NewBreaker(time.Minute, 10*time.Second, 5, toOpen, toClosed, circuit.WithClock(clock))
+//
+//	circuittest.Fail(b, 3)
+//	circuittest.ExpectState(t, b, circuit.StateOpen)
+//
+//	clock.Advance(11 * time.Second)
+//	circuittest.Succeed(b, 5)
+//	circuittest.ExpectState(t, b, circuit.StateHalfOpen)
+package circuittest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/djo/circuit"
+)
+
+// ErrFailed is the error of the failing requests run by Fail.
+var ErrFailed = errors.New("circuittest: failed")
+
+// TB is the subset of testing.TB used by the assertions.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Counts are the counters of the current period checked by ExpectCounts.
+type Counts struct {
+	Total      uint32
+	Failures   uint32
+	Successes  uint32
+	Rejections uint32
+}
+
+// Succeed runs n succeeding requests through the circuit breaker.
+func Succeed(b *circuit.Breaker, n int) {
+	for i := 0; i < n; i++ {
+		b.Execute(func() error { return nil })
+	}
+}
+
+// Fail runs n requests through the circuit breaker failing with ErrFailed.
+func Fail(b *circuit.Breaker, n int) {
+	for i := 0; i < n; i++ {
+		b.Execute(func() error { return ErrFailed })
+	}
+}
+
+// SetState moves the circuit breaker into a given state for a full period
+// (the interval, cooldown or half-open one) starting at the current time of its clock,
+// with zeroed counters and no override.
+func SetState(b *circuit.Breaker, state circuit.State) error {
+	b.Reset()
+	if state == circuit.StateClosed {
+		return nil
+	}
+
+	// the period reset has just started by the clock of the circuit breaker
+	now := b.Counts().WindowStart
+	cfg := b.Config()
+	until := now.Add(cfg.Interval)
+	if state == circuit.StateOpen {
+		until = now.Add(cfg.Cooldown)
+	}
+
+	saved, err := json.Marshal(struct {
+		State    string    `json:"state"`
+		Until    time.Time `json:"until"`
+		Override string    `json:"override"`
+	}{state.String(), until, circuit.OverrideNone.String()})
+	if err != nil {
+		return err
+	}
+	return b.LoadFrom(bytes.NewReader(saved))
+}
+
+// ExpectState reports an error unless the circuit breaker is in a given state.
+func ExpectState(t TB, b *circuit.Breaker, want circuit.State) bool {
+	t.Helper()
+	if got := b.State(); got != want {
+		t.Errorf("circuittest: %s state is %v, want %v", name(b), got, want)
+		return false
+	}
+	return true
+}
+
+// ExpectCounts reports an error unless the counters of the current period
+// of the circuit breaker are the given ones.
+func ExpectCounts(t TB, b *circuit.Breaker, want Counts) bool {
+	t.Helper()
+	c := b.Counts()
+	got := Counts{Total: c.Total, Failures: c.Failures, Successes: c.Successes, Rejections: c.Rejections}
+	if got != want {
+		t.Errorf("circuittest: %s counts are %+v, want %+v", name(b), got, want)
+		return false
+	}
+	return true
+}
+
+// name returns the name of the circuit breaker for the messages.
+func name(b *circuit.Breaker) string {
+	if b.Name() == "" {
+		return "circuit breaker"
+	}
+	return fmt.Sprintf("circuit breaker %q", b.Name())
+}
@@ -0,0 +1,84 @@
+package circuittest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records the errors reported by the assertions.
+type recorder struct {
+	errs []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func newBreaker(t *testing.T, clock *Clock) *circuit.Breaker {
+	toOpen := func(total uint32, failures uint32) bool { return failures >= 3 }
+	toClosed := func(total uint32, failures uint32) bool { return failures == 0 }
+	b, err := circuit.NewBreaker(time.Minute, 10*time.Second, 2, toOpen, toClosed,
+		circuit.WithName("payments"), circuit.WithClock(clock))
+	assert.NoError(t, err)
+	return b
+}
+
+func TestBreaker(t *testing.T) {
+	clock := NewClock(time.Unix(1520100000, 0))
+	b := newBreaker(t, clock)
+
+	Succeed(b, 1)
+	Fail(b, 2)
+	ExpectState(t, b, circuit.StateClosed)
+	ExpectCounts(t, b, Counts{Total: 3, Failures: 2, Successes: 1})
+
+	Fail(b, 1)
+	ExpectState(t, b, circuit.StateOpen)
+	Succeed(b, 1)
+	ExpectCounts(t, b, Counts{Rejections: 1})
+
+	clock.Advance(10*time.Second + time.Nanosecond)
+	Succeed(b, 2)
+	ExpectState(t, b, circuit.StateHalfOpen)
+	Succeed(b, 1)
+	ExpectState(t, b, circuit.StateClosed)
+}
+
+func TestSetState(t *testing.T) {
+	clock := NewClock(time.Unix(1520100000, 0))
+	b := newBreaker(t, clock)
+	Fail(b, 1)
+
+	assert.NoError(t, SetState(b, circuit.StateHalfOpen))
+	ExpectState(t, b, circuit.StateHalfOpen)
+	ExpectCounts(t, b, Counts{})
+	assert.Equal(t, time.Unix(1520100060, 0), b.NextTransition())
+
+	assert.NoError(t, SetState(b, circuit.StateOpen))
+	ExpectState(t, b, circuit.StateOpen)
+	assert.Equal(t, 10*time.Second, b.RetryAfter())
+
+	b.ForceOpen()
+	assert.NoError(t, SetState(b, circuit.StateClosed))
+	ExpectState(t, b, circuit.StateClosed)
+	assert.Equal(t, circuit.OverrideNone, b.Override())
+}
+
+func TestExpect(t *testing.T) {
+	b := newBreaker(t, NewClock(time.Unix(1520100000, 0)))
+	Fail(b, 1)
+
+	r := &recorder{}
+	assert.False(t, ExpectState(r, b, circuit.StateOpen))
+	assert.False(t, ExpectCounts(r, b, Counts{Total: 1}))
+	assert.Equal(t, []string{
+		`circuittest: circuit breaker "payments" state is closed, want open`,
+		`circuittest: circuit breaker "payments" counts are {Total:1 Failures:1 Successes:0 Rejections:0}, want {Total:1 Failures:0 Successes:0 Rejections:0}`,
+	}, r.errs)
+}
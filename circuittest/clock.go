@@ -0,0 +1,75 @@
+package circuittest
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a circuit.TimerClock moved by hand, safe for concurrent use,
+// pass it to the circuit breaker with circuit.WithClock:
+//
+//	clock := circuittest.NewClock(time.Unix(0, 0))
+//	b, err := circuit.NewBreaker(time.Minute, 10*time.Second, 1, toOpen, toClosed, circuit.WithClock(clock))
+//	...
+//	clock.Advance(11 * time.Second) // the cooldown elapsed
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []timer
+}
+
+type timer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewClock returns a clock stopped at a given time.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the time once the clock is advanced by d.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := timer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t.c
+	}
+	c.timers = append(c.timers, t)
+	return t.c
+}
+
+// Advance moves the clock forward by d firing the timers due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- c.now
+	}
+	c.timers = pending
+}
+
+// Timers returns the number of the timers not fired yet,
+// e.g. to wait until a request started waiting for its execution timeout.
+func (c *Clock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
@@ -0,0 +1,55 @@
+package circuittest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Unix(1520100000, 0)
+	clock := NewClock(start)
+	assert.Equal(t, start, clock.Now())
+
+	second := clock.After(time.Second)
+	minute := clock.After(time.Minute)
+	assert.Equal(t, 2, clock.Timers())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), <-second)
+	assert.Equal(t, 1, clock.Timers())
+	select {
+	case <-minute:
+		t.Fatal("fired too early")
+	default:
+	}
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, start.Add(time.Hour+time.Second), <-minute)
+	assert.Equal(t, 0, clock.Timers())
+
+	// fires immediately
+	assert.Equal(t, clock.Now(), <-clock.After(0))
+}
+
+func TestClock_ExecutionTimeout(t *testing.T) {
+	clock := NewClock(time.Unix(1520100000, 0))
+	to := func(uint32, uint32) bool { return false }
+	b, err := circuit.NewBreaker(time.Minute, time.Minute, 1, to, to,
+		circuit.WithClock(clock), circuit.WithExecutionTimeout(time.Second))
+	assert.NoError(t, err)
+
+	release := make(chan struct{})
+	defer close(release)
+	done := make(chan error)
+	go func() {
+		done <- b.Execute(func() error { <-release; return nil })
+	}()
+
+	assert.Eventually(t, func() bool { return clock.Timers() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+	assert.True(t, errors.Is(<-done, circuit.ErrExecutionTimeout))
+}
@@ -0,0 +1,107 @@
+package circuittest
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/djo/circuit"
+)
+
+// Step is a request replayed by Simulate.
+type Step struct {
+	At      time.Duration // offset of the request since the start of the simulation
+	Failure bool          // whether the request fails
+}
+
+// Requests returns n steps, one every interval starting at offset from,
+// all of them failing or succeeding, to script a simulation:
+//
+//	steps := append(circuittest.Requests(0, time.Second, 60, false),
+//		circuittest.Requests(time.Minute, 100*time.Millisecond, 50, true)...)
+func Requests(from time.Duration, every time.Duration, n int, failure bool) []Step {
+	steps := make([]Step, n)
+	for i := range steps {
+		steps[i] = Step{At: from + time.Duration(i)*every, Failure: failure}
+	}
+	return steps
+}
+
+// Transition is a change of the state during a simulation.
+type Transition struct {
+	At       time.Duration // offset since the start of the simulation
+	From, To circuit.State
+}
+
+// Result is the outcome of a simulation.
+type Result struct {
+	Timeline   []Transition  // changes of the state in the order they happened
+	Requests   int           // # of the requests replayed
+	Failures   int           // # of the failed requests accepted by the circuit breaker
+	Rejections int           // # of the requests rejected by the circuit breaker
+	Final      circuit.State // state after the last request
+}
+
+// Rejected returns the time spent rejecting the requests, the open state,
+// until a given offset since the start of the simulation.
+func (r Result) Rejected(until time.Duration) time.Duration {
+	var open time.Duration
+	var since time.Duration = -1
+	for _, t := range r.Timeline {
+		if t.To == circuit.StateOpen && since < 0 {
+			since = t.At
+		} else if t.To != circuit.StateOpen && since >= 0 {
+			open += t.At - since
+			since = -1
+		}
+	}
+	if since >= 0 && until > since {
+		open += until - since
+	}
+	return open
+}
+
+// Simulate replays the requests through a new circuit breaker with a given configuration
+// and options on a clock moved from one request to the next,
+// so the thresholds can be validated offline against a recorded or scripted traffic.
+// The steps are replayed in the order of their offsets.
+func Simulate(cfg circuit.Config, steps []Step, opts ...circuit.Option) (Result, error) {
+	start := time.Unix(0, 0)
+	clock := NewClock(start)
+
+	var r Result
+	onStateChange := func(from, to circuit.State, _ circuit.Counts) {
+		r.Timeline = append(r.Timeline, Transition{At: clock.Now().Sub(start), From: from, To: to})
+	}
+	opts = append(append([]circuit.Option{}, opts...), circuit.WithClock(clock), circuit.WithOnStateChange(onStateChange))
+
+	b, err := circuit.NewBreaker(cfg.Interval, cfg.Cooldown, cfg.AtLeastReqs, cfg.ToOpen, cfg.ToClosed, opts...)
+	if err != nil {
+		return Result{}, err
+	}
+
+	steps = append([]Step{}, steps...)
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].At < steps[j].At })
+
+	for _, s := range steps {
+		if d := s.At - clock.Now().Sub(start); d > 0 {
+			clock.Advance(d)
+		}
+
+		err := b.Execute(func() error {
+			if s.Failure {
+				return ErrFailed
+			}
+			return nil
+		})
+		r.Requests++
+		switch {
+		case errors.Is(err, circuit.ErrBreakerOpen):
+			r.Rejections++
+		case err != nil:
+			r.Failures++
+		}
+	}
+	r.Final = b.State()
+	return r, nil
+}
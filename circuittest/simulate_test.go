@@ -0,0 +1,60 @@
+package circuittest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/djo/circuit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	cfg := circuit.Config{
+		Interval:    time.Minute,
+		Cooldown:    10 * time.Second,
+		AtLeastReqs: 2,
+		ToOpen:      func(total uint32, failures uint32) bool { return failures >= 3 },
+		ToClosed:    func(total uint32, failures uint32) bool { return failures == 0 },
+	}
+
+	// out of order, replayed by the offsets; the half-open state is decided on the request after atLeastReqs
+	steps := append(Requests(14*time.Second, time.Second, 3, false), Step{At: 0})
+	steps = append(steps, Requests(time.Second, time.Second, 3, true)...)
+	steps = append(steps, Step{At: 5 * time.Second})
+
+	r, err := Simulate(cfg, steps)
+	assert.NoError(t, err)
+	assert.Equal(t, []Transition{
+		{At: 3 * time.Second, From: circuit.StateClosed, To: circuit.StateOpen},
+		{At: 14 * time.Second, From: circuit.StateOpen, To: circuit.StateHalfOpen},
+		{At: 16 * time.Second, From: circuit.StateHalfOpen, To: circuit.StateClosed},
+	}, r.Timeline)
+	assert.Equal(t, 8, r.Requests)
+	assert.Equal(t, 3, r.Failures)
+	assert.Equal(t, 1, r.Rejections)
+	assert.Equal(t, circuit.StateClosed, r.Final)
+	assert.Equal(t, 11*time.Second, r.Rejected(time.Minute))
+}
+
+func TestSimulate_Open(t *testing.T) {
+	cfg := circuit.Config{
+		Interval:    time.Minute,
+		Cooldown:    10 * time.Second,
+		AtLeastReqs: 1,
+		ToOpen:      circuit.RateThreshold(0.5, 1),
+		ToClosed:    func(total uint32, failures uint32) bool { return failures == 0 },
+	}
+
+	r, err := Simulate(cfg, Requests(0, time.Second, 5, true), circuit.WithName("payments"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Transition{{At: 0, From: circuit.StateClosed, To: circuit.StateOpen}}, r.Timeline)
+	assert.Equal(t, 1, r.Failures)
+	assert.Equal(t, 4, r.Rejections)
+	assert.Equal(t, circuit.StateOpen, r.Final)
+	assert.Equal(t, time.Minute, r.Rejected(time.Minute))
+}
+
+func TestSimulate_InvalidConfig(t *testing.T) {
+	_, err := Simulate(circuit.Config{}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,125 @@
+package circuit
+
+import "errors"
+
+// WithFailureClassifier makes the circuit breaker count only the errors
+// for which isFailure returns true as failures, e.g. downstream faults,
+// the other errors (validation errors, not found, etc.) count as successes.
+//
+// By default every non-nil error is a failure.
+func WithFailureClassifier(isFailure func(error) bool) Option {
+	return func(b *Breaker) {
+		b.isFailure = isFailure
+	}
+}
+
+// WithCriticalErrors makes the errors for which isCritical returns true,
+// e.g. a connection refused or a DNS failure, open the circuit breaker immediately
+// regardless of the thresholds, as they reliably indicate a dead dependency.
+//
+// The critical errors count as failures even if the failure classifier says otherwise.
+func WithCriticalErrors(isCritical func(error) bool) Option {
+	return func(b *Breaker) {
+		b.isCritical = isCritical
+	}
+}
+
+// WithIgnoredErrors makes the circuit breaker not count the requests returned
+// any of the given errors, matched with errors.Is, neither as failures nor as successes,
+// e.g. sql.ErrNoRows or io.EOF, as if they were never accepted.
+//
+// It takes precedence over the failure classifier, a panic is never ignored.
+func WithIgnoredErrors(errs ...error) Option {
+	return func(b *Breaker) {
+		b.ignoredErrs = append(b.ignoredErrs, errs...)
+	}
+}
+
+// ignored reports whether a request returned err is not counted at all, see WithIgnoredErrors.
+func (b *Breaker) ignored(err error) bool {
+	if err == nil || b.ignoredErrs == nil {
+		return false
+	}
+	if _, ok := err.(*PanicError); ok {
+		return false
+	}
+	for _, target := range b.ignoredErrs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// failed reports whether a request returned err counts as a failure,
+// a panic, the execution timeout and a critical error always do.
+func (b *Breaker) failed(err error) bool {
+	if err == nil {
+		return false
+	}
+	if b.critical(err) {
+		return true
+	}
+	if _, ok := err.(*PanicError); ok {
+		return true
+	}
+	if err == ErrExecutionTimeout {
+		return true
+	}
+	if b.isFailure == nil {
+		return true
+	}
+	return b.isFailure(err)
+}
+
+// critical reports whether a request returned err opens the circuit breaker immediately.
+func (b *Breaker) critical(err error) bool {
+	return err != nil && b.isCritical != nil && b.isCritical(err)
+}
+
+// Outcome is the outcome of a request as the circuit breaker counts it.
+type Outcome int
+
+const (
+	// OutcomeSuccess counts the request as succeeded.
+	OutcomeSuccess Outcome = iota
+	// OutcomeFailure counts the request as failed.
+	OutcomeFailure
+	// OutcomeIgnored doesn't count the request at all.
+	OutcomeIgnored
+	// OutcomeCritical counts the request as failed and opens the circuit breaker
+	// immediately regardless of the thresholds.
+	OutcomeCritical
+)
+
+// WithResultClassifier makes Do decide on the outcome of a request
+// by its result too, e.g. an HTTP response with the 500 status code
+// and a nil error or a partial result can be counted as a failure.
+//
+// It takes precedence over the failure classifier in Do.
+func WithResultClassifier(classify func(v any, err error) Outcome) Option {
+	return func(b *Breaker) {
+		b.classifyResult = classify
+	}
+}
+
+// outcome returns the outcome of a request returned v and err,
+// v is converted to any only for the result classifier to keep Do allocation free.
+func outcome[T any](b *Breaker, v T, err error) Outcome {
+	if _, ok := err.(*PanicError); ok || err == ErrExecutionTimeout {
+		return OutcomeFailure
+	}
+	if b.classifyResult != nil {
+		return b.classifyResult(v, err)
+	}
+	if b.ignored(err) {
+		return OutcomeIgnored
+	}
+	if b.critical(err) {
+		return OutcomeCritical
+	}
+	if b.failed(err) {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
@@ -0,0 +1,112 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithFailureClassifier(t *testing.T) {
+	errInvalid := errors.New("invalid")
+	isFailure := func(err error) bool { return err != errInvalid }
+
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithFailureClassifier(isFailure))
+	assert.NoError(t, err)
+
+	err = b.Execute(func() error { return errInvalid })
+	assert.Equal(t, errInvalid, err)
+	assert.Equal(t, uint64(0), b.failures)
+	assert.Equal(t, uint64(1), b.successes)
+	assert.Equal(t, closed, b.loadState())
+
+	err = b.Execute(func() error { return errors.New("unavailable") })
+	assert.Error(t, err)
+	assert.Equal(t, open, b.loadState())
+}
+
+func TestBreaker_WithCriticalErrors(t *testing.T) {
+	errRefused := errors.New("connection refused")
+	isCritical := func(err error) bool { return err == errRefused }
+
+	toOpen := func(total uint32, failures uint32) bool { return failures >= 100 }
+	toClosed := func(total uint32, failures uint32) bool { return failures == 0 }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000),
+		WithCriticalErrors(isCritical), WithFailureClassifier(func(error) bool { return false }))
+	assert.NoError(t, err)
+
+	err = b.Execute(func() error { return errors.New("unavailable") })
+	assert.Error(t, err)
+	assert.Equal(t, closed, b.loadState())
+
+	// counted as a failure despite the failure classifier, opens regardless of toOpen
+	err = b.Execute(func() error { return errRefused })
+	assert.Equal(t, errRefused, err)
+	assert.Equal(t, open, b.loadState())
+	assert.Equal(t, uint64(1), b.Counts().LifetimeFailures)
+
+	// in the half-open state too
+	b.now = now(1520100121)
+	_, err = Do(b, func() (int, error) { return 0, errRefused })
+	assert.Equal(t, errRefused, err)
+	assert.Equal(t, open, b.loadState())
+	assert.Equal(t, uint64(2), b.Counts().LifetimeFailures)
+}
+
+func TestToken_Critical(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return false }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	stale, _ := b.Allow()
+	tok, _ := b.Allow()
+	tok.Critical()
+	assert.Equal(t, open, b.loadState())
+
+	// the outcome of the previous period doesn't open it again
+	b.Reset()
+	stale.Critical()
+	assert.Equal(t, closed, b.loadState())
+	assert.Equal(t, uint64(2), b.Counts().LifetimeFailures)
+
+	// by the result classifier of Do
+	classify := func(v any, err error) Outcome { return OutcomeCritical }
+	b, err = b.With(WithResultClassifier(classify))
+	assert.NoError(t, err)
+	_, err = Do(b, func() (int, error) { return 503, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, open, b.loadState())
+}
+
+func TestBreaker_WithIgnoredErrors(t *testing.T) {
+	errNoRows := errors.New("no rows")
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithIgnoredErrors(errNoRows, io.EOF))
+	assert.NoError(t, err)
+
+	// neither a failure nor a success, matched when wrapped
+	err = b.Execute(func() error { return fmt.Errorf("query: %w", errNoRows) })
+	assert.ErrorIs(t, err, errNoRows)
+	err = b.ExecuteContext(context.Background(), func(context.Context) error { return io.EOF })
+	assert.Equal(t, io.EOF, err)
+	_, err = Do(b, func() (int, error) { return 0, io.EOF })
+	assert.Equal(t, io.EOF, err)
+
+	counts := b.Counts()
+	assert.Equal(t, uint32(0), counts.Total)
+	assert.Equal(t, uint32(0), counts.Successes)
+	assert.Equal(t, uint32(0), counts.Failures)
+	assert.Equal(t, closed, b.loadState())
+
+	// a panic is never ignored
+	assert.Panics(t, func() { b.Execute(func() error { panic(io.EOF) }) })
+	assert.Equal(t, open, b.loadState())
+}
@@ -0,0 +1,38 @@
+package circuit
+
+import "time"
+
+// Clock tells the time to the circuit breaker, see WithClock.
+type Clock interface {
+	Now() time.Time
+}
+
+// TimerClock is a Clock driving the timers of the circuit breaker too:
+// the health probe, the hedged requests, the execution timeout and the backoff of Retry.
+// The timers are real with a Clock which doesn't implement it.
+type TimerClock interface {
+	Clock
+	After(d time.Duration) <-chan time.Time
+}
+
+// WithClock makes the circuit breaker tell the time by a given clock instead of time.Now,
+// e.g. a simulated time driving it deterministically in tests.
+// The context deadline of ExecuteContext with WithExecutionTimeout is real anyway.
+func WithClock(c Clock) Option {
+	return func(b *Breaker) {
+		b.now = c.Now
+		if tc, ok := c.(TimerClock); ok {
+			b.after = tc.After
+		}
+	}
+}
+
+// nanotime returns the current time in nanoseconds the periods are measured in:
+// the wall clock time of the creation plus the time elapsed since then
+// on the monotonic clock, so a step of the wall clock, e.g. by NTP or by hand,
+// neither freezes nor skips a cooldown.
+//
+// The timestamps are still close to the Unix time to be reported as time.Time.
+func (b *Breaker) nanotime() int64 {
+	return b.epoch.UnixNano() + int64(b.now().Sub(b.epoch))
+}
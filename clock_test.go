@@ -0,0 +1,103 @@
+package circuit
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_nanotime(t *testing.T) {
+	to := func(uint32, uint32) bool { return true }
+
+	// time.Now carries the monotonic clock reading kept by Add
+	epoch := time.Now()
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, to, to, func() time.Time { return epoch })
+	assert.NoError(t, err)
+	assert.Equal(t, epoch.UnixNano(), b.nanotime())
+
+	b.now = func() time.Time { return epoch.Add(90 * time.Second) }
+	assert.Equal(t, epoch.UnixNano()+int64(90*time.Second), b.nanotime())
+
+	// the wall clock only, as with a fake clock
+	b.now = func() time.Time { return epoch.Round(0).Add(-time.Hour) }
+	assert.Equal(t, epoch.UnixNano()-int64(time.Hour), b.nanotime())
+}
+
+// fakeClock is a TimerClock moved by hand.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	return t.c
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- c.now
+	}
+	c.timers = pending
+}
+
+func (c *fakeClock) waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+func TestWithClock(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return failures == 0 }
+	clock := &fakeClock{now: time.Unix(1520100000, 0)}
+	b, err := NewBreaker(time.Minute, 2*time.Minute, 1, toOpen, toClosed, WithClock(clock))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Unix(1520100060, 0), b.NextTransition())
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+
+	clock.Advance(2*time.Minute + time.Second)
+	b.Execute(func() error { return nil })
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	// the execution timeout is driven by the clock too
+	b, err = b.With(WithExecutionTimeout(time.Second))
+	assert.NoError(t, err)
+	release := make(chan struct{})
+	defer close(release)
+	done := make(chan error)
+	go func() {
+		done <- b.Execute(func() error { <-release; return nil })
+	}()
+	assert.Eventually(t, func() bool { return clock.waiting() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Second)
+	assert.Equal(t, ErrExecutionTimeout, <-done)
+}
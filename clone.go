@@ -0,0 +1,20 @@
+package circuit
+
+// With returns a new circuit breaker with the configuration of this one,
+// as with Config and the options it was created with, along with the given options
+// applied on top, e.g. to derive the circuit breakers of the dependencies from a baseline:
+//
+//	baseline, err := circuit.NewBreaker(time.Minute, 10*time.Second, 1, toOpen, toClosed, circuit.WithLogger(logger))
+//	payments, err := baseline.With(circuit.WithName("payments"))
+//	accounts, err := baseline.With(circuit.WithName("accounts"), circuit.WithConsecutiveFailures(5))
+//
+// Returns an error if the given options are invalid, e.g. WithRampUp with a non-positive step.
+//
+// The new circuit breaker starts closed with zeroed counters. The observers of the options,
+// e.g. WithObserver or WithLogger, are inherited, the channels of Watch are not.
+// The policies are shared, so a stateful one like EWMA must be replaced with SetPolicy.
+func (b *Breaker) With(opts ...Option) (*Breaker, error) {
+	cfg := b.Config()
+	all := append(append([]Option{}, b.opts...), opts...)
+	return withTimeNow(cfg.Interval, cfg.Cooldown, cfg.AtLeastReqs, cfg.ToOpen, cfg.ToClosed, b.now, all...)
+}
@@ -0,0 +1,53 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_With(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 2 }
+	toClosed := func(uint32, uint32) bool { return true }
+	var events []string
+	observer := ObserverFunc(func(e Event) {
+		if e.Type == EventStateChanged {
+			events = append(events, e.Name)
+		}
+	})
+	baseline, err := withTimeNow(time.Minute, 10*time.Second, 1, toOpen, toClosed, now(1520100000), WithName("baseline"), WithObserver(observer))
+	assert.NoError(t, err)
+	baseline.Execute(func() error { return errors.New("failed") })
+	assert.NoError(t, baseline.SetCooldown(20*time.Second))
+
+	payments, err := baseline.With(WithName("payments"), WithConsecutiveFailures(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "payments", payments.Name())
+	assert.Equal(t, "baseline", baseline.Name())
+	assert.Equal(t, baseline.Status().Config, payments.Status().Config)
+
+	// fresh counters
+	assert.Equal(t, uint32(0), payments.Counts().Failures)
+	assert.Equal(t, uint32(1), baseline.Counts().Failures)
+
+	// the override applies to the clone only
+	payments.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, payments.State())
+	assert.Equal(t, int64(1520100020000000000), payments.until)
+	assert.Equal(t, StateClosed, baseline.State())
+
+	// the observer is inherited
+	assert.Equal(t, []string{"payments"}, events)
+}
+
+func TestBreaker_With_Invalid(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	baseline, err := NewBreaker(time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+
+	b, err := baseline.With(WithRampUp(0))
+	assert.EqualError(t, err, "circuit: ramp-up step must be positive")
+	assert.Nil(t, b)
+}
@@ -0,0 +1,87 @@
+package circuit
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithCoalescing makes DoKeyed deduplicate the concurrent requests with the same key
+// into a single request while the circuit breaker is half-open
+// and for a given duration after it's closed from the half-open state,
+// so a stampede of the identical requests can't open it again.
+func WithCoalescing(d time.Duration) Option {
+	return func(b *Breaker) {
+		b.coalesceFor = d.Nanoseconds()
+	}
+}
+
+// flight is a request run once for the concurrent callers with the same key.
+type flight struct {
+	wg  sync.WaitGroup
+	v   any
+	err error
+}
+
+// flights is a set of the requests in flight by key.
+type flights struct {
+	mu sync.Mutex
+	m  map[string]*flight
+}
+
+// DoKeyed runs a given request like Do does, the concurrent requests
+// with the same key share the result of a single one when coalesced, see WithCoalescing.
+// Only the single request is counted by the circuit breaker.
+func DoKeyed[T any](b *Breaker, key string, req func() (T, error)) (T, error) {
+	if !b.coalescing() {
+		return Do(b, req)
+	}
+
+	b.flights.mu.Lock()
+	if b.flights.m == nil {
+		b.flights.m = make(map[string]*flight)
+	}
+	if f, ok := b.flights.m[key]; ok {
+		b.flights.mu.Unlock()
+		f.wg.Wait()
+		b.repanic(f.err)
+		v, _ := f.v.(T)
+		return v, f.err
+	}
+
+	f := &flight{}
+	f.wg.Add(1)
+	b.flights.m[key] = f
+	b.flights.mu.Unlock()
+
+	var v T
+	f.err = guard(func() error {
+		var err error
+		v, err = Do(b, req)
+		return err
+	})
+	f.v = v
+
+	b.flights.mu.Lock()
+	delete(b.flights.m, key)
+	b.flights.mu.Unlock()
+	f.wg.Done()
+
+	b.repanic(f.err)
+	return v, f.err
+}
+
+// coalescing reports whether the requests are coalesced now.
+func (b *Breaker) coalescing() bool {
+	if b.coalesceFor == 0 {
+		return false
+	}
+
+	switch b.loadState() {
+	case halfOpen:
+		return true
+	case closed:
+		return b.nanotime()-atomic.LoadInt64(&b.recovered) <= b.coalesceFor
+	}
+	return false
+}
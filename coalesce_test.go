@@ -0,0 +1,63 @@
+package circuit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoKeyed(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return false }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 100, toOpen, toClosed, now(1520100000), WithCoalescing(10*time.Second))
+	assert.NoError(t, err)
+
+	var calls int32
+	release := make(chan struct{})
+	req := func() (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, nil
+	}
+
+	run := func(n int) []int {
+		results := make([]int, n)
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				defer wg.Done()
+				results[i], _ = DoKeyed(b, "key", req)
+			}(i)
+		}
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		return results
+	}
+
+	// not coalesced in the closed state
+	assert.Equal(t, []int{42, 42, 42}, run(3))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// coalesced in the half-open state
+	b.ForceOpen()
+	b.ClearOverride()
+	b.now = now(1520100061)
+	atomic.StoreInt32(&calls, 0)
+	release = make(chan struct{})
+	b.Execute(func() error { return nil })
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.Equal(t, []int{42, 42, 42}, run(3))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, uint32(2), b.Counts().Total)
+
+	// and for a while after the recovery
+	b.switchTo(closed, b.word, time.Unix(1520100061, 0).UnixNano(), time.Unix(1520100121, 0).UnixNano())
+	assert.True(t, b.coalescing())
+	b.now = now(1520100072)
+	assert.False(t, b.coalescing())
+}
@@ -0,0 +1,119 @@
+package circuit
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Config is the configuration of the circuit breaker, see NewBreaker.
+type Config struct {
+	Interval    time.Duration
+	Cooldown    time.Duration
+	AtLeastReqs uint32
+	ToOpen      ToState
+	ToClosed    ToState
+}
+
+// policy is the pair of the functions deciding on the transitions.
+type policy struct {
+	toOpen   ToState // called on failure being in the closed state
+	toClosed ToState // called after atLeastReqs being in the half-open state
+}
+
+// Config returns the configuration of the circuit breaker.
+func (b *Breaker) Config() Config {
+	p := b.policies()
+	return Config{
+		Interval:    time.Duration(b.intervalNanos()),
+		Cooldown:    time.Duration(b.cooldownNanos()),
+		AtLeastReqs: atomic.LoadUint32(&b.atLeastReqs),
+		ToOpen:      p.toOpen,
+		ToClosed:    p.toClosed,
+	}
+}
+
+// UpdateConfig swaps the configuration of the circuit breaker at runtime,
+// e.g. on a reload of the configuration file, without losing its state,
+// counters and subscribers.
+//
+// The current interval, cooldown or half-open period runs to its end,
+// the new interval and cooldown apply from the next one.
+// The new atLeastReqs and policies apply to the next decision.
+// The rolling window keeps its size.
+//
+// Returns an error as NewBreaker does if the configuration is invalid,
+// then the circuit breaker is left unchanged.
+func (b *Breaker) UpdateConfig(cfg Config) error {
+	if err := validate(cfg.Interval, cfg.Cooldown, cfg.AtLeastReqs, cfg.ToOpen, cfg.ToClosed); err != nil {
+		return err
+	}
+
+	atomic.StoreInt64(&b.interval, cfg.Interval.Nanoseconds())
+	atomic.StoreInt64(&b.cooldown, cfg.Cooldown.Nanoseconds())
+	atomic.StoreUint32(&b.atLeastReqs, cfg.AtLeastReqs)
+	b.policy.Store(policy{toOpen: cfg.ToOpen, toClosed: cfg.ToClosed})
+	return nil
+}
+
+// SetInterval changes the interval of the closed state at runtime,
+// it applies from the next interval, see UpdateConfig.
+func (b *Breaker) SetInterval(interval time.Duration) error {
+	if interval.Nanoseconds() == 0 {
+		return errors.New("circuit: interval must be set")
+	}
+	atomic.StoreInt64(&b.interval, interval.Nanoseconds())
+	return nil
+}
+
+// SetCooldown changes the cooldown of the open state at runtime,
+// it applies from the next cooldown, see UpdateConfig.
+func (b *Breaker) SetCooldown(cooldown time.Duration) error {
+	if cooldown.Nanoseconds() == 0 {
+		return errors.New("circuit: cooldown must be set")
+	}
+	atomic.StoreInt64(&b.cooldown, cooldown.Nanoseconds())
+	return nil
+}
+
+// SetAtLeastReqs changes the number of requests to consider in the half-open state at runtime,
+// it applies to the next request.
+func (b *Breaker) SetAtLeastReqs(atLeastReqs uint32) error {
+	if atLeastReqs == 0 {
+		return errors.New("circuit: atLeastReqs must be set")
+	}
+	atomic.StoreUint32(&b.atLeastReqs, atLeastReqs)
+	return nil
+}
+
+// SetPolicy swaps the toOpen and toClosed functions at runtime,
+// they apply to the next decision.
+func (b *Breaker) SetPolicy(toOpen ToState, toClosed ToState) error {
+	if toOpen == nil {
+		return errors.New("circuit: toOpen must be defined")
+	}
+	if toClosed == nil {
+		return errors.New("circuit: toClosed must be defined")
+	}
+	b.policy.Store(policy{toOpen: toOpen, toClosed: toClosed})
+	return nil
+}
+
+func (b *Breaker) intervalNanos() int64 {
+	return atomic.LoadInt64(&b.interval)
+}
+
+func (b *Breaker) cooldownNanos() int64 {
+	return atomic.LoadInt64(&b.cooldown)
+}
+
+func (b *Breaker) policies() policy {
+	return b.policy.Load().(policy)
+}
+
+// withoutPolicies returns the configuration without the functions,
+// e.g. for a snapshot to be compared or marshalled.
+func (c Config) withoutPolicies() Config {
+	c.ToOpen, c.ToClosed = nil, nil
+	return c
+}
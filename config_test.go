@@ -0,0 +1,86 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_UpdateConfig(t *testing.T) {
+	never := func(uint32, uint32) bool { return false }
+	always := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, never, never, now(1520100000))
+	assert.NoError(t, err)
+
+	cfg := b.Config()
+	assert.Equal(t, time.Minute, cfg.Interval)
+	assert.Equal(t, time.Minute, cfg.Cooldown)
+	assert.Equal(t, uint32(1), cfg.AtLeastReqs)
+
+	// the counters are kept
+	b.Execute(func() error { return errors.New("failed") })
+	err = b.UpdateConfig(Config{Interval: 10 * time.Second, Cooldown: 5 * time.Second, AtLeastReqs: 2, ToOpen: always, ToClosed: always})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), b.Counts().Failures)
+	assert.Equal(t, int64(1520100060000000000), b.until)
+
+	cfg = b.Config()
+	assert.Equal(t, 10*time.Second, cfg.Interval)
+	assert.Equal(t, 5*time.Second, cfg.Cooldown)
+	assert.Equal(t, uint32(2), cfg.AtLeastReqs)
+	assert.Nil(t, b.Status().Config.ToOpen)
+
+	// the new policy and cooldown
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, int64(1520100005000000000), b.until)
+
+	// the new atLeastReqs and interval
+	b.now = now(1520100006)
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, StateClosed, b.State())
+	assert.Equal(t, int64(1520100016000000000), b.until)
+
+	// invalid
+	err = b.UpdateConfig(Config{Interval: time.Minute, Cooldown: time.Minute, AtLeastReqs: 1})
+	assert.EqualError(t, err, "circuit: toOpen must be defined")
+	assert.Equal(t, 10*time.Second, b.Config().Interval)
+}
+
+func TestBreaker_Setters(t *testing.T) {
+	never := func(uint32, uint32) bool { return false }
+	always := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, never, never, now(1520100000))
+	assert.NoError(t, err)
+	ch, stop := b.Watch()
+	defer stop()
+
+	assert.NoError(t, b.SetInterval(10*time.Second))
+	assert.NoError(t, b.SetCooldown(5*time.Second))
+	assert.NoError(t, b.SetAtLeastReqs(2))
+	assert.NoError(t, b.SetPolicy(always, always))
+
+	cfg := b.Config()
+	assert.Equal(t, 10*time.Second, cfg.Interval)
+	assert.Equal(t, 5*time.Second, cfg.Cooldown)
+	assert.Equal(t, uint32(2), cfg.AtLeastReqs)
+
+	// the subscribers are kept
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, int64(1520100005000000000), b.until)
+	assert.Equal(t, StateOpen, (<-ch).To)
+
+	assert.EqualError(t, b.SetInterval(0), "circuit: interval must be set")
+	assert.EqualError(t, b.SetCooldown(0), "circuit: cooldown must be set")
+	assert.EqualError(t, b.SetAtLeastReqs(0), "circuit: atLeastReqs must be set")
+	assert.EqualError(t, b.SetPolicy(nil, always), "circuit: toOpen must be defined")
+	assert.EqualError(t, b.SetPolicy(always, nil), "circuit: toClosed must be defined")
+	assert.Equal(t, 10*time.Second, b.Config().Interval)
+}
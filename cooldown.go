@@ -0,0 +1,37 @@
+package circuit
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithCooldownFunc makes the circuit breaker compute the cooldown period
+// every time it opens instead of using the fixed one, e.g. to back off exponentially
+// or to follow a Retry-After of the dependency.
+//
+// The function is given the number of times the circuit breaker has opened
+// since it was closed last time (1 for the first time) and the counters
+// of the period which is finished by the opening.
+// The fixed cooldown is used if it returns a non-positive duration.
+func WithCooldownFunc(f func(openCount int, lastCounts Counts) time.Duration) Option {
+	return func(b *Breaker) {
+		b.cooldownFunc = f
+	}
+}
+
+// trip moves the circuit breaker into the open state for the cooldown period.
+// It's never done while the state is forced with ForceClose or the circuit breaker is disabled.
+func (b *Breaker) trip(w uint64, now int64) bool {
+	if atomic.LoadInt32(&b.override) == int32(OverrideClosed) || atomic.LoadInt32(&b.disabled) == 1 {
+		return false
+	}
+
+	cooldown := b.cooldownNanos()
+	if b.cooldownFunc != nil {
+		openCount := int(atomic.LoadUint32(&b.opens)) + 1
+		if d := b.cooldownFunc(openCount, b.snapshot()); d > 0 {
+			cooldown = d.Nanoseconds()
+		}
+	}
+	return b.switchTo(open, w, now, now+cooldown)
+}
@@ -0,0 +1,57 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithCooldownFunc(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return failures == 0 }
+
+	var counts []int
+	var failures []uint32
+	cooldown := func(openCount int, last Counts) time.Duration {
+		counts = append(counts, openCount)
+		failures = append(failures, last.Failures)
+		if openCount > 2 {
+			return 0
+		}
+		return time.Duration(openCount) * 10 * time.Second
+	}
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithCooldownFunc(cooldown))
+	assert.NoError(t, err)
+
+	fail := func() error { return errors.New("failed") }
+
+	// opened for the first time
+	b.Execute(fail)
+	assert.Equal(t, open, b.loadState())
+	assert.Equal(t, int64(1520100010000000000), b.until)
+
+	// failed in the half-open state, opened for the second time
+	b.now = now(1520100011)
+	b.Execute(fail)
+	assert.ErrorIs(t, b.Execute(fail), ErrBreakerOpen)
+	assert.Equal(t, open, b.loadState())
+	assert.Equal(t, int64(1520100031000000000), b.until)
+
+	// the fixed cooldown is used when the func returns zero
+	b.now = now(1520100032)
+	b.Execute(fail)
+	assert.ErrorIs(t, b.Execute(fail), ErrBreakerOpen)
+	assert.Equal(t, int64(1520100152000000000), b.until)
+
+	// closed and opened again, the count starts over
+	b.now = now(1520100153)
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.EqualError(t, b.Execute(fail), "failed")
+	assert.Equal(t, open, b.loadState())
+	assert.Equal(t, int64(1520100163000000000), b.until)
+
+	assert.Equal(t, []int{1, 2, 3, 1}, counts)
+	assert.Equal(t, []uint32{1, 1, 1, 1}, failures)
+}
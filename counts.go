@@ -0,0 +1,89 @@
+package circuit
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Counts is a snapshot of the circuit breaker's counters
+// during the current interval (closed state), cooldown (open state)
+// or half-open period, the counters are reset on every transition.
+// The lifetime counters are never reset.
+//
+// Total, Failures and Successes are scaled down together
+// once any exceeds math.MaxUint32, keeping the failure rate, instead of wrapping around.
+type Counts struct {
+	State       State     // current state
+	Override    Override  // manual override of the state
+	Total       uint32    // # of requests in total
+	Failures    uint32    // # of requests returned an error
+	Successes   uint32    // # of requests succeeded
+	Rejections  uint32    // # of requests rejected with ErrBreakerOpen
+	SlowCalls   uint32    // # of requests slower than the slow call threshold
+	WindowStart time.Time // when the current period started
+
+	ConsecutiveFailures uint32 // # of requests failed in a row
+	FailureScore        uint32 // sum of the weights of the failed requests if weighted with WithFailureWeight
+
+	Latency Latency // quantiles of the latency if tracked with WithLatencyPercentile
+
+	FailuresByCategory map[string]uint32 // # of requests failed by the category if counted with WithFailureCategories
+
+	LifetimeTotal      uint64 // # of requests in total since the creation
+	LifetimeFailures   uint64 // # of requests returned an error since the creation
+	LifetimeRejections uint64 // # of requests rejected with ErrBreakerOpen since the creation
+	LifetimeBypasses   uint64 // # of requests run bypassing the circuit breaker since the creation, see WithBypass
+	Transitions        uint64 // # of transitions between the states since the creation
+}
+
+// Counts returns a snapshot of the circuit breaker's counters.
+//
+// The snapshot is taken without blocking the requests,
+// it's retried if the period changes while being read.
+// Requests still in flight are counted in Total only.
+func (b *Breaker) Counts() Counts {
+	b.expireHalfOpen()
+	for {
+		w := atomic.LoadUint64(&b.word)
+		c := b.snapshot()
+		if w&settling == 0 && atomic.LoadUint64(&b.word) == w {
+			return c
+		}
+	}
+}
+
+func (b *Breaker) snapshot() Counts {
+	c := b.counts()
+	if b.latencies != nil {
+		c.Latency = b.latencies.latency()
+	}
+	if b.categorize != nil {
+		c.FailuresByCategory = b.failuresByCategory()
+	}
+	return c
+}
+
+// counts returns the snapshot without the latency and the failures by category,
+// cheap enough to take on every request.
+func (b *Breaker) counts() Counts {
+	c := Counts{
+		State:       State(b.loadState()),
+		Override:    Override(atomic.LoadInt32(&b.override)),
+		Rejections:  atomic.LoadUint32(&b.rejections),
+		SlowCalls:   atomic.LoadUint32(&b.slowCalls),
+		WindowStart: time.Unix(0, atomic.LoadInt64(&b.start)),
+
+		ConsecutiveFailures: atomic.LoadUint32(&b.consecutive),
+
+		LifetimeTotal:      b.loadLifetimeTotal(),
+		LifetimeFailures:   atomic.LoadUint64(&b.lifetimeFailures),
+		LifetimeRejections: atomic.LoadUint64(&b.lifetimeRejections),
+		LifetimeBypasses:   atomic.LoadUint64(&b.lifetimeBypasses),
+		Transitions:        atomic.LoadUint64(&b.transitions),
+	}
+	c.Total, c.Failures, c.Successes = b.counters()
+	if b.weight != nil {
+		_, c.FailureScore = b.policyCounts()
+	}
+	return c
+}
@@ -0,0 +1,48 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_Counts(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+	assert.Equal(t, Counts{State: StateClosed, WindowStart: time.Unix(1520100000, 0)}, b.Counts())
+
+	b.Execute(func() error { return nil })
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, Counts{
+		State:       StateClosed,
+		Total:       2,
+		Failures:    1,
+		Successes:   1,
+		WindowStart: time.Unix(1520100000, 0),
+
+		ConsecutiveFailures: 1,
+
+		LifetimeTotal:    2,
+		LifetimeFailures: 1,
+	}, b.Counts())
+
+	// open the breaker, the counters are reset
+	b.now = now(1520100010)
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return nil })
+	b.Execute(func() error { return nil })
+	assert.Equal(t, Counts{
+		State:       StateOpen,
+		Rejections:  2,
+		WindowStart: time.Unix(1520100010, 0),
+
+		LifetimeTotal:      3,
+		LifetimeFailures:   2,
+		LifetimeRejections: 2,
+		Transitions:        1,
+	}, b.Counts())
+}
@@ -0,0 +1,142 @@
+package circuit
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is what a decision on a transition is made by:
+// the counters of the current interval (closed state) or half-open period.
+type Snapshot struct {
+	Total               uint32        // # of requests in total, of the window if kept
+	Failures            uint32        // # of requests failed, weighted with WithFailureWeight
+	Successes           uint32        // # of requests succeeded
+	SlowCalls           uint32        // # of requests slower than the slow call threshold
+	ConsecutiveFailures uint32        // # of requests failed in a row
+	Latency             Latency       // quantiles of the latency if tracked with WithLatencyPercentile
+	Age                 time.Duration // time since the period started
+}
+
+// Decision makes a decision if a transition to the other state needs to be done
+// by a snapshot of the current period, like ToState does by the total and failures only.
+type Decision func(s Snapshot) bool
+
+// Decide adapts the function to a Decision, it's given the total and failures of the snapshot.
+func (f ToState) Decide(s Snapshot) bool {
+	return f(s.Total, s.Failures)
+}
+
+// TripPolicy decides on opening the circuit breaker in the closed state
+// by a snapshot of the interval, it's called on every failure.
+//
+// A policy may keep its own history, e.g. a moving average or streaks,
+// guarding it from the concurrent calls. If it implements Observer too,
+// it receives the events of the circuit breaker, e.g. to reset the history on the transitions.
+type TripPolicy interface {
+	ShouldTrip(s Snapshot) bool
+}
+
+// RecoverPolicy decides on closing the circuit breaker in the half-open state
+// by a snapshot of the period, it's called once the number of requests reached atLeastReqs.
+//
+// As TripPolicy, it may keep its own history and receive the events.
+type RecoverPolicy interface {
+	ShouldRecover(s Snapshot) bool
+}
+
+// ShouldTrip calls d(s).
+func (d Decision) ShouldTrip(s Snapshot) bool {
+	return d(s)
+}
+
+// ShouldRecover calls d(s).
+func (d Decision) ShouldRecover(s Snapshot) bool {
+	return d(s)
+}
+
+// ShouldTrip adapts the function to a TripPolicy, see Decide.
+func (f ToState) ShouldTrip(s Snapshot) bool {
+	return f.Decide(s)
+}
+
+// ShouldRecover adapts the function to a RecoverPolicy, see Decide.
+func (f ToState) ShouldRecover(s Snapshot) bool {
+	return f.Decide(s)
+}
+
+// WithTripPolicy makes the circuit breaker decide on opening by a given policy instead of toOpen.
+// The policy keeping its own history is shared by the circuit breakers derived with With,
+// so such a one should be given to With again.
+func WithTripPolicy(p TripPolicy) Option {
+	return func(b *Breaker) {
+		b.tripPolicy = p
+		if o, ok := p.(Observer); ok {
+			b.observers = append(b.observers, o)
+		}
+	}
+}
+
+// WithRecoverPolicy makes the circuit breaker decide on closing by a given policy instead of toClosed,
+// see WithTripPolicy.
+func WithRecoverPolicy(p RecoverPolicy) Option {
+	return func(b *Breaker) {
+		b.recoverPolicy = p
+		if o, ok := p.(Observer); ok {
+			b.observers = append(b.observers, o)
+		}
+	}
+}
+
+// WithOpenDecision makes the circuit breaker decide on opening in the closed state
+// by a snapshot of the interval instead of toOpen, e.g. by the latency and the failures together:
+//
+//	circuit.WithOpenDecision(func(s circuit.Snapshot) bool {
+//		return s.Latency.P99 > time.Second || circuit.RateThreshold(0.1, 20)(s.Total, s.Failures)
+//	})
+func WithOpenDecision(d Decision) Option {
+	return WithTripPolicy(d)
+}
+
+// WithCloseDecision makes the circuit breaker decide on closing in the half-open state
+// by a snapshot of the period instead of toClosed.
+func WithCloseDecision(d Decision) Option {
+	return WithRecoverPolicy(d)
+}
+
+// shouldOpen decides on opening by the policy counts, see policyCounts.
+func (b *Breaker) shouldOpen(total uint32, failures uint32) bool {
+	if b.tripPolicy != nil {
+		return b.tripPolicy.ShouldTrip(b.decisionSnapshot(total, failures))
+	}
+	return b.policies().toOpen(total, failures)
+}
+
+// shouldClose decides on closing by the policy counts, see policyCounts.
+func (b *Breaker) shouldClose(total uint32, failures uint32) bool {
+	if b.recoverPolicy != nil {
+		return b.recoverPolicy.ShouldRecover(b.decisionSnapshot(total, failures))
+	}
+	return b.policies().toClosed(total, failures)
+}
+
+// decisionSnapshot returns a snapshot of the current period with the policy counts.
+func (b *Breaker) decisionSnapshot(total uint32, failures uint32) Snapshot {
+	s := Snapshot{
+		Total:               total,
+		Failures:            failures,
+		SlowCalls:           atomic.LoadUint32(&b.slowCalls),
+		ConsecutiveFailures: atomic.LoadUint32(&b.consecutive),
+		Age:                 time.Duration(b.nanotime() - atomic.LoadInt64(&b.start)),
+	}
+	if b.window != nil {
+		// the window keeps the failures and successes only
+		_, failed := b.window.counts(b.nanotime())
+		s.Successes = total - failed
+	} else {
+		_, _, s.Successes = b.counters()
+	}
+	if b.latencies != nil {
+		s.Latency = b.latencies.latency()
+	}
+	return s
+}
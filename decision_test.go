@@ -0,0 +1,104 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToState_Decide(t *testing.T) {
+	toOpen := RateThreshold(0.5, 2)
+	assert.False(t, toOpen.Decide(Snapshot{Total: 1, Failures: 1}))
+	assert.True(t, toOpen.Decide(Snapshot{Total: 2, Failures: 1, Successes: 1}))
+}
+
+func TestBreaker_WithOpenDecision(t *testing.T) {
+	var got Snapshot
+	decide := func(s Snapshot) bool {
+		got = s
+		return s.ConsecutiveFailures >= 2 && s.Age >= 10*time.Second
+	}
+	toOpen := func(total uint32, failures uint32) bool { panic("replaced by the decision") }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithOpenDecision(decide))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return nil })
+	b.Execute(func() error { return errors.New("failed") })
+	b.now = now(1520100010)
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, Snapshot{Total: 3, Failures: 2, Successes: 1, ConsecutiveFailures: 2, Age: 10 * time.Second}, got)
+	assert.Equal(t, open, b.loadState())
+}
+
+func TestBreaker_WithCloseDecision(t *testing.T) {
+	var got Snapshot
+	decide := func(s Snapshot) bool {
+		got = s
+		return s.Successes >= 2
+	}
+	toOpen := func(total uint32, failures uint32) bool { return true }
+	toClosed := func(total uint32, failures uint32) bool { panic("replaced by the decision") }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 2, toOpen, toClosed, now(1520100000), WithCloseDecision(decide))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState())
+
+	b.now = now(1520100121)
+	b.Execute(func() error { return nil })
+	b.Execute(func() error { return nil })
+	b.now = now(1520100125)
+	b.Execute(func() error { return nil })
+	assert.Equal(t, Snapshot{Total: 2, Successes: 2, Age: 4 * time.Second}, got)
+	assert.Equal(t, closed, b.loadState())
+}
+
+// failureStreak trips after n failures in a row across the intervals,
+// forgetting the streak on every transition.
+type failureStreak struct {
+	n, streak int
+	resets    int
+}
+
+func (p *failureStreak) ShouldTrip(s Snapshot) bool {
+	if s.ConsecutiveFailures == 1 && s.Successes > 0 {
+		p.streak = 0
+	}
+	p.streak++
+	return p.streak >= p.n
+}
+
+func (p *failureStreak) Observe(e Event) {
+	if e.Type == EventStateChanged {
+		p.streak = 0
+		p.resets++
+	}
+}
+
+func TestBreaker_WithTripPolicy(t *testing.T) {
+	to := func(total uint32, failures uint32) bool { panic("replaced by the policy") }
+	p := &failureStreak{n: 3}
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, to, to, now(1520100000),
+		WithTripPolicy(p), WithRecoverPolicy(RateThreshold(0.5, 1)))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, 2, p.streak)
+
+	// the streak survives the interval
+	b.now = now(1520100061)
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState())
+	assert.Equal(t, 0, p.streak)
+	assert.Equal(t, 1, p.resets)
+
+	// ToState adapted to the recover policy, 1 of 1 failed
+	b.now = now(1520100182)
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return nil })
+	assert.Equal(t, closed, b.loadState())
+}
@@ -0,0 +1,35 @@
+package circuit
+
+import "context"
+
+// Do runs a given request through the circuit breaker like Execute does
+// and returns the request's result.
+//
+// The outcome is decided by the result classifier when it's set,
+// otherwise by the error as in Execute.
+//
+// Returns the zero value of T and ErrBreakerOpen when the circuit breaker
+// doesn't accept the request, otherwise the result and the error from the req function.
+func Do[T any](b *Breaker, req func() (T, error)) (T, error) {
+	tok, err := b.Allow()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	var v T
+	if err = b.inject(context.Background()); err == nil {
+		err = guard(func() error {
+			var err error
+			if b.executionTimeout > 0 {
+				v, err = runTimed(b.after, b.executionTimeout, req)
+			} else {
+				v, err = req()
+			}
+			return err
+		})
+	}
+	tok.Record(outcome(b, v, err))
+	b.repanic(err)
+	return v, b.wrap(err)
+}
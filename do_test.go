@@ -0,0 +1,83 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	v, err := Do(b, func() (string, error) { return "ok", nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", v)
+	assert.Equal(t, uint64(1), b.total)
+
+	// the result is returned along with the request's error
+	v, err = Do(b, func() (string, error) { return "partial", errors.New("failed") })
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, "partial", v)
+	assert.Equal(t, open, b.loadState())
+
+	// the zero value when the breaker is open
+	n, err := Do(b, func() (int, error) { return 42, nil })
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+	assert.Equal(t, 0, n)
+}
+
+func TestDo_WithResultClassifier(t *testing.T) {
+	type response struct{ status int }
+	classify := func(v any, err error) Outcome {
+		if err != nil {
+			return OutcomeIgnored
+		}
+		if v.(response).status >= 500 {
+			return OutcomeFailure
+		}
+		return OutcomeSuccess
+	}
+
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithResultClassifier(classify))
+	assert.NoError(t, err)
+
+	resp, err := Do(b, func() (response, error) { return response{200}, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, response{200}, resp)
+	assert.Equal(t, uint64(1), b.total)
+	assert.Equal(t, uint64(1), b.successes)
+
+	// ignored, not counted at all
+	_, err = Do(b, func() (response, error) { return response{}, errors.New("canceled") })
+	assert.Error(t, err)
+	assert.Equal(t, uint64(1), b.total)
+	assert.Equal(t, uint64(0), b.failures)
+
+	// a failure despite of the nil error
+	resp, err = Do(b, func() (response, error) { return response{500}, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, response{500}, resp)
+	assert.Equal(t, open, b.loadState())
+}
+
+func BenchmarkDo(b *testing.B) {
+	toOpen := func(total uint32, failures uint32) bool { return false }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	cb, err := NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(b, err)
+	req := func() (int, error) { return 1000, nil }
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			Do(cb, req)
+		}
+	})
+}
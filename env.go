@@ -0,0 +1,93 @@
+package circuit
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// FromEnv returns a new circuit breaker configured by the environment variables
+// with a given prefix, e.g. for "CIRCUIT_PAYMENTS":
+//
+//	CIRCUIT_PAYMENTS_INTERVAL=1m          the interval, required
+//	CIRCUIT_PAYMENTS_COOLDOWN=10s         the cooldown, required
+//	CIRCUIT_PAYMENTS_FAILURE_RATE=0.5     the failure rate opening the circuit breaker, required
+//	CIRCUIT_PAYMENTS_MIN_REQUESTS=10      the minimum # of requests to open it, 1 by default
+//	CIRCUIT_PAYMENTS_AT_LEAST_REQS=5      atLeastReqs, 1 by default
+//
+// The circuit breaker opens with RateThreshold(failureRate, minRequests)
+// and closes once the failure rate of the half-open requests is below the failure rate.
+// The durations are parsed with time.ParseDuration.
+func FromEnv(prefix string, opts ...Option) (*Breaker, error) {
+	env := envReader{prefix: prefix}
+
+	interval := env.duration("INTERVAL")
+	cooldown := env.duration("COOLDOWN")
+	rate := env.float("FAILURE_RATE")
+	minRequests := env.uint32("MIN_REQUESTS", 1)
+	atLeastReqs := env.uint32("AT_LEAST_REQS", 1)
+	if env.err != nil {
+		return nil, env.err
+	}
+	if rate <= 0 || rate > 1 {
+		return nil, fmt.Errorf("circuit: %s_FAILURE_RATE must be in (0, 1], got %v", prefix, rate)
+	}
+
+	toOpen := RateThreshold(rate, minRequests)
+	failing := RateThreshold(rate, 1)
+	toClosed := func(total, failures uint32) bool { return !failing(total, failures) }
+	return NewBreaker(interval, cooldown, atLeastReqs, toOpen, toClosed, opts...)
+}
+
+// envReader reads the environment variables with a prefix
+// keeping the first error.
+type envReader struct {
+	prefix string
+	err    error
+}
+
+func (r *envReader) lookup(name string, required bool) (string, string, bool) {
+	key := r.prefix + "_" + name
+	v, ok := os.LookupEnv(key)
+	if !ok && required && r.err == nil {
+		r.err = fmt.Errorf("circuit: %s must be set", key)
+	}
+	return key, v, ok && r.err == nil
+}
+
+func (r *envReader) duration(name string) time.Duration {
+	key, v, ok := r.lookup(name, true)
+	if !ok {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		r.err = fmt.Errorf("circuit: %s: invalid duration %q, e.g. \"1m30s\"", key, v)
+	}
+	return d
+}
+
+func (r *envReader) float(name string) float64 {
+	key, v, ok := r.lookup(name, true)
+	if !ok {
+		return 0
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		r.err = fmt.Errorf("circuit: %s: invalid number %q", key, v)
+	}
+	return f
+}
+
+func (r *envReader) uint32(name string, def uint32) uint32 {
+	key, v, ok := r.lookup(name, false)
+	if !ok {
+		return def
+	}
+	n, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		r.err = fmt.Errorf("circuit: %s: invalid number %q", key, v)
+	}
+	return uint32(n)
+}
@@ -0,0 +1,60 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("CIRCUIT_PAYMENTS_INTERVAL", "1m")
+	t.Setenv("CIRCUIT_PAYMENTS_COOLDOWN", "10s")
+	t.Setenv("CIRCUIT_PAYMENTS_FAILURE_RATE", "0.5")
+	t.Setenv("CIRCUIT_PAYMENTS_MIN_REQUESTS", "2")
+
+	b, err := FromEnv("CIRCUIT_PAYMENTS", WithName("payments"))
+	assert.NoError(t, err)
+	assert.Equal(t, "payments", b.Name())
+	cfg := b.Config()
+	assert.Equal(t, time.Minute, cfg.Interval)
+	assert.Equal(t, 10*time.Second, cfg.Cooldown)
+	assert.Equal(t, uint32(1), cfg.AtLeastReqs)
+
+	// at least 2 requests to open
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateClosed, b.State())
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+
+	assert.False(t, cfg.ToClosed(2, 1))
+	assert.True(t, cfg.ToClosed(3, 1))
+}
+
+func TestFromEnv_Errors(t *testing.T) {
+	_, err := FromEnv("CIRCUIT_ORDERS")
+	assert.EqualError(t, err, "circuit: CIRCUIT_ORDERS_INTERVAL must be set")
+
+	t.Setenv("CIRCUIT_ORDERS_INTERVAL", "1 minute")
+	_, err = FromEnv("CIRCUIT_ORDERS")
+	assert.EqualError(t, err, `circuit: CIRCUIT_ORDERS_INTERVAL: invalid duration "1 minute", e.g. "1m30s"`)
+
+	t.Setenv("CIRCUIT_ORDERS_INTERVAL", "1m")
+	t.Setenv("CIRCUIT_ORDERS_COOLDOWN", "10s")
+	_, err = FromEnv("CIRCUIT_ORDERS")
+	assert.EqualError(t, err, "circuit: CIRCUIT_ORDERS_FAILURE_RATE must be set")
+
+	t.Setenv("CIRCUIT_ORDERS_FAILURE_RATE", "50%")
+	_, err = FromEnv("CIRCUIT_ORDERS")
+	assert.EqualError(t, err, `circuit: CIRCUIT_ORDERS_FAILURE_RATE: invalid number "50%"`)
+
+	t.Setenv("CIRCUIT_ORDERS_FAILURE_RATE", "50")
+	_, err = FromEnv("CIRCUIT_ORDERS")
+	assert.EqualError(t, err, "circuit: CIRCUIT_ORDERS_FAILURE_RATE must be in (0, 1], got 50")
+
+	t.Setenv("CIRCUIT_ORDERS_FAILURE_RATE", "0.5")
+	t.Setenv("CIRCUIT_ORDERS_AT_LEAST_REQS", "-1")
+	_, err = FromEnv("CIRCUIT_ORDERS")
+	assert.EqualError(t, err, `circuit: CIRCUIT_ORDERS_AT_LEAST_REQS: invalid number "-1"`)
+}
@@ -0,0 +1,172 @@
+package circuit
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Group is a set of circuit breakers created lazily per key
+// (host, shard, tenant, etc.) from the shared configuration.
+type Group struct {
+	interval    time.Duration
+	cooldown    time.Duration
+	atLeastReqs uint32
+	toOpen      ToState
+	toClosed    ToState
+	opts        []Option
+
+	mu       sync.RWMutex
+	breakers map[string]*member
+	keyFunc  KeyFunc                      // derives the keys of the requests, KeyByOperation if nil
+	maxSize  int                          // max # of the circuit breakers if set, see SetMaxSize
+	onEvict  func(key string, b *Breaker) // called for every circuit breaker evicted if set
+	tick     uint64                       // # of the uses of the circuit breakers, orders them by recency
+	idleTTL  int64                        // duration of no use to expire a circuit breaker after if set, see SetIdleTTL
+	swept    int64                        // timestamp of the last sweep of the idle circuit breakers
+	global   *Breaker                     // covers the whole dependency if set, see SetGlobal
+	minOpen  int                          // # of the open circuit breakers to open the global one
+	openRate float64                      // rate of the open circuit breakers to open the global one
+	outliers *OutlierDetection            // ejects the outliers if set, see SetOutlierDetection
+	detected int64                        // timestamp of the last detection of the outliers
+	picks    uint32                       // # of the picks of PickHealthy, rotates them
+
+	now func() time.Time // time.Now
+}
+
+// NewGroup returns a new group creating a circuit breaker per key
+// with the given configuration, see NewBreaker for the arguments.
+// Every circuit breaker is named by its key.
+func NewGroup(interval time.Duration, cooldown time.Duration, atLeastReqs uint32, toOpen ToState, toClosed ToState, opts ...Option) (*Group, error) {
+	if err := validate(interval, cooldown, atLeastReqs, toOpen, toClosed); err != nil {
+		return nil, err
+	}
+
+	g := &Group{
+		interval:    interval,
+		cooldown:    cooldown,
+		atLeastReqs: atLeastReqs,
+		toOpen:      toOpen,
+		toClosed:    toClosed,
+		opts:        opts,
+		breakers:    make(map[string]*member),
+		now:         time.Now,
+	}
+	return g, nil
+}
+
+// Get returns the circuit breaker for a given key,
+// creating it on the first use.
+func (g *Group) Get(key string) *Breaker {
+	if atomic.LoadInt64(&g.idleTTL) > 0 {
+		g.sweepIdle(false)
+	}
+	if g.detectDue() {
+		g.DetectOutliers()
+	}
+
+	g.mu.RLock()
+	m, ok := g.breakers[key]
+	g.mu.RUnlock()
+	if ok {
+		g.touch(m)
+		return m.b
+	}
+
+	g.mu.Lock()
+	if m, ok := g.breakers[key]; ok {
+		g.mu.Unlock()
+		g.touch(m)
+		return m.b
+	}
+
+	opts := append([]Option{WithName(key)}, g.opts...)
+	// the configuration is validated in NewGroup
+	b, _ := withTimeNow(g.interval, g.cooldown, g.atLeastReqs, g.toOpen, g.toClosed, g.now, opts...)
+	b.onOpen = g.checkGlobal
+	m = &member{b: b}
+	g.touch(m)
+	g.breakers[key] = m
+	evicted, onEvict := g.evict(m), g.onEvict
+	g.mu.Unlock()
+
+	notifyEvicted(evicted, onEvict)
+	return b
+}
+
+// Execute runs a given request through the circuit breaker for the key,
+// see Breaker.Execute.
+func (g *Group) Execute(key string, req func() error) error {
+	b := g.Get(key)
+	return g.guardGlobal(context.Background(), func(context.Context) error { return b.Execute(req) })
+}
+
+// KeyFunc derives the key of the circuit breaker of a group for a request
+// from its context and the request itself, e.g. *http.Request in circuithttp
+// or the full method name in circuitgrpc, nil for ExecuteContext.
+type KeyFunc func(ctx context.Context, req any) string
+
+// KeyByOperation is a KeyFunc returning the operation of the metadata carried by ctx, see WithMetadata.
+func KeyByOperation(ctx context.Context, req any) string {
+	return MetadataFrom(ctx).Operation
+}
+
+// KeyByTenant is a KeyFunc returning the tenant of the metadata carried by ctx, see WithMetadata.
+func KeyByTenant(ctx context.Context, req any) string {
+	return MetadataFrom(ctx).Tenant
+}
+
+// SetKeyFunc sets the function deriving the keys of the requests
+// for For and ExecuteContext, KeyByOperation by default.
+func (g *Group) SetKeyFunc(f KeyFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.keyFunc = f
+}
+
+// For returns the circuit breaker for the key of a request derived by the key function,
+// creating it on the first use.
+func (g *Group) For(ctx context.Context, req any) *Breaker {
+	g.mu.RLock()
+	f := g.keyFunc
+	g.mu.RUnlock()
+	if f == nil {
+		f = KeyByOperation
+	}
+	return g.Get(f(ctx, req))
+}
+
+// ExecuteContext runs a given request through the circuit breaker for the key
+// derived from ctx, see SetKeyFunc and Breaker.ExecuteContext.
+func (g *Group) ExecuteContext(ctx context.Context, req func(context.Context) error) error {
+	b := g.For(ctx, nil)
+	return g.guardGlobal(ctx, func(ctx context.Context) error { return b.ExecuteContext(ctx, req) })
+}
+
+// Keys returns the sorted keys of the created circuit breakers.
+func (g *Group) Keys() []string {
+	g.mu.RLock()
+	keys := make([]string, 0, len(g.breakers))
+	for key := range g.breakers {
+		keys = append(keys, key)
+	}
+	g.mu.RUnlock()
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Each calls f for every created circuit breaker in the order of keys.
+// It's safe to use the group within f.
+func (g *Group) Each(f func(key string, b *Breaker)) {
+	for _, key := range g.Keys() {
+		g.mu.RLock()
+		m, ok := g.breakers[key]
+		g.mu.RUnlock()
+		if ok {
+			f(key, m.b)
+		}
+	}
+}
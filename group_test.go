@@ -0,0 +1,315 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGroup(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	_, err := NewGroup(time.Minute, 0, 1, to, to)
+	assert.EqualError(t, err, "circuit: cooldown must be set")
+
+	g, err := NewGroup(time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+	assert.Empty(t, g.Keys())
+}
+
+func TestGroup_Execute(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	g, err := NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	err = g.Execute("a.example.com", func() error { return errors.New("failed") })
+	assert.Error(t, err)
+	err = g.Execute("b.example.com", func() error { return nil })
+	assert.NoError(t, err)
+
+	// only the breaker of the failed host is open
+	err = g.Execute("a.example.com", func() error { return nil })
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+	err = g.Execute("b.example.com", func() error { return nil })
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, g.Keys())
+	assert.Equal(t, "a.example.com", g.Get("a.example.com").Name())
+	assert.Equal(t, StateOpen, g.Get("a.example.com").State())
+
+	var keys []string
+	g.Each(func(key string, b *Breaker) {
+		keys = append(keys, key)
+	})
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, keys)
+}
+
+func TestGroup_GetInParallel(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	g, err := NewGroup(time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+
+	breakers := make([]*Breaker, 20)
+	var wg sync.WaitGroup
+	wg.Add(20)
+	for i := 0; i < 20; i++ {
+		go func(i int) {
+			breakers[i] = g.Get("key")
+			wg.Done()
+		}(i)
+	}
+
+	wg.Wait()
+	for _, b := range breakers {
+		assert.True(t, b == breakers[0])
+	}
+}
+
+func TestGroup_ExecuteContext(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	g, err := NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+
+	charge := WithMetadata(context.Background(), Metadata{Operation: "Charge", Tenant: "acme"})
+	refund := WithMetadata(context.Background(), Metadata{Operation: "Refund", Tenant: "acme"})
+	g.ExecuteContext(charge, func(context.Context) error { return errors.New("failed") })
+	assert.NoError(t, g.ExecuteContext(refund, func(context.Context) error { return nil }))
+	assert.Equal(t, []string{"Charge", "Refund"}, g.Keys(), "by the operation by default")
+	assert.Equal(t, StateOpen, g.For(charge, nil).State())
+
+	g.SetKeyFunc(KeyByTenant)
+	assert.NoError(t, g.ExecuteContext(charge, func(context.Context) error { return nil }))
+	assert.Equal(t, []string{"Charge", "Refund", "acme"}, g.Keys())
+
+	g.SetKeyFunc(func(ctx context.Context, req any) string { return req.(string) })
+	assert.Equal(t, "custom", g.For(context.Background(), "custom").Name())
+}
+
+func TestGroup_SetMaxSize(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	g, err := NewGroup(time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+
+	evicted := make(map[string]*Breaker)
+	g.SetMaxSize(2, func(key string, b *Breaker) { evicted[key] = b })
+
+	a := g.Get("a")
+	g.Get("b")
+	g.Get("a")
+	g.Get("c")
+	assert.Equal(t, []string{"a", "c"}, g.Keys(), "b is the least recently used")
+	assert.Len(t, evicted, 1)
+	assert.Equal(t, "b", evicted["b"].Name())
+
+	g.SetMaxSize(1, func(key string, b *Breaker) { evicted[key] = b })
+	assert.Equal(t, []string{"c"}, g.Keys())
+	assert.True(t, evicted["a"] == a)
+
+	g.SetMaxSize(0, nil)
+	g.Get("d")
+	assert.Equal(t, []string{"c", "d"}, g.Keys())
+	assert.False(t, g.Get("a") == a, "a new circuit breaker for an evicted key")
+}
+
+func TestGroup_SetIdleTTL(t *testing.T) {
+	var expired []Event
+	observer := ObserverFunc(func(e Event) {
+		if e.Type == EventExpired {
+			expired = append(expired, e)
+		}
+	})
+	to := func(uint32, uint32) bool { return false }
+	g, err := NewGroup(time.Minute, time.Minute, 1, to, to, WithObserver(observer))
+	assert.NoError(t, err)
+	g.now = now(1520100000)
+
+	g.Execute("a", func() error { return nil })
+	g.SetIdleTTL(time.Minute)
+	g.now = now(1520100030)
+	g.Execute("b", func() error { return errors.New("failed") })
+	g.now = now(1520100059)
+	g.Get("a")
+	assert.Equal(t, []string{"a", "b"}, g.Keys())
+
+	g.now = now(1520100089)
+	g.Get("c")
+	assert.Equal(t, []string{"a", "b", "c"}, g.Keys(), "swept at most once per ttl")
+
+	g.now = now(1520100090)
+	g.ExpireIdle()
+	assert.Equal(t, []string{"a", "c"}, g.Keys())
+	assert.Len(t, expired, 1)
+	assert.Equal(t, "b", expired[0].Name)
+	assert.Equal(t, uint32(1), expired[0].Counts.Failures, "the final counts")
+
+	g.now = now(1520100200)
+	g.Get("d")
+	assert.Equal(t, []string{"d"}, g.Keys())
+	assert.Len(t, expired, 3)
+
+	g.SetIdleTTL(0)
+	g.now = now(1520100900)
+	g.Get("e")
+	g.ExpireIdle()
+	assert.Equal(t, []string{"d", "e"}, g.Keys())
+}
+
+func TestGroup_SetGlobal(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	g, err := NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+	global, err := NewBreaker(time.Minute, time.Minute, 1, RateThreshold(0.9, 100), toClosed)
+	assert.NoError(t, err)
+	g.SetGlobal(global, 2, 0.5)
+
+	failed := errors.New("failed")
+	for _, key := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, g.Execute(key, func() error { return nil }))
+	}
+	assert.Equal(t, failed, g.Execute("a", func() error { return failed }))
+	assert.ErrorIs(t, g.Execute("a", func() error { return nil }), ErrBreakerOpen)
+	assert.Equal(t, StateClosed, global.State(), "1 of 4 open")
+	assert.Equal(t, uint32(0), global.Counts().Rejections)
+
+	assert.Equal(t, failed, g.Execute("b", func() error { return failed }))
+	assert.Equal(t, StateOpen, global.State(), "2 of 4 open")
+
+	var oe *OpenError
+	err = g.Execute("c", func() error { return nil })
+	assert.ErrorAs(t, err, &oe)
+	assert.Equal(t, "", oe.Name, "rejected by the global one")
+	assert.Equal(t, uint64(6), global.Counts().LifetimeTotal, "the rejections by the keys aren't counted")
+
+	g.SetGlobal(nil, 0, 0)
+	assert.NoError(t, g.Execute("c", func() error { return nil }))
+}
+
+func TestGroup_SetGlobal_Panic(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	g, err := NewGroup(time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+	global, err := NewBreaker(time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+	g.SetGlobal(global, 1, 0)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		g.Execute("a", func() error { panic("boom") })
+	})
+	assert.Equal(t, uint32(1), global.Counts().Failures)
+}
+
+func TestGroup_SetGlobal_ExecutionTimeout(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	g, err := NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+	global, err := NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed, WithExecutionTimeout(time.Second))
+	assert.NoError(t, err)
+	g.SetGlobal(global, 2, 0.5)
+
+	failed := errors.New("failed")
+	assert.Equal(t, failed, g.Execute("a", func() error { return failed }))
+	assert.Equal(t, StateOpen, global.State())
+	global.Reset()
+
+	assert.ErrorIs(t, g.Execute("a", func() error { return nil }), ErrBreakerOpen)
+	assert.Equal(t, StateClosed, global.State(), "the rejection by the key isn't counted")
+}
+
+func TestGroup_SetGlobal_ExecuteContext(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	g, err := NewGroup(time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+	global, err := NewBreaker(time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+	g.SetGlobal(global, 1, 0)
+	global.Trip()
+
+	assert.ErrorIs(t, g.ExecuteContext(context.Background(), func(context.Context) error { return nil }), ErrBreakerOpen)
+	assert.NoError(t, g.ExecuteContext(WithBypass(context.Background()), func(context.Context) error { return nil }))
+	assert.Equal(t, uint64(1), global.Counts().LifetimeBypasses)
+}
+
+func TestGroup_SetOutlierDetection(t *testing.T) {
+	toOpen := RateThreshold(0.9, 10)
+	toClosed := func(uint32, uint32) bool { return true }
+	g, err := NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+	g.now = now(1520100000)
+	g.SetOutlierDetection(&OutlierDetection{MinRequests: 10, MinMembers: 6})
+
+	run := func(key string, failures int) {
+		for i := 0; i < 10; i++ {
+			g.Execute(key, func() error {
+				if i < failures {
+					return errors.New("failed")
+				}
+				return nil
+			})
+		}
+	}
+	for _, key := range []string{"a", "b", "c", "d"} {
+		run(key, 1)
+	}
+	run("e", 5)
+	assert.Empty(t, g.DetectOutliers(), "too few members")
+
+	run("f", 1)
+	assert.Equal(t, StateClosed, g.Get("e").State(), "once per interval")
+
+	g.now = now(1520100010)
+	g.Get("a")
+	assert.Equal(t, StateOpen, g.Get("e").State(), "50% failed against 17% on average")
+	for _, key := range []string{"a", "b", "c", "d", "f"} {
+		assert.Equal(t, StateClosed, g.Get(key).State())
+	}
+
+	g.SetOutlierDetection(nil)
+	assert.Empty(t, g.DetectOutliers())
+}
+
+func TestGroup_PickHealthy(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	g, err := NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+	g.now = now(1520100000)
+
+	_, err = g.PickHealthy(nil)
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+
+	hosts := []string{"a", "b", "c"}
+	var picks []string
+	for i := 0; i < 4; i++ {
+		host, err := g.PickHealthy(hosts)
+		assert.NoError(t, err)
+		picks = append(picks, host)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "a"}, picks, "round-robin")
+
+	g.Execute("b", func() error { return errors.New("failed") })
+	picks = nil
+	for i := 0; i < 4; i++ {
+		host, err := g.PickHealthy(hosts)
+		assert.NoError(t, err)
+		picks = append(picks, host)
+	}
+	assert.Equal(t, []string{"c", "c", "a", "c"}, picks, "b is open")
+
+	g.Execute("a", func() error { return errors.New("failed") })
+	g.Execute("c", func() error { return errors.New("failed") })
+	_, err = g.PickHealthy(hosts)
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+
+	g.Get("b").now = now(1520100061)
+	assert.Equal(t, StateOpen, g.Get("b").State())
+	host, err := g.PickHealthy(hosts)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", host, "the cooldown is over when none is closed")
+}
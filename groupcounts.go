@@ -0,0 +1,44 @@
+package circuit
+
+// GroupCounts is the roll-up of the counts of the circuit breakers of a group,
+// the counters of the current periods and the lifetime ones are summed up.
+type GroupCounts struct {
+	Breakers int // # of the circuit breakers
+	Closed   int // # of the circuit breakers in the closed state
+	HalfOpen int // # of the circuit breakers in the half-open state
+	Open     int // # of the circuit breakers in the open state
+
+	Total      uint64 // # of requests in total
+	Failures   uint64 // # of requests returned an error
+	Rejections uint64 // # of requests rejected with ErrBreakerOpen
+
+	LifetimeTotal      uint64 // # of requests in total since the creation
+	LifetimeFailures   uint64 // # of requests returned an error since the creation
+	LifetimeRejections uint64 // # of requests rejected with ErrBreakerOpen since the creation
+}
+
+// Counts returns the roll-up of the counts of the circuit breakers of the group,
+// e.g. for a dashboard of a dependency keyed by many hosts or tenants.
+func (g *Group) Counts() GroupCounts {
+	var gc GroupCounts
+	g.Each(func(_ string, b *Breaker) {
+		c := b.Counts()
+		gc.Breakers++
+		switch c.State {
+		case StateClosed:
+			gc.Closed++
+		case StateHalfOpen:
+			gc.HalfOpen++
+		case StateOpen:
+			gc.Open++
+		}
+
+		gc.Total += uint64(c.Total)
+		gc.Failures += uint64(c.Failures)
+		gc.Rejections += uint64(c.Rejections)
+		gc.LifetimeTotal += c.LifetimeTotal
+		gc.LifetimeFailures += c.LifetimeFailures
+		gc.LifetimeRejections += c.LifetimeRejections
+	})
+	return gc
+}
@@ -0,0 +1,29 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_Counts(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	g, err := NewGroup(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(t, err)
+	assert.Equal(t, GroupCounts{}, g.Counts())
+
+	g.Execute("a", func() error { return nil })
+	g.Execute("a", func() error { return nil })
+	g.Execute("b", func() error { return errors.New("failed") })
+	g.Execute("b", func() error { return nil })
+	g.Execute("c", func() error { return nil })
+
+	assert.Equal(t, GroupCounts{
+		Breakers: 3, Closed: 2, Open: 1,
+		Total: 3, Rejections: 1,
+		LifetimeTotal: 4, LifetimeFailures: 1, LifetimeRejections: 1,
+	}, g.Counts())
+}
@@ -0,0 +1,56 @@
+package circuit
+
+import "context"
+
+// SetGlobal puts a global circuit breaker covering the whole dependency
+// in front of the circuit breakers of the keys for Execute and ExecuteContext,
+// nil removes it. The requests pass both, the global one rejects them
+// when it's open and counts their outcomes by its own thresholds,
+// but not the rejections by the circuit breakers of the keys.
+// The requests of ExecuteContext pass the global one with their ctx,
+// e.g. WithBypass and the priority apply to it as well.
+//
+// The global circuit breaker also opens once at least minOpen circuit breakers of the keys
+// and at least openRate of them are open, e.g. when the whole backend is down
+// and the per key intervals would detect it one by one:
+//
+//	global, err := circuit.NewBreaker(time.Minute, 30*time.Second, 1, circuit.RateThreshold(0.5, 100), toClosed)
+//	g.SetGlobal(global, 3, 0.5)
+func (g *Group) SetGlobal(global *Breaker, minOpen int, openRate float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.global, g.minOpen, g.openRate = global, minOpen, openRate
+}
+
+// guardGlobal runs a request of ctx through the global circuit breaker if set.
+func (g *Group) guardGlobal(ctx context.Context, run func(context.Context) error) error {
+	g.mu.RLock()
+	global := g.global
+	g.mu.RUnlock()
+	if global == nil {
+		return run(ctx)
+	}
+	return global.executeContext(ctx, run, true)
+}
+
+// checkGlobal opens the global circuit breaker if enough circuit breakers of the keys are open,
+// it's called whenever one of them opens.
+func (g *Group) checkGlobal() {
+	g.mu.RLock()
+	global, minOpen, openRate := g.global, g.minOpen, g.openRate
+	var opened, all int
+	if global != nil {
+		for _, m := range g.breakers {
+			all++
+			if m.b.loadState() == open {
+				opened++
+			}
+		}
+	}
+	g.mu.RUnlock()
+
+	if global == nil || opened == 0 || opened < minOpen || float64(opened) < openRate*float64(all) {
+		return
+	}
+	global.Trip()
+}
@@ -0,0 +1,65 @@
+package circuit
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SetIdleTTL makes the group expire the circuit breakers not used for ttl,
+// e.g. of the hosts or tenants which stopped sending requests, zero stops expiring them.
+//
+// The idle circuit breakers are swept by the uses of the group at most once per ttl,
+// or by ExpireIdle. Every expired one emits EventExpired with its final counts
+// to its observers before it's dropped, a request for its key later
+// starts with a new closed circuit breaker.
+func (g *Group) SetIdleTTL(ttl time.Duration) {
+	now := g.now().UnixNano()
+	g.mu.RLock()
+	for _, m := range g.breakers {
+		if atomic.LoadInt64(&m.seen) == 0 {
+			// not tracked so far
+			atomic.StoreInt64(&m.seen, now)
+		}
+	}
+	g.mu.RUnlock()
+
+	atomic.StoreInt64(&g.swept, now)
+	atomic.StoreInt64(&g.idleTTL, ttl.Nanoseconds())
+}
+
+// ExpireIdle expires the circuit breakers not used for the idle TTL now, see SetIdleTTL.
+func (g *Group) ExpireIdle() {
+	if atomic.LoadInt64(&g.idleTTL) > 0 {
+		g.sweepIdle(true)
+	}
+}
+
+// sweepIdle expires the idle circuit breakers if forced or a ttl passed since the last sweep.
+func (g *Group) sweepIdle(force bool) {
+	ttl := atomic.LoadInt64(&g.idleTTL)
+	now := g.now().UnixNano()
+	swept := atomic.LoadInt64(&g.swept)
+	if !force && now-swept < ttl {
+		return
+	}
+	if !atomic.CompareAndSwapInt64(&g.swept, swept, now) && !force {
+		// swept by another goroutine
+		return
+	}
+
+	var expired []*Breaker
+	g.mu.Lock()
+	for key, m := range g.breakers {
+		if now-atomic.LoadInt64(&m.seen) >= ttl {
+			expired = append(expired, m.b)
+			delete(g.breakers, key)
+		}
+	}
+	g.mu.Unlock()
+
+	at := time.Unix(0, now)
+	for _, b := range expired {
+		counts := b.Counts()
+		b.emit(Event{Type: EventExpired, Time: at, Name: b.name, State: counts.State, Counts: counts})
+	}
+}
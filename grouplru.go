@@ -0,0 +1,67 @@
+package circuit
+
+import "sync/atomic"
+
+// member is a circuit breaker of a group with the recency of its use.
+type member struct {
+	b    *Breaker
+	used uint64 // tick of the group at the last use
+	seen int64  // timestamp of the last use if the idle circuit breakers expire
+}
+
+// SetMaxSize caps the number of the circuit breakers of the group,
+// e.g. keyed by a high-cardinality key like a user or URL,
+// evicting the least recently used ones once it's exceeded, zero removes the cap.
+//
+// onEvict, if not nil, is called with every evicted circuit breaker
+// after it's removed from the group, e.g. to unregister its metrics.
+// A request for the evicted key later starts with a new closed circuit breaker.
+func (g *Group) SetMaxSize(n int, onEvict func(key string, b *Breaker)) {
+	g.mu.Lock()
+	g.maxSize, g.onEvict = n, onEvict
+	evicted := g.evict(nil)
+	g.mu.Unlock()
+
+	notifyEvicted(evicted, onEvict)
+}
+
+// touch marks the circuit breaker of a member as the most recently used.
+func (g *Group) touch(m *member) {
+	atomic.StoreUint64(&m.used, atomic.AddUint64(&g.tick, 1))
+	if atomic.LoadInt64(&g.idleTTL) > 0 {
+		atomic.StoreInt64(&m.seen, g.now().UnixNano())
+	}
+}
+
+// evict removes the least recently used circuit breakers but the one of keep
+// while the group is over its max size, returns the removed ones by key.
+// It's called with the lock held.
+func (g *Group) evict(keep *member) map[string]*Breaker {
+	var evicted map[string]*Breaker
+	for g.maxSize > 0 && len(g.breakers) > g.maxSize {
+		var lru string
+		var oldest *member
+		for key, m := range g.breakers {
+			if m != keep && (oldest == nil || atomic.LoadUint64(&m.used) < atomic.LoadUint64(&oldest.used)) {
+				lru, oldest = key, m
+			}
+		}
+
+		if evicted == nil {
+			evicted = make(map[string]*Breaker)
+		}
+		evicted[lru] = oldest.b
+		delete(g.breakers, lru)
+	}
+	return evicted
+}
+
+// notifyEvicted calls onEvict for every evicted circuit breaker if set.
+func notifyEvicted(evicted map[string]*Breaker, onEvict func(key string, b *Breaker)) {
+	if onEvict == nil {
+		return
+	}
+	for key, b := range evicted {
+		onEvict(key, b)
+	}
+}
@@ -0,0 +1,129 @@
+package circuit
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// OutlierDetection is the configuration of Group.SetOutlierDetection, the zero values take the defaults.
+type OutlierDetection struct {
+	Interval     time.Duration // period of the detection, 10 seconds by default
+	Deviations   float64       // # of standard deviations above the mean failure rate of an outlier, 1.9 by default
+	MinRequests  uint32        // # of requests in the current period of a circuit breaker to judge it, 100 by default
+	MinMembers   int           // # of circuit breakers with enough requests to detect the outliers, 5 by default
+	MaxEjections float64       // max fraction of the circuit breakers open at once by the detection, at least one, 0.1 by default
+}
+
+// SetOutlierDetection makes the group compare the failure rates of the closed circuit breakers
+// with the mean one and open the statistical outliers ahead of their own thresholds,
+// e.g. one bad host behind a load balancer among the good ones, nil stops the detection.
+//
+// The outliers are detected by the uses of the group at most once per interval, or by DetectOutliers.
+// An ejected circuit breaker recovers as usual once its cooldown ends.
+func (g *Group) SetOutlierDetection(o *OutlierDetection) {
+	if o != nil {
+		c := *o
+		if c.Interval <= 0 {
+			c.Interval = 10 * time.Second
+		}
+		if c.Deviations <= 0 {
+			c.Deviations = 1.9
+		}
+		if c.MinRequests == 0 {
+			c.MinRequests = 100
+		}
+		if c.MinMembers <= 0 {
+			c.MinMembers = 5
+		}
+		if c.MaxEjections <= 0 || c.MaxEjections > 1 {
+			c.MaxEjections = 0.1
+		}
+		o = &c
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.outliers = o
+	atomic.StoreInt64(&g.detected, g.now().UnixNano())
+}
+
+// detectDue reports whether an interval of the outlier detection passed since the last one
+// and claims the detection if so.
+func (g *Group) detectDue() bool {
+	g.mu.RLock()
+	o := g.outliers
+	g.mu.RUnlock()
+	if o == nil {
+		return false
+	}
+
+	now := g.now().UnixNano()
+	detected := atomic.LoadInt64(&g.detected)
+	return now-detected >= o.Interval.Nanoseconds() && atomic.CompareAndSwapInt64(&g.detected, detected, now)
+}
+
+// DetectOutliers opens the circuit breakers whose failure rates are the outliers now,
+// returns their sorted keys, see SetOutlierDetection.
+func (g *Group) DetectOutliers() []string {
+	type rated struct {
+		key  string
+		b    *Breaker
+		rate float64
+	}
+
+	// copied to evaluate them unlocked, Counts can trip a circuit breaker calling back the group
+	g.mu.RLock()
+	o := g.outliers
+	all := make([]rated, 0, len(g.breakers))
+	for key, m := range g.breakers {
+		all = append(all, rated{key: key, b: m.b})
+	}
+	g.mu.RUnlock()
+
+	if o == nil {
+		return nil
+	}
+
+	var members []rated
+	var opened int
+	for _, m := range all {
+		c := m.b.Counts()
+		switch {
+		case c.State == StateOpen:
+			opened++
+		case c.State == StateClosed && c.Total >= o.MinRequests:
+			m.rate = float64(c.Failures) / float64(c.Total)
+			members = append(members, m)
+		}
+	}
+	if len(members) < o.MinMembers {
+		return nil
+	}
+
+	var mean, variance float64
+	for _, m := range members {
+		mean += m.rate
+	}
+	mean /= float64(len(members))
+	for _, m := range members {
+		variance += (m.rate - mean) * (m.rate - mean)
+	}
+	threshold := mean + o.Deviations*math.Sqrt(variance/float64(len(members)))
+
+	// the worst ones first while the ejections are capped
+	sort.Slice(members, func(i, j int) bool { return members[i].rate > members[j].rate })
+	ejections := max(int(o.MaxEjections*float64(len(all))), 1) - opened
+	var keys []string
+	for _, m := range members {
+		if m.rate <= threshold || len(keys) >= ejections {
+			break
+		}
+		m.b.Trip()
+		keys = append(keys, m.key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
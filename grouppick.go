@@ -0,0 +1,50 @@
+package circuit
+
+import "sync/atomic"
+
+// PickHealthy returns one of the given keys whose circuit breaker is closed,
+// rotating the picks round-robin, for a client side load balancing
+// sparing the endpoints known to fail:
+//
+//	host, err := g.PickHealthy(hosts)
+//	if err != nil {
+//		return err
+//	}
+//	err = g.Execute(host, func() error { return call(host) })
+//
+// A key without a circuit breaker yet counts as closed. A half-open one,
+// or an open one whose cooldown is over, is picked only when none is closed,
+// to let it probe the recovery.
+// Returns ErrBreakerOpen if every circuit breaker is open or there are no keys.
+func (g *Group) PickHealthy(keys []string) (string, error) {
+	if len(keys) == 0 {
+		return "", ErrBreakerOpen
+	}
+
+	first := int(atomic.AddUint32(&g.picks, 1)-1) % len(keys)
+	for _, want := range []func(*Breaker) bool{closedBreaker, recoveringBreaker} {
+		for i := range keys {
+			key := keys[(first+i)%len(keys)]
+			if want(g.Get(key)) {
+				return key, nil
+			}
+		}
+	}
+	return "", ErrBreakerOpen
+}
+
+func closedBreaker(b *Breaker) bool {
+	return b.State() == StateClosed
+}
+
+// recoveringBreaker tells whether a circuit breaker lets a trial request through,
+// it's half-open or open with the cooldown over, the state changes lazily by the next request.
+func recoveringBreaker(b *Breaker) bool {
+	switch b.State() {
+	case StateHalfOpen:
+		return true
+	case StateOpen:
+		return b.RetryAfter() == 0
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package circuit
+
+import "time"
+
+// WithHalfOpenTimeout limits the time the circuit breaker stays in the half-open state
+// waiting for atLeastReqs, e.g. when the traffic has stopped, after which
+// it's moved into a given state, StateClosed or StateOpen for another cooldown period.
+//
+// The timeout is checked by the incoming requests, State and Counts,
+// EventHalfOpenTimeout is emitted to the observers when it's reached.
+func WithHalfOpenTimeout(d time.Duration, to State) Option {
+	return func(b *Breaker) {
+		b.halfOpenTimeout = d.Nanoseconds()
+		b.halfOpenTo = to
+	}
+}
+
+// halfOpenPeriod returns the duration of the half-open period.
+func (b *Breaker) halfOpenPeriod() int64 {
+	if b.halfOpenTimeout > 0 {
+		return b.halfOpenTimeout
+	}
+	return b.intervalNanos()
+}
+
+// expireHalfOpen moves the circuit breaker out of the half-open state
+// once its timeout is reached, reports whether it did.
+func (b *Breaker) expireHalfOpen() bool {
+	if b.halfOpenTimeout == 0 {
+		return false
+	}
+
+	// any state changes are done based on CompareAndSwap(word)
+	w, until := b.period()
+
+	if stateOf(w) != halfOpen {
+		return false
+	}
+
+	now := b.nanotime()
+	if now <= until {
+		return false
+	}
+
+	var ok bool
+	if b.halfOpenTo == StateClosed {
+		ok = b.switchTo(closed, w, now, now+b.intervalNanos())
+	} else {
+		ok = b.trip(w, now)
+	}
+
+	if ok && len(b.observers) > 0 {
+		counts := b.snapshot()
+		b.emit(Event{Type: EventHalfOpenTimeout, Time: time.Unix(0, now), Name: b.name, From: StateHalfOpen, State: counts.State, Counts: counts})
+	}
+	return ok
+}
@@ -0,0 +1,49 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithHalfOpenTimeout(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return true }
+	toClosed := func(uint32, uint32) bool { return true }
+
+	var events []Event
+	observer := ObserverFunc(func(e Event) {
+		if e.Type == EventHalfOpenTimeout {
+			events = append(events, e)
+		}
+	})
+	b, err := withTimeNow(time.Minute, time.Minute, 5, toOpen, toClosed, now(1520100000),
+		WithHalfOpenTimeout(30*time.Second, StateOpen), WithObserver(observer))
+	assert.NoError(t, err)
+
+	b.ForceOpen()
+	b.ClearOverride()
+
+	// half-open with a single probe
+	b.now = now(1520100061)
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.Equal(t, int64(1520100091000000000), b.until)
+
+	// timed out into the open state with a new cooldown period
+	b.now = now(1520100092)
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, int64(1520100152000000000), b.until)
+	assert.Len(t, events, 1)
+	assert.Equal(t, StateHalfOpen, events[0].From)
+	assert.Equal(t, StateOpen, events[0].State)
+
+	// timed out into the closed state
+	b.halfOpenTo = StateClosed
+	b.now = now(1520100153)
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	b.now = now(1520100184)
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, StateClosed, b.Counts().State)
+	assert.Len(t, events, 2)
+}
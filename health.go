@@ -0,0 +1,71 @@
+package circuit
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// WithHealthProbe makes the circuit breaker run a given probe every interval
+// in the background while it's open, closing it once atLeastReqs probes
+// succeeded in a row, so the recovery doesn't depend on the real requests.
+// The requests still move it into the half-open state after the cooldown period as usual.
+//
+// The probe is given a context with the interval as the timeout,
+// a returned error or a panic counts as a failure.
+func WithHealthProbe(probe func(ctx context.Context) error, interval time.Duration) Option {
+	return func(b *Breaker) {
+		b.healthProbe = probe
+		b.healthInterval = interval
+	}
+}
+
+// probeHealth runs the health probe until the circuit breaker leaves the open period
+// of a given generation, only a single goroutine does it at once, the one of the latest period.
+func (b *Breaker) probeHealth(gen uint64) {
+	for {
+		probing := atomic.LoadUint64(&b.probing)
+		if probing >= gen {
+			return
+		}
+		if atomic.CompareAndSwapUint64(&b.probing, probing, gen) {
+			break
+		}
+	}
+	defer atomic.CompareAndSwapUint64(&b.probing, gen, 0)
+
+	var successes uint32
+	for {
+		<-b.after(b.healthInterval)
+
+		if atomic.LoadUint64(&b.probing) != gen {
+			// taken over by the probe of a later period
+			return
+		}
+
+		// any state changes are done based on CompareAndSwap(word)
+		w := atomic.LoadUint64(&b.word)
+
+		if stateOf(w) != open || genOf(w) != gen {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), b.healthInterval)
+		err := guard(func() error { return b.healthProbe(ctx) })
+		cancel()
+
+		if err != nil {
+			successes = 0
+			continue
+		}
+
+		successes++
+		if successes >= atomic.LoadUint32(&b.atLeastReqs) && atomic.LoadInt32(&b.override) != int32(OverrideOpen) {
+			now := b.nanotime()
+			if b.switchTo(closed, w, now, now+b.intervalNanos()) {
+				b.startRamp(now)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithHealthProbe(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return true }
+	toClosed := func(uint32, uint32) bool { return false }
+
+	var healthy int32
+	var probes int32
+	probe := func(ctx context.Context) error {
+		atomic.AddInt32(&probes, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			return errors.New("unhealthy")
+		}
+		return nil
+	}
+	b, err := withTimeNow(time.Minute, time.Hour, 3, toOpen, toClosed, now(1520100000), WithHealthProbe(probe, time.Millisecond))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+
+	// kept open while the probes fail
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&probes) > 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, StateOpen, b.State())
+
+	// closed once the probes succeed in a row
+	atomic.StoreInt32(&healthy, 1)
+	assert.Eventually(t, func() bool { return b.State() == StateClosed }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return atomic.LoadUint64(&b.probing) == 0 }, time.Second, time.Millisecond)
+}
+
+func TestBreaker_WithHealthProbe_Generation(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return true }
+	toClosed := func(uint32, uint32) bool { return false }
+
+	var probes int32
+	probe := func(ctx context.Context) error {
+		atomic.AddInt32(&probes, 1)
+		return errors.New("unhealthy")
+	}
+	b, err := withTimeNow(time.Minute, time.Hour, 3, toOpen, toClosed, now(1520100000), WithHealthProbe(probe, time.Millisecond))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	gen := b.loadGen()
+	assert.Eventually(t, func() bool { return atomic.LoadUint64(&b.probing) == gen }, time.Second, time.Millisecond)
+
+	// neither the probe of the same period nor of an earlier one run again
+	b.probeHealth(gen)
+	b.probeHealth(gen - 1)
+	assert.Equal(t, gen, atomic.LoadUint64(&b.probing))
+
+	// the probe of a later period takes over, returning as the period isn't current
+	b.probeHealth(gen + 1)
+	assert.Equal(t, uint64(0), atomic.LoadUint64(&b.probing))
+	assert.Equal(t, StateOpen, b.State())
+}
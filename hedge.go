@@ -0,0 +1,94 @@
+package circuit
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// ExecuteHedged runs a given request like ExecuteContext does and launches
+// up to maxHedges backup attempts, one every hedgeAfter while none has returned,
+// cutting the tail latency of a slow dependency.
+//
+// Every attempt is accepted and counted by the circuit breaker on its own,
+// no more are launched once it rejects one. The first attempt succeeded wins,
+// the context of the others is cancelled and their outcomes are not counted.
+//
+// Returns ErrBreakerOpen when the circuit breaker doesn't accept the first attempt,
+// nil once an attempt succeeded, otherwise the error of the first failed attempt.
+// The panic of any attempt is resumed once it's returned, as with Execute,
+// a negative maxHedges is the same as zero.
+func (b *Breaker) ExecuteHedged(ctx context.Context, req func(context.Context) error, hedgeAfter time.Duration, maxHedges int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	maxHedges = max(maxHedges, 0)
+
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var won int32
+	results := make(chan error, maxHedges+1)
+	launch := func() error {
+		tok, err := b.Allow()
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			err := guard(func() error { return req(hedgeCtx) })
+			switch {
+			case atomic.LoadInt32(&won) == 1:
+				// cancelled by the winner
+				tok.Ignore()
+			case b.contextErrors && ctx.Err() != nil:
+				tok.Record(b.contextErrorOutcome)
+			case b.ignored(err):
+				tok.Ignore()
+			case b.critical(err):
+				tok.Critical()
+			case b.failed(err):
+				tok.Failure()
+			default:
+				tok.Success()
+			}
+			results <- err
+		}()
+		return nil
+	}
+
+	if err := launch(); err != nil {
+		return err
+	}
+
+	timer := b.after(hedgeAfter)
+
+	var firstErr error
+	for pending, hedges := 1, 0; pending > 0; {
+		select {
+		case err := <-results:
+			pending--
+			if _, ok := err.(*PanicError); ok && !b.panicAsError {
+				// the other attempts are cancelled and not counted
+				atomic.StoreInt32(&won, 1)
+				b.repanic(err)
+			}
+			if !b.failed(err) {
+				atomic.StoreInt32(&won, 1)
+				return b.wrap(err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		case <-timer:
+			if hedges < maxHedges && launch() == nil {
+				hedges++
+				pending++
+				timer = b.after(hedgeAfter)
+			}
+		}
+	}
+
+	b.repanic(firstErr)
+	return b.wrap(firstErr)
+}
@@ -0,0 +1,85 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_ExecuteHedged(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return false }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	// the first attempt is slow, the hedge wins and the first is cancelled
+	var attempts, cancelled int32
+	err = b.ExecuteHedged(context.Background(), func(ctx context.Context) error {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			<-ctx.Done()
+			atomic.AddInt32(&cancelled, 1)
+			return ctx.Err()
+		}
+		return nil
+	}, time.Millisecond, 2)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return b.Counts().Total == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cancelled))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	counts := b.Counts()
+	assert.Equal(t, uint32(1), counts.Successes)
+	assert.Equal(t, uint32(0), counts.Failures)
+
+	// all the attempts failed
+	err = b.ExecuteHedged(context.Background(), func(ctx context.Context) error {
+		time.Sleep(5 * time.Millisecond)
+		return errors.New("failed")
+	}, time.Millisecond, 2)
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, uint32(3), b.Counts().Failures)
+
+	// rejected
+	b.ForceOpen()
+	err = b.ExecuteHedged(context.Background(), func(ctx context.Context) error { return nil }, time.Millisecond, 2)
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+}
+
+func TestBreaker_ExecuteHedged_Panic(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	b, err := NewBreaker(time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+
+	// of a hedge
+	var attempts int32
+	assert.PanicsWithValue(t, "boom", func() {
+		b.ExecuteHedged(context.Background(), func(ctx context.Context) error {
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			panic("boom")
+		}, time.Millisecond, 1)
+	})
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+	assert.Eventually(t, func() bool { return b.Counts().Failures == 1 }, time.Second, time.Millisecond)
+}
+
+func TestBreaker_ExecuteHedged_NegativeHedges(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	b, err := NewBreaker(time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+
+	var attempts int32
+	err = b.ExecuteHedged(context.Background(), func(ctx context.Context) error {
+		atomic.AddInt32(&attempts, 1)
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}, time.Millisecond, -2)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
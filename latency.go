@@ -0,0 +1,172 @@
+package circuit
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// histBuckets is the number of the buckets of the latency histogram,
+// they grow by 2^(1/4) from 1µs up to about 50 minutes.
+const histBuckets = 128
+
+// Latency is the approximate quantiles of the requests' latency.
+type Latency struct {
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+}
+
+// histogram counts the latencies in the exponential buckets.
+type histogram struct {
+	buckets [histBuckets]uint32
+}
+
+func (h *histogram) observe(d int64) {
+	atomic.AddUint32(&h.buckets[bucketOf(d)], 1)
+}
+
+// quantile returns the upper bound of the bucket holding the q-quantile,
+// zero if there are no observations.
+func (h *histogram) quantile(q float64) time.Duration {
+	var counts [histBuckets]uint32
+	var total uint64
+	for i := range h.buckets {
+		counts[i] = atomic.LoadUint32(&h.buckets[i])
+		total += uint64(counts[i])
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for i, n := range counts {
+		seen += uint64(n)
+		if seen >= rank && n > 0 {
+			return upperBound(i)
+		}
+	}
+	return upperBound(histBuckets - 1)
+}
+
+func (h *histogram) latency() Latency {
+	return Latency{P50: h.quantile(0.5), P95: h.quantile(0.95), P99: h.quantile(0.99)}
+}
+
+func (h *histogram) reset() {
+	for i := range h.buckets {
+		atomic.StoreUint32(&h.buckets[i], 0)
+	}
+}
+
+// bucketOf returns the index of the bucket of d nanoseconds.
+func bucketOf(d int64) int {
+	us := d / int64(time.Microsecond)
+	if us < 1 {
+		return 0
+	}
+
+	i := int(4*math.Log2(float64(us))) + 1
+	if i >= histBuckets {
+		return histBuckets - 1
+	}
+	return i
+}
+
+func upperBound(i int) time.Duration {
+	return time.Duration(math.Pow(2, float64(i)/4) * float64(time.Microsecond))
+}
+
+// WithSlowCallThreshold makes the circuit breaker count the requests
+// taking d or longer as slow, even when they succeed,
+// and open it once the rate of the slow requests in the interval reaches a given rate.
+func WithSlowCallThreshold(d time.Duration, rate float64) Option {
+	return func(b *Breaker) {
+		b.slowThreshold = d.Nanoseconds()
+		b.slowRate = rate
+	}
+}
+
+// WithLatencyPercentile makes the circuit breaker track the latency
+// of the requests in a histogram per period, exposed in Counts().Latency,
+// and open it once the q-quantile of the latency in the interval
+// exceeds a given bound, e.g. WithLatencyPercentile(0.99, 500*time.Millisecond).
+//
+// The quantiles are approximate, within 19% of the real values.
+func WithLatencyPercentile(q float64, bound time.Duration) Option {
+	return func(b *Breaker) {
+		b.latencies = &histogram{}
+		b.latencyQuantile = q
+		b.latencyBound = bound
+	}
+}
+
+// startTimer returns the start timestamp of a request if the latency is tracked.
+func (b *Breaker) startTimer() int64 {
+	if b.slowThreshold == 0 && b.latencies == nil {
+		return 0
+	}
+	return b.nanotime()
+}
+
+// observeLatency records the latency of a request started at start.
+func (b *Breaker) observeLatency(start int64) {
+	if start == 0 {
+		return
+	}
+
+	d := b.nanotime() - start
+
+	if b.slowThreshold > 0 && d >= b.slowThreshold {
+		atomic.AddUint32(&b.slowCalls, 1)
+		b.onSlowCall()
+	}
+
+	if b.latencies != nil {
+		b.latencies.observe(d)
+		// the quantile can exceed the bound only with a latency above it
+		if time.Duration(d) > b.latencyBound {
+			b.onSlowQuantile()
+		}
+	}
+}
+
+func (b *Breaker) onSlowCall() {
+	// any state changes are done based on CompareAndSwap(word)
+	w := atomic.LoadUint64(&b.word)
+
+	if stateOf(w) != closed {
+		return
+	}
+
+	total, _, _ := b.counters()
+	slow := atomic.LoadUint32(&b.slowCalls)
+	if total == 0 || total < b.minVolume {
+		return
+	}
+
+	if float64(slow)/float64(total) >= b.slowRate {
+		now := b.nanotime()
+		b.trip(w, now)
+	}
+}
+
+func (b *Breaker) onSlowQuantile() {
+	// any state changes are done based on CompareAndSwap(word)
+	w := atomic.LoadUint64(&b.word)
+
+	if stateOf(w) != closed {
+		return
+	}
+
+	total, _, _ := b.counters()
+	if total == 0 || total < b.minVolume {
+		return
+	}
+
+	if b.latencies.quantile(b.latencyQuantile) > b.latencyBound {
+		now := b.nanotime()
+		b.trip(w, now)
+	}
+}
@@ -0,0 +1,95 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithSlowCallThreshold(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return false }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithSlowCallThreshold(time.Second, 0.5), WithMinimumVolume(3))
+	assert.NoError(t, err)
+
+	sec := int64(1520100000)
+	execute := func(d int64) {
+		b.Execute(func() error {
+			sec += d
+			b.now = now(sec)
+			return nil
+		})
+	}
+
+	execute(0)
+	execute(2)
+	assert.Equal(t, uint32(1), b.Counts().SlowCalls)
+	assert.Equal(t, closed, b.loadState())
+
+	// the minimum volume reached
+	execute(1)
+	assert.Equal(t, open, b.loadState())
+	assert.Equal(t, uint32(0), b.Counts().SlowCalls)
+}
+
+func TestToken_SlowCall(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return false }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithSlowCallThreshold(time.Second, 1))
+	assert.NoError(t, err)
+
+	tok, err := b.Allow()
+	assert.NoError(t, err)
+	b.now = now(1520100005)
+	tok.Success()
+	assert.Equal(t, open, b.loadState())
+}
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	assert.Equal(t, time.Duration(0), h.quantile(0.99))
+
+	for i := 0; i < 98; i++ {
+		h.observe(int64(10 * time.Millisecond))
+	}
+	h.observe(int64(time.Second))
+	h.observe(int64(time.Second))
+
+	l := h.latency()
+	assert.InDelta(t, float64(10*time.Millisecond), float64(l.P50), float64(2*time.Millisecond))
+	assert.InDelta(t, float64(10*time.Millisecond), float64(l.P95), float64(2*time.Millisecond))
+	assert.InDelta(t, float64(time.Second), float64(l.P99), float64(200*time.Millisecond))
+	assert.True(t, l.P99 >= time.Second)
+
+	h.reset()
+	assert.Equal(t, Latency{}, h.latency())
+
+	assert.Equal(t, 0, bucketOf(500))
+	assert.Equal(t, histBuckets-1, bucketOf(int64(24*time.Hour)))
+}
+
+func TestBreaker_WithLatencyPercentile(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return false }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithLatencyPercentile(0.5, time.Second))
+	assert.NoError(t, err)
+
+	ns := time.Unix(1520100000, 0).UnixNano()
+	execute := func(d time.Duration) {
+		b.Execute(func() error {
+			ns += int64(d)
+			b.now = func() time.Time { return time.Unix(0, ns) }
+			return nil
+		})
+	}
+
+	execute(100 * time.Millisecond)
+	execute(2 * time.Second)
+	assert.Equal(t, closed, b.loadState())
+	assert.True(t, b.Counts().Latency.P99 >= 2*time.Second)
+
+	execute(3 * time.Second)
+	assert.Equal(t, open, b.loadState())
+	assert.Equal(t, Latency{}, b.Counts().Latency)
+}
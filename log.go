@@ -0,0 +1,82 @@
+package circuit
+
+import (
+	"context"
+	"log/slog"
+	"math/bits"
+)
+
+// WithLogger makes the circuit breaker log the transitions,
+// the rejections and the invalid configuration with a given logger.
+//
+// The rejections are sampled, logged when their number
+// in the current period reaches a power of two.
+func WithLogger(logger *slog.Logger) Option {
+	return func(b *Breaker) {
+		b.logger = logger
+		b.observers = append(b.observers, ObserverFunc(b.logEvent))
+	}
+}
+
+func (b *Breaker) logEvent(e Event) {
+	switch e.Type {
+	case EventStateChanged:
+		b.logStateChange(e.From, e.State, e.Counts)
+	case EventRequestRejected:
+		b.logRejection(e.Counts.Rejections)
+	case EventHalfOpenTimeout:
+		b.logger.Warn("circuit: half-open timed out",
+			slog.String("name", b.name),
+			slog.String("to", e.State.String()),
+		)
+	}
+}
+
+func (b *Breaker) logStateChange(from, to State, counts Counts) {
+	level := slog.LevelInfo
+	if to == StateOpen {
+		level = slog.LevelWarn
+	}
+	b.logger.Log(context.Background(), level, "circuit: state changed",
+		slog.String("name", b.name),
+		slog.String("from", from.String()),
+		slog.String("to", to.String()),
+		slog.Uint64("total", uint64(counts.Total)),
+		slog.Uint64("failures", uint64(counts.Failures)),
+		slog.Uint64("rejections", uint64(counts.Rejections)),
+	)
+}
+
+// logRejection logs the nth rejection in the current period if it's sampled.
+func (b *Breaker) logRejection(n uint32) {
+	if bits.OnesCount32(n) != 1 {
+		return
+	}
+
+	b.logger.Warn("circuit: request rejected",
+		slog.String("name", b.name),
+		slog.Uint64("rejections", uint64(n)),
+	)
+}
+
+func (b *Breaker) logStorageError(err error) {
+	if b.logger == nil {
+		return
+	}
+
+	b.logger.Error("circuit: storage failed",
+		slog.String("name", b.name),
+		slog.String("error", err.Error()),
+	)
+}
+
+func (b *Breaker) logInvalid(err error) {
+	if b.logger == nil {
+		return
+	}
+
+	b.logger.Error("circuit: invalid configuration",
+		slog.String("name", b.name),
+		slog.String("error", err.Error()),
+	)
+}
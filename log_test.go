@@ -0,0 +1,46 @@
+package circuit
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithName("payments"), WithLogger(logger))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	for i := 0; i < 5; i++ {
+		b.Execute(func() error { return nil })
+	}
+
+	assert.Equal(t, []string{
+		`level=WARN msg="circuit: state changed" name=payments from=closed to=open total=1 failures=1 rejections=0`,
+		`level=WARN msg="circuit: request rejected" name=payments rejections=1`,
+		`level=WARN msg="circuit: request rejected" name=payments rejections=2`,
+		`level=WARN msg="circuit: request rejected" name=payments rejections=4`,
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+
+	buf.Reset()
+	_, err = NewBreaker(time.Minute, time.Minute, 0, toOpen, toClosed, WithName("accounts"), WithLogger(logger))
+	assert.Error(t, err)
+	assert.Equal(t, `level=ERROR msg="circuit: invalid configuration" name=accounts error="circuit: atLeastReqs must be set"`+"\n", buf.String())
+}
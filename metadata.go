@@ -0,0 +1,57 @@
+package circuit
+
+import "context"
+
+// Metadata is the metadata of a request carried by its context,
+// e.g. to attribute the events of a circuit breaker shared by many operations.
+type Metadata struct {
+	Operation string   // name of the operation, e.g. "GetUser"
+	Tenant    string   // tenant the request is made for
+	Priority  Priority // priority of the request, see WithPriority
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a copy of ctx carrying a given metadata of the request
+// for ExecuteContext, its priority is carried as with WithPriority.
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	return WithPriority(context.WithValue(ctx, metadataKey{}, md), md.Priority)
+}
+
+// MetadataFrom returns the metadata of the request carried by ctx,
+// with the priority set by WithPriority if any, the zero value if there is none.
+func MetadataFrom(ctx context.Context) Metadata {
+	md, _ := ctx.Value(metadataKey{}).(Metadata)
+	md.Priority = PriorityFrom(ctx)
+	return md
+}
+
+// WithContextClassifier makes ExecuteContext count a request returned err
+// as the outcome returned by classify, given the context of the request
+// to decide by its metadata, e.g. to ignore the failures of a tenant known to be misbehaving:
+//
+//	circuit.WithContextClassifier(func(ctx context.Context, err error) circuit.Outcome {
+//		if err == nil {
+//			return circuit.OutcomeSuccess
+//		}
+//		if circuit.MetadataFrom(ctx).Tenant == "load-test" {
+//			return circuit.OutcomeIgnored
+//		}
+//		return circuit.OutcomeFailure
+//	})
+//
+// It replaces the error classification of ExecuteContext, a panic is always a failure.
+func WithContextClassifier(classify func(ctx context.Context, err error) Outcome) Option {
+	return func(b *Breaker) {
+		b.classifyContext = classify
+	}
+}
+
+// metadata returns the metadata carried by ctx for the events, nil if there are no observers.
+func (b *Breaker) metadata(ctx context.Context) *Metadata {
+	if len(b.observers) == 0 {
+		return nil
+	}
+	md := MetadataFrom(ctx)
+	return &md
+}
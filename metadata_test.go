@@ -0,0 +1,76 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataFrom(t *testing.T) {
+	assert.Equal(t, Metadata{}, MetadataFrom(context.Background()))
+
+	md := Metadata{Operation: "GetUser", Tenant: "acme", Priority: PriorityHigh}
+	ctx := WithMetadata(context.Background(), md)
+	assert.Equal(t, md, MetadataFrom(ctx))
+	assert.Equal(t, PriorityHigh, PriorityFrom(ctx))
+
+	ctx = WithPriority(ctx, PriorityLow)
+	assert.Equal(t, Metadata{Operation: "GetUser", Tenant: "acme", Priority: PriorityLow}, MetadataFrom(ctx))
+}
+
+func TestBreaker_ExecuteContext_Metadata(t *testing.T) {
+	events := make(map[EventType]Metadata)
+	observer := ObserverFunc(func(e Event) {
+		if e.Type != EventStateChanged {
+			events[e.Type] = e.Metadata
+		}
+	})
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithObserver(observer))
+	assert.NoError(t, err)
+
+	md := Metadata{Operation: "GetUser", Tenant: "acme"}
+	ctx := WithMetadata(context.Background(), md)
+	b.ExecuteContext(ctx, func(context.Context) error { return errors.New("failed") })
+	b.ExecuteContext(ctx, func(context.Context) error { return nil })
+	assert.Equal(t, map[EventType]Metadata{
+		EventRequestAllowed:  md,
+		EventRequestFailed:   md,
+		EventRequestRejected: md,
+	}, events)
+
+	b.Execute(func() error { return nil })
+	assert.Equal(t, Metadata{}, events[EventRequestRejected], "no context, no metadata")
+}
+
+func TestBreaker_WithContextClassifier(t *testing.T) {
+	classify := func(ctx context.Context, err error) Outcome {
+		if err == nil {
+			return OutcomeSuccess
+		}
+		if MetadataFrom(ctx).Tenant == "load-test" {
+			return OutcomeIgnored
+		}
+		return OutcomeFailure
+	}
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithContextClassifier(classify))
+	assert.NoError(t, err)
+
+	loadTest := WithMetadata(context.Background(), Metadata{Tenant: "load-test"})
+	b.ExecuteContext(loadTest, func(context.Context) error { return errors.New("failed") })
+	b.ExecuteContext(context.Background(), func(context.Context) error { return nil })
+	b.ExecuteContext(context.Background(), func(context.Context) error { return errors.New("failed") })
+	assert.Equal(t, Counts{State: StateClosed, WindowStart: time.Unix(1520100000, 0), Total: 2, Successes: 1, Failures: 1,
+		ConsecutiveFailures: 1, LifetimeTotal: 2, LifetimeFailures: 1}, b.Counts())
+
+	assert.Panics(t, func() {
+		b.ExecuteContext(loadTest, func(context.Context) error { panic("boom") })
+	})
+	assert.Equal(t, open, b.loadState(), "a panic is always a failure")
+}
@@ -0,0 +1,66 @@
+package circuit
+
+import "time"
+
+// windowBuckets is the number of the buckets of a window of WithMultiWindow.
+const windowBuckets = 10
+
+// WindowThreshold is a failure rate threshold over a sliding window, see WithMultiWindow.
+type WindowThreshold struct {
+	Span        time.Duration // length of the window
+	Rate        float64       // failure rate to open the circuit breaker at
+	MinRequests uint32        // # of requests in the window to judge it
+}
+
+// WithMultiWindow makes the circuit breaker open once the failure rate
+// over any of the sliding windows reaches its threshold regardless of toOpen,
+// e.g. a short window catching the sharp spikes quickly along with a long one
+// catching the slow burns, one interval never fits both:
+//
+//	circuit.WithMultiWindow(
+//		circuit.WindowThreshold{Span: 10 * time.Second, Rate: 0.5, MinRequests: 20},
+//		circuit.WindowThreshold{Span: 10 * time.Minute, Rate: 0.05, MinRequests: 200},
+//	)
+//
+// The windows slide in 10 buckets each and are reset on every transition.
+func WithMultiWindow(thresholds ...WindowThreshold) Option {
+	return func(b *Breaker) {
+		b.multiWindow = nil
+		for _, t := range thresholds {
+			if t.Span > 0 {
+				b.multiWindow = append(b.multiWindow, thresholdWindow{t, newRollingWindow(t.Span.Nanoseconds(), windowBuckets)})
+			}
+		}
+	}
+}
+
+// thresholdWindow is a sliding window of WithMultiWindow with its threshold.
+type thresholdWindow struct {
+	WindowThreshold
+	w *rollingWindow
+}
+
+// recordMultiWindow records an outcome in every window.
+func (b *Breaker) recordMultiWindow(now int64, failure bool) {
+	for _, t := range b.multiWindow {
+		t.w.record(now, failure)
+	}
+}
+
+// multiWindowTrips reports whether the failure rate over any window reached its threshold.
+func (b *Breaker) multiWindowTrips(now int64) bool {
+	for _, t := range b.multiWindow {
+		total, failures := t.w.counts(now)
+		if total > 0 && total >= t.MinRequests && float64(failures)/float64(total) >= t.Rate {
+			return true
+		}
+	}
+	return false
+}
+
+// resetMultiWindow clears every window.
+func (b *Breaker) resetMultiWindow(now int64) {
+	for _, t := range b.multiWindow {
+		t.w.reset(now)
+	}
+}
@@ -0,0 +1,55 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithMultiWindow(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return false }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	newBreaker := func() *Breaker {
+		b, err := withTimeNow(time.Hour, time.Minute, 1, toOpen, toClosed, now(1520100000), WithMultiWindow(
+			WindowThreshold{Span: 10 * time.Second, Rate: 0.5, MinRequests: 4},
+			WindowThreshold{Span: 10 * time.Minute, Rate: 0.1, MinRequests: 25},
+		))
+		assert.NoError(t, err)
+		return b
+	}
+	failed := func() error { return errors.New("failed") }
+	succeeded := func() error { return nil }
+
+	// a sharp spike trips the short window
+	b := newBreaker()
+	b.Execute(succeeded)
+	b.Execute(failed)
+	b.Execute(failed)
+	assert.Equal(t, closed, b.loadState())
+	b.Execute(failed)
+	assert.Equal(t, open, b.loadState())
+
+	// a slow burn, 1 of 5 failed every 10 seconds, trips the long window only
+	b = newBreaker()
+	for i := int64(0); i < 5; i++ {
+		b.now = now(1520100000 + i*10)
+		for j := 0; j < 4; j++ {
+			b.Execute(succeeded)
+		}
+		assert.Equal(t, closed, b.loadState())
+		b.Execute(failed)
+	}
+	assert.Equal(t, open, b.loadState())
+
+	// the failures out of the windows don't count
+	b = newBreaker()
+	b.Execute(failed)
+	b.Execute(failed)
+	b.Execute(failed)
+	b.now = now(1520100011)
+	b.Execute(succeeded)
+	b.Execute(failed)
+	assert.Equal(t, closed, b.loadState())
+}
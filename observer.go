@@ -0,0 +1,126 @@
+package circuit
+
+import "time"
+
+// EventType is the type of an event of the circuit breaker.
+type EventType int
+
+const (
+	// EventRequestAllowed is emitted when a request is accepted to run.
+	EventRequestAllowed EventType = iota
+	// EventRequestRejected is emitted when a request is rejected with ErrBreakerOpen.
+	EventRequestRejected
+	// EventRequestFailed is emitted when a request is counted as a failure.
+	EventRequestFailed
+	// EventStateChanged is emitted on every transition.
+	EventStateChanged
+	// EventHalfOpenTimeout is emitted when the half-open state timed out, see WithHalfOpenTimeout.
+	EventHalfOpenTimeout
+	// EventTuned is emitted when the settings are tuned, see WithAutoTune.
+	EventTuned
+	// EventRequestBypassed is emitted when a request is run bypassing the circuit breaker, see WithBypass.
+	EventRequestBypassed
+	// EventExpired is emitted with the final counts when an idle circuit breaker of a group expires, see Group.SetIdleTTL.
+	EventExpired
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventRequestAllowed:
+		return "request-allowed"
+	case EventRequestRejected:
+		return "request-rejected"
+	case EventRequestFailed:
+		return "request-failed"
+	case EventStateChanged:
+		return "state-changed"
+	case EventHalfOpenTimeout:
+		return "half-open-timeout"
+	case EventTuned:
+		return "tuned"
+	case EventRequestBypassed:
+		return "request-bypassed"
+	case EventExpired:
+		return "expired"
+	}
+	return "unknown"
+}
+
+// Event is an event of the circuit breaker received by the observers.
+type Event struct {
+	Type  EventType
+	Time  time.Time
+	Name  string // name of the circuit breaker
+	State State  // state of the circuit breaker, the new one for EventStateChanged and EventHalfOpenTimeout
+	From  State  // previous state for EventStateChanged and EventHalfOpenTimeout
+
+	// Counts is a snapshot of the counters,
+	// of the finished period for EventStateChanged.
+	// It has no Latency and FailuresByCategory for the request events,
+	// they're costly to compute on every request, see Snapshot.
+	Counts Counts
+
+	Err error // error of the request for EventRequestFailed, nil if unknown
+
+	Tuning Tuning // new settings for EventTuned
+
+	Metadata Metadata // metadata of the request carried by the context of ExecuteContext, see WithMetadata
+
+	b *Breaker // emitted the request event, see Snapshot
+}
+
+// Snapshot returns the current counts of the circuit breaker for a request event,
+// including the latency and the failures by category missing in Counts,
+// and Counts for the other events.
+func (e Event) Snapshot() Counts {
+	if e.b == nil {
+		return e.Counts
+	}
+	return e.b.Counts()
+}
+
+// Observer receives the events of the circuit breaker,
+// e.g. to collect metrics or log.
+//
+// Observe is called synchronously by the goroutine running the request
+// or making the transition, so it should return quickly.
+type Observer interface {
+	Observe(e Event)
+}
+
+// ObserverFunc is a function used as an Observer.
+type ObserverFunc func(e Event)
+
+// Observe calls f(e).
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+// WithObserver attaches an observer to the circuit breaker,
+// the option can be used many times to attach many observers.
+func WithObserver(o Observer) Option {
+	return func(b *Breaker) {
+		b.observers = append(b.observers, o)
+	}
+}
+
+func (b *Breaker) emit(e Event) {
+	for _, o := range b.observers {
+		o.Observe(e)
+	}
+}
+
+// emitRequest emits an event of a request if there are observers.
+func (b *Breaker) emitRequest(typ EventType, err error, md *Metadata) {
+	if len(b.observers) == 0 {
+		return
+	}
+
+	counts := b.counts()
+	e := Event{Type: typ, Time: b.now(), Name: b.name, State: counts.State, Counts: counts, Err: err, b: b}
+	if md != nil {
+		e.Metadata = *md
+	}
+	b.emit(e)
+}
@@ -0,0 +1,80 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithObserver(t *testing.T) {
+	var events1, events2 []Event
+	o1 := ObserverFunc(func(e Event) { events1 = append(events1, e) })
+	o2 := ObserverFunc(func(e Event) { events2 = append(events2, e) })
+
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithName("payments"), WithObserver(o1), WithObserver(o2))
+	assert.NoError(t, err)
+
+	failed := errors.New("failed")
+	b.Execute(func() error { return failed })
+	b.Execute(func() error { return nil })
+
+	var types []EventType
+	for _, e := range events1 {
+		types = append(types, e.Type)
+		assert.Equal(t, "payments", e.Name)
+		assert.Equal(t, time.Unix(1520100000, 0), e.Time)
+	}
+	assert.Equal(t, []EventType{EventRequestAllowed, EventRequestFailed, EventStateChanged, EventRequestRejected}, types)
+	assert.Equal(t, events1, events2)
+
+	assert.Equal(t, StateClosed, events1[0].State)
+	assert.Equal(t, uint32(1), events1[0].Counts.Total)
+
+	assert.Equal(t, failed, events1[1].Err)
+	assert.Equal(t, uint32(1), events1[1].Counts.Failures)
+
+	assert.Equal(t, StateClosed, events1[2].From)
+	assert.Equal(t, StateOpen, events1[2].State)
+	assert.Equal(t, uint32(1), events1[2].Counts.Failures)
+
+	assert.Equal(t, StateOpen, events1[3].State)
+	assert.Equal(t, uint32(1), events1[3].Counts.Rejections)
+}
+
+func TestEvent_Snapshot(t *testing.T) {
+	var events []Event
+	observer := ObserverFunc(func(e Event) { events = append(events, e) })
+	toOpen := func(uint32, uint32) bool { return false }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000),
+		WithLatencyPercentile(0.5, time.Minute), WithObserver(observer))
+	assert.NoError(t, err)
+
+	b.Execute(func() error {
+		b.now = now(1520100002)
+		return nil
+	})
+	assert.Len(t, events, 1)
+	e := events[0]
+	assert.Equal(t, Latency{}, e.Counts.Latency, "not computed on every request")
+	assert.True(t, e.Snapshot().Latency.P99 >= 2*time.Second)
+
+	e = Event{Type: EventStateChanged, Counts: Counts{Total: 1}}
+	assert.Equal(t, e.Counts, e.Snapshot())
+}
+
+func TestEventType_String(t *testing.T) {
+	assert.Equal(t, "request-allowed", EventRequestAllowed.String())
+	assert.Equal(t, "request-rejected", EventRequestRejected.String())
+	assert.Equal(t, "request-failed", EventRequestFailed.String())
+	assert.Equal(t, "state-changed", EventStateChanged.String())
+	assert.Equal(t, "half-open-timeout", EventHalfOpenTimeout.String())
+	assert.Equal(t, "tuned", EventTuned.String())
+	assert.Equal(t, "request-bypassed", EventRequestBypassed.String())
+	assert.Equal(t, "expired", EventExpired.String())
+	assert.Equal(t, "unknown", EventType(42).String())
+}
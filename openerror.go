@@ -0,0 +1,57 @@
+package circuit
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// OpenError is the error returned when the circuit breaker doesn't accept a request,
+// errors.Is(err, ErrBreakerOpen) reports true for it:
+//
+//	var oe *circuit.OpenError
+//	if errors.As(err, &oe) && oe.RetryAfter > 0 {
+//		w.Header().Set("Retry-After", strconv.Itoa(int(oe.RetryAfter.Seconds())+1))
+//	}
+type OpenError struct {
+	Name       string        // name of the circuit breaker
+	State      State         // state of the circuit breaker when the request was rejected
+	RetryAfter time.Duration // time left until the cooldown ends, zero if unknown, e.g. half-open or forced open
+	Counts     Counts        // snapshot of the counters when the request was rejected, without Latency and FailuresByCategory
+}
+
+// Error returns the message of ErrBreakerOpen with the name and the time left if known.
+func (e *OpenError) Error() string {
+	msg := ErrBreakerOpen.Error()
+	if e.Name != "" {
+		msg = fmt.Sprintf("circuit: breaker %q open", e.Name)
+	}
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %v", e.RetryAfter)
+	}
+	return msg
+}
+
+// Is reports whether target is ErrBreakerOpen.
+func (e *OpenError) Is(target error) bool {
+	return target == ErrBreakerOpen
+}
+
+// openError returns the error of a rejected request.
+func (b *Breaker) openError() error {
+	// rejecting is the hot path while the dependency is down
+	counts := b.counts()
+	e := &OpenError{Name: b.name, State: counts.State, Counts: counts}
+	if counts.State == StateOpen && counts.Override != OverrideOpen {
+		e.RetryAfter = b.cooldownLeft()
+	}
+	return e
+}
+
+// cooldownLeft returns the time left until the current period ends, zero if it has.
+func (b *Breaker) cooldownLeft() time.Duration {
+	if left := atomic.LoadInt64(&b.until) - b.nanotime(); left > 0 {
+		return time.Duration(left)
+	}
+	return 0
+}
@@ -0,0 +1,46 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenError(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, 10*time.Second, 1, toOpen, toClosed, now(1520099998), WithName("payments"),
+		WithLatencyPercentile(0.5, time.Minute))
+	assert.NoError(t, err)
+
+	b.Execute(func() error {
+		b.now = now(1520100000)
+		return nil
+	})
+	assert.NotEqual(t, Latency{}, b.Counts().Latency)
+	b.Execute(func() error { return errors.New("failed") })
+	b.now = now(1520100004)
+	err = b.Execute(func() error { return nil })
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+	assert.EqualError(t, err, `circuit: breaker "payments" open, retry after 6s`)
+
+	var oe *OpenError
+	assert.True(t, errors.As(err, &oe))
+	assert.Equal(t, "payments", oe.Name)
+	assert.Equal(t, StateOpen, oe.State)
+	assert.Equal(t, 6*time.Second, oe.RetryAfter)
+	assert.Equal(t, uint32(1), oe.Counts.Rejections)
+	assert.Equal(t, Latency{}, oe.Counts.Latency, "not computed on every rejection")
+
+	// unknown while forced open
+	b.ForceOpen()
+	_, err = b.Allow()
+	assert.True(t, errors.As(err, &oe))
+	assert.Equal(t, time.Duration(0), oe.RetryAfter)
+	assert.Equal(t, OverrideOpen, oe.Counts.Override)
+
+	// without a name
+	assert.EqualError(t, &OpenError{State: StateHalfOpen}, "circuit: breaker open")
+}
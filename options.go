@@ -0,0 +1,57 @@
+package circuit
+
+// Option configures the optional behavior of the circuit breaker.
+type Option func(*Breaker)
+
+// WithName sets the name of the circuit breaker,
+// e.g. the name of the dependency it protects.
+func WithName(name string) Option {
+	return func(b *Breaker) {
+		b.name = name
+	}
+}
+
+// WithIgnoreContextErrors makes ExecuteContext not count an error as a failure
+// when the request's context is done by the time the request returns,
+// e.g. the caller cancelled it or its deadline exceeded.
+func WithIgnoreContextErrors() Option {
+	return WithContextErrorOutcome(OutcomeSuccess)
+}
+
+// WithContextErrorOutcome makes ExecuteContext and ExecuteHedged count a request
+// as a given outcome when the request's context is done by the time the request returns,
+// e.g. OutcomeIgnored not to count the caller's cancellations and deadlines
+// against the dependency at all, or OutcomeFailure to count them as usual.
+//
+// By default the error of such a request is counted as any other one.
+// A panic of the request is always a failure.
+func WithContextErrorOutcome(o Outcome) Option {
+	return func(b *Breaker) {
+		b.contextErrors = true
+		b.contextErrorOutcome = o
+	}
+}
+
+// WithShadowMode makes the circuit breaker never block the requests,
+// e.g. to validate the thresholds against the production traffic before enforcing them.
+// It changes the state and counts the rejections as usual, reporting them
+// to the observers and metrics, but runs the requests it would reject anyway
+// without counting their outcomes.
+func WithShadowMode() Option {
+	return func(b *Breaker) {
+		b.shadow = true
+	}
+}
+
+// WithOnStateChange registers a function called on every transition
+// of the circuit breaker with the counters of the finished period.
+//
+// It's called synchronously by the goroutine making the transition,
+// so it should return quickly.
+func WithOnStateChange(f func(from, to State, counts Counts)) Option {
+	return WithObserver(ObserverFunc(func(e Event) {
+		if e.Type == EventStateChanged {
+			f(e.From, e.State, e.Counts)
+		}
+	}))
+}
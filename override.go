@@ -0,0 +1,124 @@
+package circuit
+
+import "sync/atomic"
+
+// Override is a manual override of the circuit breaker's state.
+type Override int32
+
+const (
+	// OverrideNone lets the circuit breaker change its state by itself.
+	OverrideNone = Override(0)
+	// OverrideOpen keeps the circuit breaker open, set with ForceOpen.
+	OverrideOpen = Override(1)
+	// OverrideClosed keeps the circuit breaker closed, set with ForceClose.
+	OverrideClosed = Override(2)
+)
+
+// String returns the name of the override.
+func (o Override) String() string {
+	switch o {
+	case OverrideNone:
+		return "none"
+	case OverrideOpen:
+		return "forced-open"
+	case OverrideClosed:
+		return "forced-closed"
+	}
+	return "unknown"
+}
+
+// parseOverride returns the override by its name, OverrideNone if it's empty.
+func parseOverride(s string) (Override, bool) {
+	if s == "" {
+		return OverrideNone, true
+	}
+	for _, o := range []Override{OverrideNone, OverrideOpen, OverrideClosed} {
+		if o.String() == s {
+			return o, true
+		}
+	}
+	return 0, false
+}
+
+// ForceOpen moves the circuit breaker into the open state and keeps it there,
+// rejecting every request, until ClearOverride or ForceClose is called,
+// e.g. to shed the load off a dependency during an incident.
+func (b *Breaker) ForceOpen() {
+	atomic.StoreInt32(&b.override, int32(OverrideOpen))
+	b.force(open, b.cooldownNanos())
+}
+
+// ForceClose moves the circuit breaker into the closed state and keeps it there,
+// the requests are still counted but never open it,
+// until ClearOverride or ForceOpen is called.
+func (b *Breaker) ForceClose() {
+	atomic.StoreInt32(&b.override, int32(OverrideClosed))
+	b.force(closed, b.intervalNanos())
+}
+
+// ClearOverride lets the circuit breaker change its state by itself again
+// starting from the current one, a forced open circuit breaker
+// becomes half-open once its cooldown period elapses.
+func (b *Breaker) ClearOverride() {
+	atomic.StoreInt32(&b.override, int32(OverrideNone))
+}
+
+// Override returns the current manual override of the circuit breaker.
+func (b *Breaker) Override() Override {
+	return Override(atomic.LoadInt32(&b.override))
+}
+
+// force moves the circuit breaker into a given state for a period unless it's there already.
+func (b *Breaker) force(state int32, period int64) {
+	for {
+		// any state changes are done based on CompareAndSwap(word)
+		w := atomic.LoadUint64(&b.word)
+		if stateOf(w) == state {
+			return
+		}
+
+		now := b.nanotime()
+		if b.switchTo(state, w, now, now+period) {
+			return
+		}
+	}
+}
+
+// Trip opens the circuit breaker for the cooldown period unless it's open already,
+// as if the requests failed, e.g. when another instance of the application
+// found the dependency failing. Unlike ForceOpen it recovers as usual.
+// It does nothing while the state is forced with ForceClose or the circuit breaker is disabled.
+func (b *Breaker) Trip() {
+	for {
+		// any state changes are done based on CompareAndSwap(word)
+		w := atomic.LoadUint64(&b.word)
+		if stateOf(w) == open {
+			return
+		}
+
+		if b.trip(w, b.nanotime()) {
+			return
+		}
+		if atomic.LoadInt32(&b.override) == int32(OverrideClosed) || atomic.LoadInt32(&b.disabled) == 1 {
+			return
+		}
+	}
+}
+
+// Disable turns the circuit breaker into a pass-through until Enable is called,
+// e.g. to roll back misconfigured thresholds without a redeploy.
+// The requests are still counted, but never rejected
+// and the state is not changed regardless of the outcomes.
+func (b *Breaker) Disable() {
+	atomic.StoreInt32(&b.disabled, 1)
+}
+
+// Enable makes a disabled circuit breaker work again starting from the current state.
+func (b *Breaker) Enable() {
+	atomic.StoreInt32(&b.disabled, 0)
+}
+
+// Disabled reports whether the circuit breaker is disabled with Disable.
+func (b *Breaker) Disabled() bool {
+	return atomic.LoadInt32(&b.disabled) == 1
+}
@@ -0,0 +1,120 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverride_String(t *testing.T) {
+	assert.Equal(t, "none", OverrideNone.String())
+	assert.Equal(t, "forced-open", OverrideOpen.String())
+	assert.Equal(t, "forced-closed", OverrideClosed.String())
+	assert.Equal(t, "unknown", Override(3).String())
+}
+
+func TestBreaker_ForceOpen(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	b.ForceOpen()
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, OverrideOpen, b.Override())
+	assert.Equal(t, OverrideOpen, b.Counts().Override)
+	assert.ErrorIs(t, b.Execute(func() error { return nil }), ErrBreakerOpen)
+
+	// kept open after the cooldown period
+	b.now = now(1520100061)
+	assert.ErrorIs(t, b.Execute(func() error { return nil }), ErrBreakerOpen)
+	assert.Equal(t, StateOpen, b.State())
+
+	// half-open once cleared
+	b.ClearOverride()
+	assert.Equal(t, OverrideNone, b.Override())
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, StateHalfOpen, b.State())
+	assert.Equal(t, uint64(2), b.Counts().Transitions)
+}
+
+func TestBreaker_ForceClose(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+
+	b.ForceClose()
+	assert.Equal(t, StateClosed, b.State())
+	assert.Equal(t, OverrideClosed, b.Override())
+
+	// the failures are counted but don't open it
+	assert.EqualError(t, b.Execute(func() error { return errors.New("failed") }), "failed")
+	assert.EqualError(t, b.Execute(func() error { return errors.New("failed") }), "failed")
+	assert.Equal(t, StateClosed, b.State())
+	assert.Equal(t, uint32(2), b.Counts().Failures)
+
+	// opens on the next failure once cleared
+	b.ClearOverride()
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestBreaker_Disable(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+
+	b.Disable()
+	assert.True(t, b.Disabled())
+
+	// accepted and counted in the open state
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.EqualError(t, b.Execute(func() error { return errors.New("failed") }), "failed")
+	assert.Equal(t, StateOpen, b.State())
+	counts := b.Counts()
+	assert.Equal(t, uint32(2), counts.Total)
+	assert.Equal(t, uint32(1), counts.Failures)
+	assert.Equal(t, uint32(0), counts.Rejections)
+
+	// the failures don't open it
+	b.Reset()
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateClosed, b.State())
+
+	b.Enable()
+	assert.False(t, b.Disabled())
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+	assert.ErrorIs(t, b.Execute(func() error { return nil }), ErrBreakerOpen)
+}
+
+func TestBreaker_Trip(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, to, to, now(1520100000))
+	assert.NoError(t, err)
+
+	b.Trip()
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, OverrideNone, b.Override())
+	assert.Equal(t, int64(1520100120000000000), b.until)
+
+	// already open
+	b.now = now(1520100010)
+	b.Trip()
+	assert.Equal(t, int64(1520100120000000000), b.until)
+
+	// not while forced closed
+	b.ForceClose()
+	b.Trip()
+	assert.Equal(t, StateClosed, b.State())
+}
@@ -0,0 +1,56 @@
+package circuit
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error of a request which panicked,
+// returned by Execute when WithPanicAsError is used.
+type PanicError struct {
+	Value interface{} // the value passed to panic
+	Stack []byte      // the stack trace of the goroutine at the time of the panic
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("circuit: request panicked: %v", e.Value)
+}
+
+// WithPanicAsError makes the circuit breaker return a panic of the request
+// as *PanicError instead of resuming it.
+//
+// Either way the panic is recovered and counted as a failure first.
+func WithPanicAsError() Option {
+	return func(b *Breaker) {
+		b.panicAsError = true
+	}
+}
+
+// guard runs a given request returning its panic as *PanicError.
+func guard(req func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return req()
+}
+
+// guardContext does the same as guard passing ctx into the request,
+// sparing ExecuteContext a closure allocated for every request.
+func guardContext(ctx context.Context, req func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return req(ctx)
+}
+
+// repanic resumes the panic of the request unless WithPanicAsError is used.
+func (b *Breaker) repanic(err error) {
+	if pe, ok := err.(*PanicError); ok && !b.panicAsError {
+		panic(pe.Value)
+	}
+}
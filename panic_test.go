@@ -0,0 +1,43 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_Execute_Panic(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		b.Execute(func() error { panic("boom") })
+	})
+	assert.Equal(t, uint64(1), b.total)
+	assert.Equal(t, uint64(1), b.failures)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		Do(b, func() (int, error) { panic("boom") })
+	})
+	assert.Equal(t, open, b.loadState())
+}
+
+func TestBreaker_WithPanicAsError(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	isFailure := func(error) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithPanicAsError(), WithFailureClassifier(isFailure))
+	assert.NoError(t, err)
+
+	// a panic is a failure regardless of the failure classifier
+	err = b.Execute(func() error { panic("boom") })
+	assert.EqualError(t, err, "circuit: request panicked: boom")
+	pe, ok := err.(*PanicError)
+	assert.True(t, ok)
+	assert.Equal(t, "boom", pe.Value)
+	assert.NotEmpty(t, pe.Stack)
+	assert.Equal(t, open, b.loadState())
+}
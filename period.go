@@ -0,0 +1,55 @@
+package circuit
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// The state and the generation of the current period are packed into a single word,
+// so a transition is done by one CompareAndSwap of it and can't be observed halfway:
+//
+//	generation (61 bits) | settling (1 bit) | state (2 bits)
+//
+// The winner of the CompareAndSwap sets the settling bit while it resets the counters
+// and sets the end of the new period, the word is never swapped while it's set.
+const (
+	stateMask = 1<<2 - 1
+	settling  = 1 << 2
+	genShift  = 3
+)
+
+// pack returns the word of a given state and generation.
+func pack(state int32, gen uint64) uint64 {
+	return gen<<genShift | uint64(state)
+}
+
+// stateOf returns the state packed into a word.
+func stateOf(w uint64) int32 {
+	return int32(w & stateMask)
+}
+
+// genOf returns the generation packed into a word.
+func genOf(w uint64) uint64 {
+	return w >> genShift
+}
+
+// loadState returns the current state.
+func (b *Breaker) loadState() int32 {
+	return stateOf(atomic.LoadUint64(&b.word))
+}
+
+// loadGen returns the generation of the current period.
+func (b *Breaker) loadGen() uint64 {
+	return genOf(atomic.LoadUint64(&b.word))
+}
+
+// period returns the word and the end of the current period,
+// the end is reported as never reached while the period is settling,
+// as it's not set yet.
+func (b *Breaker) period() (uint64, int64) {
+	w := atomic.LoadUint64(&b.word)
+	if w&settling != 0 {
+		return w, math.MaxInt64
+	}
+	return w, atomic.LoadInt64(&b.until)
+}
@@ -0,0 +1,69 @@
+package circuit
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPack(t *testing.T) {
+	for _, state := range []int32{closed, halfOpen, open} {
+		w := pack(state, 42)
+		assert.Equal(t, state, stateOf(w))
+		assert.Equal(t, uint64(42), genOf(w))
+		assert.Zero(t, w&settling)
+	}
+}
+
+func TestBreaker_period(t *testing.T) {
+	to := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, to, to, now(1520100000))
+	assert.NoError(t, err)
+
+	w, until := b.period()
+	assert.Equal(t, pack(closed, 0), w)
+	assert.Equal(t, int64(1520100060000000000), until)
+
+	// the end of a period being set is never reached, nor the word swapped
+	b.word = pack(open, 1) | settling
+	w, until = b.period()
+	assert.Equal(t, int64(math.MaxInt64), until)
+	assert.False(t, b.switchTo(halfOpen, w, 1520100001000000000, 1520100061000000000))
+	assert.Equal(t, open, b.loadState())
+
+	// once settled, the cooldown elapsed moves it into the half-open state of the next generation
+	b.word = pack(open, 1)
+	b.now = now(1520100061)
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Equal(t, pack(halfOpen, 2), b.word)
+}
+
+func TestBreaker_switchToInParallel(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, to, to, now(1520100000))
+	assert.NoError(t, err)
+
+	// every generation is won by exactly one goroutine
+	var won uint64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				w := atomic.LoadUint64(&b.word)
+				if b.switchTo(int32(i%3), w, 1520100000000000000, 1520100060000000000) {
+					atomic.AddUint64(&won, 1)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, atomic.LoadUint64(&won), b.loadGen())
+	assert.Zero(t, atomic.LoadUint64(&b.word)&settling)
+}
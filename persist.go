@@ -0,0 +1,57 @@
+package circuit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// saved is the state of the circuit breaker written by SaveTo.
+type saved struct {
+	State    string    `json:"state"`
+	Until    time.Time `json:"until"`
+	Override string    `json:"override"`
+}
+
+// SaveTo writes the state of the circuit breaker, the end of its current period
+// and the override as JSON to w, to be restored with LoadFrom after a restart,
+// so a known-bad dependency isn't hammered by the fresh instance.
+func (b *Breaker) SaveTo(w io.Writer) error {
+	until := atomic.LoadInt64(&b.until)
+	return json.NewEncoder(w).Encode(saved{
+		State:    State(b.loadState()).String(),
+		Until:    time.Unix(0, until),
+		Override: b.Override().String(),
+	})
+}
+
+// LoadFrom restores the state of the circuit breaker written by SaveTo from r,
+// the counters start from zero. A saved open state with an elapsed cooldown period
+// becomes half-open on the next request as usual.
+func (b *Breaker) LoadFrom(r io.Reader) error {
+	var s saved
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return fmt.Errorf("circuit: decode saved state: %w", err)
+	}
+
+	state, ok := parseState(s.State)
+	if !ok {
+		return fmt.Errorf("circuit: unknown state %q", s.State)
+	}
+
+	override, ok := parseOverride(s.Override)
+	if !ok {
+		return fmt.Errorf("circuit: unknown override %q", s.Override)
+	}
+	atomic.StoreInt32(&b.override, int32(override))
+
+	for {
+		// any state changes are done based on CompareAndSwap(word)
+		w := atomic.LoadUint64(&b.word)
+		if b.switchTo(int32(state), w, b.nanotime(), s.Until.UnixNano()) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package circuit
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_SaveTo(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+
+	var buf bytes.Buffer
+	assert.NoError(t, b.SaveTo(&buf))
+
+	// restarted
+	restored, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100030))
+	assert.NoError(t, err)
+	assert.NoError(t, restored.LoadFrom(&buf))
+	assert.Equal(t, StateOpen, restored.State())
+	assert.Equal(t, b.until, restored.until)
+	assert.ErrorIs(t, restored.Execute(func() error { return nil }), ErrBreakerOpen)
+
+	// half-open after the cooldown period
+	restored.now = now(1520100061)
+	assert.NoError(t, restored.Execute(func() error { return nil }))
+	assert.Equal(t, StateHalfOpen, restored.State())
+}
+
+func TestBreaker_LoadFrom(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, to, to, now(1520100000))
+	assert.NoError(t, err)
+
+	err = b.LoadFrom(strings.NewReader(`{"state":"closed","until":"2018-03-03T18:01:00Z","override":"forced-closed"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, OverrideClosed, b.Override())
+
+	err = b.LoadFrom(strings.NewReader(`{`))
+	assert.Error(t, err)
+
+	err = b.LoadFrom(strings.NewReader(`{"state":"ajar"}`))
+	assert.EqualError(t, err, `circuit: unknown state "ajar"`)
+
+	err = b.LoadFrom(strings.NewReader(`{"state":"open","override":"maybe"}`))
+	assert.EqualError(t, err, `circuit: unknown override "maybe"`)
+}
@@ -0,0 +1,78 @@
+package circuit
+
+import (
+	"math"
+	"sync"
+)
+
+// RateThreshold returns a function for toOpen or toClosed
+// which returns true once the failure rate reaches a given rate
+// and there are at least minRequests in total.
+//
+// For toClosed negate it, e.g. close the circuit breaker
+// only if less than 10% of requests failed:
+//
+//	tooMany := circuit.RateThreshold(0.1, 1)
+//	toClosed := func(total, failures uint32) bool { return !tooMany(total, failures) }
+func RateThreshold(rate float64, minRequests uint32) ToState {
+	return func(total uint32, failures uint32) bool {
+		return total > 0 && total >= minRequests && float64(failures)/float64(total) >= rate
+	}
+}
+
+// WithConsecutiveFailures makes the circuit breaker open
+// after n requests failed in a row in the closed state regardless of toOpen,
+// any succeeded request resets the streak. It's ignored if n is zero.
+func WithConsecutiveFailures(n uint32) Option {
+	return func(b *Breaker) {
+		b.maxConsecutive = n
+	}
+}
+
+// WithMinimumVolume makes the circuit breaker never open
+// when fewer than n requests were observed in the interval (or the window),
+// regardless of toOpen and the consecutive failures,
+// so a single failed request can't open it at low traffic.
+func WithMinimumVolume(n uint32) Option {
+	return func(b *Breaker) {
+		b.minVolume = n
+	}
+}
+
+// EWMA returns a toOpen function tracking an exponentially weighted moving average
+// of the failure rate, every request moves it towards 1 (failure) or 0 (success)
+// by a given alpha in (0, 1]. It returns true once the average reaches the threshold,
+// giving a smoother behavior than the raw counts of the interval.
+//
+// The outcomes are derived from the growth of the counts between the calls,
+// the requests still in flight are taken as succeeded.
+// The returned function keeps its own state, so it must not be shared
+// between circuit breakers.
+func EWMA(alpha float64, threshold float64) ToState {
+	var (
+		mu                    sync.Mutex
+		rate                  float64
+		lastTotal, lastFailed uint32
+	)
+
+	return func(total uint32, failures uint32) bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if total < lastTotal || failures < lastFailed {
+			// the counters were reset
+			lastTotal, lastFailed = 0, 0
+		}
+
+		newTotal, newFailed := total-lastTotal, failures-lastFailed
+		lastTotal, lastFailed = total, failures
+
+		if newTotal > newFailed {
+			rate *= math.Pow(1-alpha, float64(newTotal-newFailed))
+		}
+		for i := uint32(0); i < newFailed; i++ {
+			rate = alpha + (1-alpha)*rate
+		}
+		return rate >= threshold
+	}
+}
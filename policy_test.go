@@ -0,0 +1,87 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateThreshold(t *testing.T) {
+	toOpen := RateThreshold(0.05, 10)
+	assert.False(t, toOpen(0, 0))
+	assert.False(t, toOpen(9, 9))
+	assert.False(t, toOpen(100, 4))
+	assert.True(t, toOpen(100, 5))
+	assert.True(t, toOpen(10, 10))
+
+	// no minimum
+	assert.False(t, RateThreshold(0.5, 0)(0, 0))
+	assert.True(t, RateThreshold(0.5, 0)(1, 1))
+}
+
+func TestEWMA(t *testing.T) {
+	toOpen := EWMA(0.5, 0.7)
+
+	// 1 failure: 0.5
+	assert.False(t, toOpen(1, 1))
+	// 1 success, 1 failure: 0.25 -> 0.625
+	assert.False(t, toOpen(3, 2))
+	// 1 failure: 0.8125
+	assert.True(t, toOpen(4, 3))
+
+	// the counters were reset, 2 successes and 1 failure: 0.203125 -> 0.6015625
+	assert.False(t, toOpen(3, 1))
+}
+
+func TestBreaker_EWMA(t *testing.T) {
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, EWMA(0.2, 0.5), toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		b.Execute(func() error { return errors.New("failed") })
+	}
+	assert.Equal(t, closed, b.loadState())
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState())
+}
+
+func TestBreaker_WithConsecutiveFailures(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return false }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithConsecutiveFailures(3))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, uint32(2), b.Counts().ConsecutiveFailures)
+
+	// a success resets the streak
+	b.Execute(func() error { return nil })
+	assert.Equal(t, uint32(0), b.Counts().ConsecutiveFailures)
+
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return errors.New("failed") })
+	// the streak continues into the next interval
+	b.now = now(1520100061)
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState())
+	assert.Equal(t, uint32(0), b.Counts().ConsecutiveFailures)
+}
+
+func TestBreaker_WithMinimumVolume(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithMinimumVolume(3), WithConsecutiveFailures(1))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, closed, b.loadState())
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState())
+}
@@ -0,0 +1,50 @@
+package circuit
+
+import "context"
+
+// Priority is the priority of a request, see WithPriorityShedding.
+type Priority int
+
+const (
+	// PriorityLow is for the requests which can be shed first, e.g. prefetching.
+	PriorityLow = Priority(-1)
+	// PriorityNormal is the priority of the requests without one.
+	PriorityNormal = Priority(0)
+	// PriorityHigh is for the requests which matter the most, e.g. checkout.
+	PriorityHigh = Priority(1)
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx carrying a given priority of the request
+// for ExecuteContext.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom returns the priority of the request carried by ctx,
+// PriorityNormal if there is none.
+func PriorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// WithPriorityShedding makes ExecuteContext reject the requests with a priority
+// below min in the half-open state, leaving the probes to the high priority traffic
+// while the dependency is recovering. The priority is set with WithPriority.
+func WithPriorityShedding(min Priority) Option {
+	return func(b *Breaker) {
+		b.shedding = true
+		b.minPriority = min
+	}
+}
+
+// shed reports whether a request with the priority carried by ctx is shed.
+func (b *Breaker) shed(ctx context.Context) bool {
+	if !b.shedding || b.loadState() != halfOpen {
+		return false
+	}
+	return PriorityFrom(ctx) < b.minPriority
+}
@@ -0,0 +1,51 @@
+package circuit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityFrom(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, PriorityNormal, PriorityFrom(ctx))
+	assert.Equal(t, PriorityHigh, PriorityFrom(WithPriority(ctx, PriorityHigh)))
+}
+
+func TestBreaker_WithPriorityShedding(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return true }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 2, toOpen, toClosed, now(1520100000), WithPriorityShedding(PriorityHigh))
+	assert.NoError(t, err)
+
+	req := func(context.Context) error { return nil }
+	low := WithPriority(context.Background(), PriorityLow)
+	high := WithPriority(context.Background(), PriorityHigh)
+
+	// not shed in the closed state
+	assert.NoError(t, b.ExecuteContext(low, req))
+
+	b.ForceOpen()
+	b.ClearOverride()
+	b.now = now(1520100061)
+
+	// the first request moves it into the half-open state
+	assert.NoError(t, b.ExecuteContext(high, req))
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	// the low and normal priority requests are shed
+	assert.ErrorIs(t, b.ExecuteContext(low, req), ErrBreakerOpen)
+	assert.ErrorIs(t, b.ExecuteContext(context.Background(), req), ErrBreakerOpen)
+	assert.NoError(t, b.ExecuteContext(high, req))
+
+	counts := b.Counts()
+	assert.Equal(t, uint32(2), counts.Total)
+	assert.Equal(t, uint32(2), counts.Rejections)
+
+	// closed by the next high priority request
+	assert.NoError(t, b.ExecuteContext(high, req))
+	assert.Equal(t, StateClosed, b.State())
+	assert.NoError(t, b.ExecuteContext(low, req))
+}
@@ -0,0 +1,38 @@
+package circuit
+
+import "sync/atomic"
+
+// WithMaxHalfOpenProbes makes the circuit breaker admit at most n requests
+// in flight at once in the half-open state, the others are rejected
+// with ErrBreakerOpen until the probes return.
+// Without it all the concurrent requests may pass before any outcome is recorded,
+// overrunning atLeastReqs. It's ignored if n is zero.
+func WithMaxHalfOpenProbes(n uint32) Option {
+	return func(b *Breaker) {
+		b.maxProbes = n
+	}
+}
+
+// acquireProbe admits a request in the half-open state if there is a room for a probe.
+func (b *Breaker) acquireProbe() (ok bool, probe bool) {
+	if b.maxProbes == 0 {
+		return true, false
+	}
+
+	for {
+		n := atomic.LoadUint32(&b.probes)
+		if n >= b.maxProbes {
+			return false, false
+		}
+		if atomic.CompareAndSwapUint32(&b.probes, n, n+1) {
+			return true, true
+		}
+	}
+}
+
+// release frees the room of a probe once its outcome is known.
+func (b *Breaker) release(probe bool) {
+	if probe {
+		atomic.AddUint32(&b.probes, ^uint32(0))
+	}
+}
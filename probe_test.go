@@ -0,0 +1,49 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithMaxHalfOpenProbes(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return true }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 5, toOpen, toClosed, now(1520100000), WithMaxHalfOpenProbes(2))
+	assert.NoError(t, err)
+
+	b.ForceOpen()
+	b.ClearOverride()
+	b.now = now(1520100061)
+
+	// two probes in flight
+	tok1, err := b.Allow()
+	assert.NoError(t, err)
+	assert.Equal(t, halfOpen, b.loadState())
+	tok2, err := b.Allow()
+	assert.NoError(t, err)
+
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+	assert.ErrorIs(t, b.Execute(func() error { return nil }), ErrBreakerOpen)
+
+	// a room for another probe once one returned
+	tok1.Success()
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	tok2.Ignore()
+	assert.Equal(t, uint32(0), b.probes)
+
+	counts := b.Counts()
+	assert.Equal(t, uint32(2), counts.Total)
+	assert.Equal(t, uint32(2), counts.Successes)
+	assert.Equal(t, uint32(2), counts.Rejections)
+
+	// no limit in the closed state
+	b.Reset()
+	tok1, _ = b.Allow()
+	tok2, _ = b.Allow()
+	_, err = b.Allow()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), b.probes)
+}
@@ -0,0 +1,58 @@
+package circuit
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithRampUp makes the circuit breaker recover gradually instead of letting
+// all the traffic through at once once it's closed after the half-open state,
+// protecting a fragile dependency from the thundering herd.
+//
+// It admits a given fraction of the requests for every step of the schedule
+// in turn, e.g. WithRampUp(10*time.Second, 0.1, 0.25, 0.5) admits 10% of the requests
+// for the first 10 seconds, then 25%, then 50% and all of them after 30 seconds.
+// The others are rejected with ErrBreakerOpen. The failures of the admitted requests
+// open the circuit breaker as usual.
+//
+// The schedule of 10%, 25% and 50% is used if no fractions are given.
+// NewBreaker returns an error if the step isn't positive.
+func WithRampUp(step time.Duration, fractions ...float64) Option {
+	if len(fractions) == 0 {
+		fractions = []float64{0.1, 0.25, 0.5}
+	}
+
+	return func(b *Breaker) {
+		b.rampStep = step.Nanoseconds()
+		b.rampFractions = fractions
+	}
+}
+
+// startRamp starts ramping the traffic up at now if it's configured.
+func (b *Breaker) startRamp(now int64) {
+	if len(b.rampFractions) == 0 {
+		return
+	}
+	atomic.StoreUint64(&b.rampSeen, 0)
+	atomic.StoreInt64(&b.rampStart, now)
+}
+
+// rampAdmit reports whether a request is admitted at now,
+// it's spreading the admitted requests evenly by counting them.
+func (b *Breaker) rampAdmit(now int64) bool {
+	start := atomic.LoadInt64(&b.rampStart)
+	if start == 0 {
+		return true
+	}
+
+	step := int((now - start) / b.rampStep)
+	if step >= len(b.rampFractions) {
+		// ramped up
+		atomic.CompareAndSwapInt64(&b.rampStart, start, 0)
+		return true
+	}
+
+	f := b.rampFractions[step]
+	k := float64(atomic.AddUint64(&b.rampSeen, 1) - 1)
+	return uint64((k+1)*f) > uint64(k*f)
+}
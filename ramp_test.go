@@ -0,0 +1,64 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithRampUp(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithRampUp(10*time.Second, 0.25, 0.5))
+	assert.NoError(t, err)
+
+	admitted := func(n int) int {
+		var k int
+		for i := 0; i < n; i++ {
+			if b.Execute(func() error { return nil }) == nil {
+				k++
+			}
+		}
+		return k
+	}
+
+	// not ramping up until closed after the half-open state
+	assert.Equal(t, 8, admitted(8))
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState())
+
+	// probe in the half-open state, closed by the next request
+	b.now = now(1520100061)
+	assert.Equal(t, 1, admitted(1))
+	assert.Equal(t, 2, admitted(8))
+	assert.Equal(t, closed, b.loadState())
+
+	b.now = now(1520100071)
+	assert.Equal(t, 4, admitted(8))
+
+	// ramped up
+	b.now = now(1520100081)
+	assert.Equal(t, 8, admitted(8))
+	assert.Equal(t, int64(0), b.rampStart)
+
+	// reopened by a failure while ramping up
+	b.startRamp(1520100081000000000)
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState())
+	assert.Equal(t, int64(0), b.rampStart)
+}
+
+func TestBreaker_WithRampUp_Step(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return false }
+	toClosed := func(uint32, uint32) bool { return true }
+	_, err := NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed, WithRampUp(0))
+	assert.EqualError(t, err, "circuit: ramp-up step must be positive")
+	_, err = NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed, WithRampUp(-time.Second, 0.5))
+	assert.EqualError(t, err, "circuit: ramp-up step must be positive")
+}
@@ -0,0 +1,90 @@
+package circuit
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Registry is a set of circuit breakers looked up by name,
+// a central place to find them for metrics exporters and admin endpoints.
+type Registry struct {
+	mu       sync.RWMutex
+	breakers map[string]*Breaker
+}
+
+// NewRegistry returns a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{breakers: make(map[string]*Breaker)}
+}
+
+// NewBreaker returns a new circuit breaker with a given name registered,
+// see NewBreaker for the arguments.
+//
+// Returns an error if a circuit breaker with the name is already registered.
+func (r *Registry) NewBreaker(name string, interval time.Duration, cooldown time.Duration, atLeastReqs uint32, toOpen ToState, toClosed ToState, opts ...Option) (*Breaker, error) {
+	opts = append([]Option{WithName(name)}, opts...)
+	b, err := NewBreaker(interval, cooldown, atLeastReqs, toOpen, toClosed, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Register(name, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Register adds a given circuit breaker under the name.
+//
+// Returns an error if a circuit breaker with the name is already registered.
+func (r *Registry) Register(name string, b *Breaker) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.breakers[name]; ok {
+		return fmt.Errorf("circuit: breaker %q already registered", name)
+	}
+	r.breakers[name] = b
+	return nil
+}
+
+// Unregister removes the circuit breaker with a given name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	delete(r.breakers, name)
+	r.mu.Unlock()
+}
+
+// Get returns the circuit breaker with a given name,
+// ok is false if there is no such circuit breaker.
+func (r *Registry) Get(name string) (b *Breaker, ok bool) {
+	r.mu.RLock()
+	b, ok = r.breakers[name]
+	r.mu.RUnlock()
+	return b, ok
+}
+
+// Names returns the sorted names of the registered circuit breakers.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.breakers))
+	for name := range r.breakers {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// Each calls f for every registered circuit breaker in the order of names.
+// It's safe to use the registry within f.
+func (r *Registry) Each(f func(name string, b *Breaker)) {
+	for _, name := range r.Names() {
+		if b, ok := r.Get(name); ok {
+			f(name, b)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	r := NewRegistry()
+
+	payments, err := r.NewBreaker("payments", time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+	assert.Equal(t, "payments", payments.Name())
+
+	_, err = r.NewBreaker("payments", time.Minute, time.Minute, 1, to, to)
+	assert.EqualError(t, err, `circuit: breaker "payments" already registered`)
+
+	_, err = r.NewBreaker("invalid", 0, time.Minute, 1, to, to)
+	assert.EqualError(t, err, "circuit: interval must be set")
+
+	accounts, err := NewBreaker(time.Minute, time.Minute, 1, to, to)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Register("accounts", accounts))
+
+	b, ok := r.Get("payments")
+	assert.True(t, ok)
+	assert.Equal(t, payments, b)
+
+	_, ok = r.Get("invalid")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"accounts", "payments"}, r.Names())
+
+	var names []string
+	r.Each(func(name string, b *Breaker) {
+		names = append(names, name)
+	})
+	assert.Equal(t, []string{"accounts", "payments"}, names)
+
+	r.Unregister("accounts")
+	assert.Equal(t, []string{"payments"}, r.Names())
+}
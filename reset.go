@@ -0,0 +1,29 @@
+package circuit
+
+import "sync/atomic"
+
+// Reset returns the circuit breaker into the closed state
+// with zeroed counters and a fresh interval, clearing the override,
+// e.g. once the dependency is known to be fixed.
+// The lifetime counters are kept.
+func (b *Breaker) Reset() {
+	atomic.StoreInt32(&b.override, int32(OverrideNone))
+
+	for {
+		// any state changes are done based on CompareAndSwap(word)
+		w := atomic.LoadUint64(&b.word)
+		now := b.nanotime()
+		if b.switchTo(closed, w, now, now+b.intervalNanos()) {
+			atomic.StoreUint32(&b.consecutive, 0)
+			atomic.StoreUint32(&b.opens, 0)
+			atomic.StoreInt64(&b.rampStart, 0)
+			if b.window != nil {
+				b.window.reset(now)
+			}
+			if b.multiWindow != nil {
+				b.resetMultiWindow(now)
+			}
+			return
+		}
+	}
+}
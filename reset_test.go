@@ -0,0 +1,42 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_Reset(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(uint32, uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithCountWindow(10))
+	assert.NoError(t, err)
+
+	// closed with a failure in the window
+	b.Execute(func() error { return errors.New("failed") })
+	b.now = now(1520100010)
+	b.Reset()
+	assert.Equal(t, StateClosed, b.State())
+	assert.Equal(t, int64(1520100070000000000), b.until)
+	counts := b.Counts()
+	assert.Equal(t, uint32(0), counts.Total)
+	assert.Equal(t, uint32(0), counts.Failures)
+	assert.Equal(t, uint32(0), counts.ConsecutiveFailures)
+	assert.Equal(t, time.Unix(1520100010, 0), counts.WindowStart)
+	assert.Equal(t, uint64(1), counts.LifetimeFailures)
+
+	// the failure before the reset doesn't count
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateClosed, b.State())
+
+	// open and forced
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+	b.ForceOpen()
+	b.Reset()
+	assert.Equal(t, StateClosed, b.State())
+	assert.Equal(t, OverrideNone, b.Override())
+	assert.NoError(t, b.Execute(func() error { return nil }))
+}
@@ -0,0 +1,62 @@
+package circuit
+
+import (
+	"errors"
+	"time"
+)
+
+// RetryPolicy is the policy of retrying the failed requests in Retry.
+type RetryPolicy struct {
+	MaxAttempts int                             // # of attempts including the first one
+	Backoff     func(attempt int) time.Duration // delay after a given failed attempt (1-based), none if nil
+}
+
+// ExponentialBackoff returns a backoff doubling the delay after every attempt
+// starting from base, capped by max.
+func ExponentialBackoff(base time.Duration, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// Retry runs a given request through the circuit breaker like Execute does,
+// retrying it with the policy's backoff while it fails.
+//
+// It stops without waiting once the circuit breaker is open, returning the last error,
+// ErrBreakerOpen is returned if the circuit breaker rejected the request
+// and is never retried, so the rejections are not counted as more failures.
+// Only the errors counted as failures by the circuit breaker are retried.
+func Retry(b *Breaker, policy RetryPolicy, req func() error) error {
+	return policy.retry(func() error { return b.Execute(req) }, func(err error) bool {
+		return b.failed(err) && b.State() != StateOpen
+	}, b.after)
+}
+
+// Execute runs a given request retrying it with the backoff while it fails,
+// ErrBreakerOpen is never retried. It makes RetryPolicy a Policy for Chain,
+// placed outside of the circuit breaker.
+func (p RetryPolicy) Execute(req func() error) error {
+	return p.retry(req, func(error) bool { return true }, time.After)
+}
+
+// retry runs a given request until it succeeds, the attempts are exhausted
+// or it fails with an error which is not retryable, waiting for the backoff by after.
+func (p RetryPolicy) retry(req func() error, retryable func(error) bool, after func(time.Duration) <-chan time.Time) error {
+	for attempt := 1; ; attempt++ {
+		err := req()
+		if err == nil || errors.Is(err, ErrBreakerOpen) || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		if p.Backoff != nil {
+			<-after(p.Backoff(attempt))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	assert.Equal(t, 10*time.Millisecond, backoff(1))
+	assert.Equal(t, 20*time.Millisecond, backoff(2))
+	assert.Equal(t, 40*time.Millisecond, backoff(3))
+	assert.Equal(t, 50*time.Millisecond, backoff(4))
+}
+
+func TestRetry(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 2 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	policy := RetryPolicy{MaxAttempts: 5, Backoff: ExponentialBackoff(time.Microsecond, time.Millisecond)}
+
+	// succeeded on the second attempt
+	var attempts int
+	err = Retry(b, policy, func() error {
+		attempts++
+		if attempts < 2 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	// stopped once the circuit breaker opened
+	attempts = 0
+	err = Retry(b, policy, func() error { attempts++; return errors.New("failed") })
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, StateOpen, b.State())
+
+	// rejected, not retried
+	assert.ErrorIs(t, Retry(b, policy, func() error { return nil }), ErrBreakerOpen)
+	assert.Equal(t, uint32(1), b.Counts().Rejections)
+}
+
+func TestRetry_MaxAttempts(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return false }
+	toClosed := func(uint32, uint32) bool { return true }
+	isFailure := func(err error) bool { return err.Error() != "not found" }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithFailureClassifier(isFailure))
+	assert.NoError(t, err)
+
+	var attempts int
+	err = Retry(b, RetryPolicy{MaxAttempts: 3}, func() error { attempts++; return errors.New("failed") })
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, 3, attempts)
+
+	// not a failure, not retried
+	attempts = 0
+	err = Retry(b, RetryPolicy{MaxAttempts: 3}, func() error { attempts++; return errors.New("not found") })
+	assert.EqualError(t, err, "not found")
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,117 @@
+package circuit
+
+import (
+	"math"
+	"math/bits"
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+)
+
+// WithShardedCounters spreads the counters of the interval over n stripes,
+// every request updates a random one, which cuts the contention on them
+// for the circuit breakers serving hundreds of thousands of requests per second.
+//
+// The stripes are summed up whenever the circuit breaker makes a decision
+// or the counts are read, which makes those slower, so it doesn't pay off
+// for a moderate load. The number of CPUs is used if n is zero.
+func WithShardedCounters(n int) Option {
+	return func(b *Breaker) {
+		if n <= 0 {
+			n = runtime.NumCPU()
+		}
+		b.shards = make([]shard, n)
+	}
+}
+
+// shard is a stripe of the counters of the interval.
+type shard struct {
+	lifetimeTotal uint64
+	total         uint64
+	failures      uint64
+	successes     uint64
+	_             [64 - 32]byte // keeps the stripes on separate cache lines
+}
+
+// shard returns a random stripe of the counters.
+func (b *Breaker) shard() *shard {
+	return &b.shards[rand.Intn(len(b.shards))]
+}
+
+// addTotal adds delta, 1 or -1, to the number of requests in total
+// during the interval and since the creation.
+func (b *Breaker) addTotal(delta int32) {
+	total, lifetimeTotal := &b.total, &b.lifetimeTotal
+	if b.shards != nil {
+		s := b.shard()
+		total, lifetimeTotal = &s.total, &s.lifetimeTotal
+	}
+	atomic.AddUint64(total, uint64(delta))
+	atomic.AddUint64(lifetimeTotal, uint64(delta))
+}
+
+// addFailure counts a failed request during the interval.
+func (b *Breaker) addFailure() {
+	failures := &b.failures
+	if b.shards != nil {
+		failures = &b.shard().failures
+	}
+	atomic.AddUint64(failures, 1)
+}
+
+// addSuccess counts a succeeded request during the interval.
+func (b *Breaker) addSuccess() {
+	successes := &b.successes
+	if b.shards != nil {
+		successes = &b.shard().successes
+	}
+	atomic.AddUint64(successes, 1)
+}
+
+// counters returns the number of requests in total, failed and succeeded during the interval.
+//
+// They're counted in 64 bits and scaled down together once any exceeds math.MaxUint32
+// instead of wrapping around, so the policies still get the right failure rate.
+func (b *Breaker) counters() (total uint32, failures uint32, successes uint32) {
+	t := atomic.LoadUint64(&b.total)
+	f := atomic.LoadUint64(&b.failures)
+	s := atomic.LoadUint64(&b.successes)
+
+	// a decrement of Token.Ignore may wrap a stripe around, the sum is still right
+	for i := range b.shards {
+		sh := &b.shards[i]
+		t += atomic.LoadUint64(&sh.total)
+		f += atomic.LoadUint64(&sh.failures)
+		s += atomic.LoadUint64(&sh.successes)
+	}
+
+	if n := max(t, f, s); n > math.MaxUint32 {
+		shift := bits.Len64(n) - 32
+		t, f, s = t>>shift, f>>shift, s>>shift
+	}
+	return uint32(t), uint32(f), uint32(s)
+}
+
+// loadLifetimeTotal returns the number of requests in total since the creation.
+func (b *Breaker) loadLifetimeTotal() uint64 {
+	n := atomic.LoadUint64(&b.lifetimeTotal)
+	for i := range b.shards {
+		n += atomic.LoadUint64(&b.shards[i].lifetimeTotal)
+	}
+	return n
+}
+
+// resetCounters zeroes the counters of the interval.
+func (b *Breaker) resetCounters() {
+	atomic.StoreUint64(&b.failures, 0)
+	atomic.StoreUint64(&b.successes, 0)
+	atomic.StoreUint64(&b.total, 0)
+	atomic.StoreUint64(&b.failureScore, 0)
+	for i := range b.shards {
+		s := &b.shards[i]
+		atomic.StoreUint64(&s.failures, 0)
+		atomic.StoreUint64(&s.successes, 0)
+		atomic.StoreUint64(&s.total, 0)
+	}
+	b.resetCategories()
+}
@@ -0,0 +1,98 @@
+package circuit
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithShardedCounters(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return total >= 100 && failures*2 >= total }
+	toClosed := func(total uint32, failures uint32) bool { return failures == 0 }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithShardedCounters(4))
+	assert.NoError(t, err)
+	assert.Len(t, b.shards, 4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				b.Execute(func() error { return nil })
+			}
+		}()
+	}
+	wg.Wait()
+
+	// summed up over the stripes
+	counts := b.Counts()
+	assert.Equal(t, uint32(80), counts.Total)
+	assert.Equal(t, uint32(80), counts.Successes)
+
+	tok, _ := b.Allow()
+	tok.Ignore()
+	counts = b.Counts()
+	assert.Equal(t, uint32(80), counts.Total)
+	assert.Equal(t, uint64(80), counts.LifetimeTotal)
+
+	for i := 0; i < 80; i++ {
+		b.Execute(func() error { return errors.New("failed") })
+	}
+	assert.Equal(t, StateOpen, b.State())
+
+	// reset on the transition
+	counts = b.Counts()
+	assert.Equal(t, uint32(0), counts.Total)
+	assert.Equal(t, uint32(0), counts.Failures)
+	assert.Equal(t, uint64(160), counts.LifetimeTotal)
+}
+
+func TestWithShardedCounters_NumCPU(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	b, err := NewBreaker(time.Minute, time.Minute, 1, to, to, WithShardedCounters(0))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, b.shards)
+}
+
+func BenchmarkBreaker_ExecuteSharded(b *testing.B) {
+	toOpen := func(total uint32, failures uint32) bool { return false }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	cb, err := NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed, WithShardedCounters(0))
+	assert.NoError(b, err)
+	req := func() error { return nil }
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cb.Execute(req)
+		}
+	})
+}
+
+func TestBreaker_counters(t *testing.T) {
+	var got [2]uint32
+	toOpen := func(total uint32, failures uint32) bool {
+		got = [2]uint32{total, failures}
+		return RateThreshold(0.5, 1)(total, failures)
+	}
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	// past uint32, scaled down keeping the failure rate instead of wrapping around
+	b.total = 3 << 32
+	b.failures = 3<<31 - 1
+	b.successes = 3 << 31
+	total, failures, successes := b.counters()
+	assert.Equal(t, uint32(3<<30), total)
+	assert.Equal(t, uint32(3<<29-1), failures)
+	assert.Equal(t, uint32(3<<29), successes)
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, [2]uint32{3 << 30, 3 << 29}, got)
+	assert.Equal(t, StateOpen, b.State())
+}
@@ -0,0 +1,92 @@
+package circuit
+
+import (
+	"sync"
+	"time"
+)
+
+// SLO is the reliability target of NewSLOPolicy.
+type SLO struct {
+	Objective   float64       // fraction of the requests to succeed, e.g. 0.999
+	Window      time.Duration // period of the error budget, e.g. 30 days
+	BurnRate    float64       // burn rate of the interval to open at, e.g. 14.4
+	MinRequests uint32        // # of requests in the interval to judge it
+}
+
+// SLOPolicy is a TripPolicy opening the circuit breaker by the burn rate
+// of the error budget of an SLO, see NewSLOPolicy.
+type SLOPolicy struct {
+	slo SLO
+
+	mu       sync.Mutex
+	start    time.Time // start of the current budget window
+	total    uint64    // # of the requests during the budget window
+	failures uint64    // # of the failures during the budget window
+}
+
+// NewSLOPolicy returns a policy for WithTripPolicy opening the circuit breaker
+// once the failures of the interval burn the error budget of the SLO,
+// 1 - Objective of the requests, at BurnRate times the sustainable rate or faster,
+// e.g. 14.4 spends 2% of a 30 days budget in an hour:
+//
+//	circuit.WithTripPolicy(circuit.NewSLOPolicy(circuit.SLO{Objective: 0.999, Window: 30 * 24 * time.Hour, BurnRate: 14.4, MinRequests: 100}))
+//
+// Once the budget of the window is exhausted, any burn rate of 1 or above opens it.
+// The budget counts the requests and failures the policy receives as an Observer,
+// it's renewed every Window. The policy must not be shared between circuit breakers.
+func NewSLOPolicy(slo SLO) *SLOPolicy {
+	return &SLOPolicy{slo: slo}
+}
+
+// ShouldTrip implements TripPolicy.
+func (p *SLOPolicy) ShouldTrip(s Snapshot) bool {
+	if s.Total == 0 || s.Total < p.slo.MinRequests {
+		return false
+	}
+
+	burn := p.burnRate(uint64(s.Total), uint64(s.Failures))
+	if burn >= p.slo.BurnRate {
+		return true
+	}
+	return burn >= 1 && p.Budget() <= 0
+}
+
+// Budget returns the fraction of the error budget left in the current window,
+// 1 if there were no requests and negative once it's overspent.
+func (p *SLOPolicy) Budget() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.total == 0 {
+		return 1
+	}
+	return 1 - p.burnRate(p.total, p.failures)
+}
+
+// burnRate returns how many times faster than sustainable the failures spend the budget.
+func (p *SLOPolicy) burnRate(total, failures uint64) float64 {
+	budget := 1 - p.slo.Objective
+	if budget <= 0 {
+		budget = 1e-9
+	}
+	return float64(failures) / float64(total) / budget
+}
+
+// Observe implements Observer, it counts the requests against the budget.
+func (p *SLOPolicy) Observe(e Event) {
+	if e.Type != EventRequestAllowed && e.Type != EventRequestFailed {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.start.IsZero() || (p.slo.Window > 0 && e.Time.Sub(p.start) >= p.slo.Window) {
+		p.start, p.total, p.failures = e.Time, 0, 0
+	}
+	if e.Type == EventRequestAllowed {
+		p.total++
+	} else {
+		p.failures++
+	}
+}
@@ -0,0 +1,49 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSLOPolicy_ShouldTrip(t *testing.T) {
+	p := NewSLOPolicy(SLO{Objective: 0.9, Window: 100 * time.Second, BurnRate: 5, MinRequests: 10})
+	assert.True(t, p.ShouldTrip(Snapshot{Total: 10, Failures: 5}))
+	assert.False(t, p.ShouldTrip(Snapshot{Total: 10, Failures: 4}))
+	assert.False(t, p.ShouldTrip(Snapshot{Total: 9, Failures: 9}), "too few requests")
+	assert.Equal(t, 1.0, p.Budget())
+
+	for i := 0; i < 10; i++ {
+		p.Observe(Event{Type: EventRequestAllowed, Time: time.Unix(1520100000, 0)})
+	}
+	p.Observe(Event{Type: EventRequestFailed, Time: time.Unix(1520100000, 0)})
+	assert.InDelta(t, 0.0, p.Budget(), 1e-9)
+	assert.True(t, p.ShouldTrip(Snapshot{Total: 10, Failures: 1}), "the budget is exhausted")
+	assert.False(t, p.ShouldTrip(Snapshot{Total: 20, Failures: 1}))
+
+	p.Observe(Event{Type: EventRequestAllowed, Time: time.Unix(1520100100, 0)})
+	assert.Equal(t, 1.0, p.Budget(), "renewed")
+	assert.False(t, p.ShouldTrip(Snapshot{Total: 10, Failures: 1}))
+}
+
+func TestBreaker_WithTripPolicy_SLO(t *testing.T) {
+	p := NewSLOPolicy(SLO{Objective: 0.9, Window: time.Hour, BurnRate: 5, MinRequests: 10})
+	toOpen := func(total uint32, failures uint32) bool { panic("replaced by the policy") }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := withTimeNow(10*time.Second, time.Minute, 1, toOpen, toClosed, now(1520100000), WithTripPolicy(p))
+	assert.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		b.Execute(func() error { return nil })
+	}
+	for i := 0; i < 4; i++ {
+		b.Execute(func() error { return errors.New("failed") })
+	}
+	assert.Equal(t, closed, b.loadState())
+	assert.InDelta(t, -3.44, p.Budget(), 0.01)
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState(), "burns at 5x")
+}
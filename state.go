@@ -0,0 +1,82 @@
+package circuit
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// State is the state of the circuit breaker.
+type State int32
+
+const (
+	// StateClosed allows the requests from the application to pass.
+	StateClosed = State(closed)
+	// StateHalfOpen allows a limited number of requests to pass.
+	StateHalfOpen = State(halfOpen)
+	// StateOpen fails the requests immediately with ErrBreakerOpen.
+	StateOpen = State(open)
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// parseState returns the state by its name.
+func parseState(s string) (State, bool) {
+	for _, st := range []State{StateClosed, StateHalfOpen, StateOpen} {
+		if st.String() == s {
+			return st, true
+		}
+	}
+	return 0, false
+}
+
+// State returns the current state of the circuit breaker.
+//
+// The state is changed lazily by the incoming requests,
+// so an open circuit breaker with an elapsed cooldown period
+// stays open until the next request.
+// A state forced with ForceOpen or ForceClose is kept until ClearOverride.
+func (b *Breaker) State() State {
+	b.expireHalfOpen()
+	return State(b.loadState())
+}
+
+// NextTransition returns when the current period of the circuit breaker ends:
+// the interval in the closed state, the cooldown in the open one
+// and the half-open period otherwise.
+// The state is changed lazily, by the first request after that time.
+//
+// Returns the zero time when the state is forced or the circuit breaker is disabled,
+// since it doesn't change by itself then.
+func (b *Breaker) NextTransition() time.Time {
+	if atomic.LoadInt32(&b.override) != int32(OverrideNone) || atomic.LoadInt32(&b.disabled) == 1 {
+		return time.Time{}
+	}
+	return time.Unix(0, atomic.LoadInt64(&b.until))
+}
+
+// RetryAfter returns how long until the circuit breaker accepts requests again,
+// the time left until the cooldown ends, zero unless it's open,
+// so callers can schedule a retry instead of being rejected meanwhile.
+//
+// A circuit breaker forced open with ForceOpen has no end of the cooldown,
+// the cooldown period is returned as a hint when to check again.
+func (b *Breaker) RetryAfter() time.Duration {
+	if atomic.LoadInt32(&b.disabled) == 1 || b.loadState() != open {
+		return 0
+	}
+	if atomic.LoadInt32(&b.override) == int32(OverrideOpen) {
+		return time.Duration(b.cooldownNanos())
+	}
+	return b.cooldownLeft()
+}
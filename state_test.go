@@ -0,0 +1,96 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "half-open", StateHalfOpen.String())
+	assert.Equal(t, "open", StateOpen.String())
+	assert.Equal(t, "unknown", State(42).String())
+}
+
+func TestBreaker_State(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+	assert.Equal(t, StateClosed, b.State())
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+
+	// after cooldown period
+	b.now = now(1520100121)
+	b.Execute(func() error { return nil })
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	b.Execute(func() error { return nil })
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_RetryAfter(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Unix(1520100060, 0), b.NextTransition())
+	assert.Equal(t, time.Duration(0), b.RetryAfter())
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, time.Unix(1520100120, 0), b.NextTransition())
+	assert.Equal(t, 2*time.Minute, b.RetryAfter())
+
+	b.now = now(1520100090)
+	assert.Equal(t, 30*time.Second, b.RetryAfter())
+
+	// the cooldown elapsed, accepted by the next request
+	b.now = now(1520100121)
+	assert.Equal(t, time.Duration(0), b.RetryAfter())
+
+	// no end of the cooldown when forced
+	b.ForceOpen()
+	assert.Equal(t, time.Time{}, b.NextTransition())
+	assert.Equal(t, 2*time.Minute, b.RetryAfter())
+}
+
+func TestBreaker_WithOnStateChange(t *testing.T) {
+	type transition struct {
+		from, to State
+		counts   Counts
+	}
+	var transitions []transition
+	onStateChange := func(from, to State, counts Counts) {
+		transitions = append(transitions, transition{from, to, counts})
+	}
+
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithOnStateChange(onStateChange))
+	assert.NoError(t, err)
+
+	// a new interval in the closed state is not a transition
+	b.now = now(1520100061)
+	b.Execute(func() error { return nil })
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return nil })
+
+	// after cooldown period
+	b.now = now(1520100182)
+	b.Execute(func() error { return nil })
+	b.Execute(func() error { return nil })
+
+	assert.Equal(t, []transition{
+		{StateClosed, StateOpen, Counts{State: StateClosed, Total: 2, Failures: 1, Successes: 1, WindowStart: time.Unix(1520100061, 0),
+			ConsecutiveFailures: 1, LifetimeTotal: 2, LifetimeFailures: 1, Transitions: 1}},
+		{StateOpen, StateHalfOpen, Counts{State: StateOpen, Rejections: 1, WindowStart: time.Unix(1520100061, 0),
+			LifetimeTotal: 2, LifetimeFailures: 1, LifetimeRejections: 1, Transitions: 2}},
+		{StateHalfOpen, StateClosed, Counts{State: StateHalfOpen, Total: 1, Successes: 1, WindowStart: time.Unix(1520100182, 0),
+			LifetimeTotal: 3, LifetimeFailures: 1, LifetimeRejections: 1, Transitions: 3}},
+	}, transitions)
+}
@@ -0,0 +1,203 @@
+package circuit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Status is a snapshot of the circuit breaker for the logs, admin APIs and stores.
+//
+// It's marshalled into JSON as:
+//
+//	{
+//	  "name": "payments",
+//	  "state": "open",
+//	  "override": "none",
+//	  "disabled": false,
+//	  "until": "2018-03-03T18:01:00Z",
+//	  "counts": {
+//	    "total": 10, "failures": 6, "successes": 4, "rejections": 0, "slowCalls": 0,
+//	    "windowStart": "2018-03-03T18:00:00Z", "consecutiveFailures": 2, "failureScore": 6,
+//	    "latency": {"p50": "12ms", "p95": "80ms", "p99": "1.2s"},
+//	    "failuresByCategory": {"timeout": 4, "unavailable": 2},
+//	    "lifetimeTotal": 120, "lifetimeFailures": 9, "lifetimeRejections": 3, "lifetimeBypasses": 1,
+//	    "transitions": 2
+//	  },
+//	  "config": {"interval": "1m0s", "cooldown": "10s", "atLeastReqs": 1}
+//	}
+//
+// The states and overrides are named as by their String methods,
+// the durations are formatted as by time.Duration's String.
+// The failures by category are left out unless counted with WithFailureCategories.
+// The policies of the configuration are left out of the snapshot.
+type Status struct {
+	Name     string
+	State    State
+	Override Override
+	Disabled bool
+	Until    time.Time // end of the current interval, cooldown or half-open period
+	Counts   Counts
+	Config   Config
+}
+
+// Status returns a snapshot of the circuit breaker.
+func (b *Breaker) Status() Status {
+	counts := b.Counts()
+	return Status{
+		Name:     b.name,
+		State:    counts.State,
+		Override: counts.Override,
+		Disabled: b.Disabled(),
+		Until:    time.Unix(0, atomic.LoadInt64(&b.until)),
+		Counts:   counts,
+		Config:   b.Config().withoutPolicies(),
+	}
+}
+
+type statusJSON struct {
+	Name     string     `json:"name"`
+	State    string     `json:"state"`
+	Override string     `json:"override"`
+	Disabled bool       `json:"disabled"`
+	Until    time.Time  `json:"until"`
+	Counts   countsJSON `json:"counts"`
+	Config   configJSON `json:"config"`
+}
+
+type countsJSON struct {
+	Total               uint32            `json:"total"`
+	Failures            uint32            `json:"failures"`
+	Successes           uint32            `json:"successes"`
+	Rejections          uint32            `json:"rejections"`
+	SlowCalls           uint32            `json:"slowCalls"`
+	WindowStart         time.Time         `json:"windowStart"`
+	ConsecutiveFailures uint32            `json:"consecutiveFailures"`
+	FailureScore        uint32            `json:"failureScore"`
+	Latency             latencyJSON       `json:"latency"`
+	FailuresByCategory  map[string]uint32 `json:"failuresByCategory,omitempty"`
+	LifetimeTotal       uint64            `json:"lifetimeTotal"`
+	LifetimeFailures    uint64            `json:"lifetimeFailures"`
+	LifetimeRejections  uint64            `json:"lifetimeRejections"`
+	LifetimeBypasses    uint64            `json:"lifetimeBypasses"`
+	Transitions         uint64            `json:"transitions"`
+}
+
+type latencyJSON struct {
+	P50 string `json:"p50"`
+	P95 string `json:"p95"`
+	P99 string `json:"p99"`
+}
+
+type configJSON struct {
+	Interval    string `json:"interval"`
+	Cooldown    string `json:"cooldown"`
+	AtLeastReqs uint32 `json:"atLeastReqs"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s Status) MarshalJSON() ([]byte, error) {
+	c := s.Counts
+	return json.Marshal(statusJSON{
+		Name:     s.Name,
+		State:    s.State.String(),
+		Override: s.Override.String(),
+		Disabled: s.Disabled,
+		Until:    s.Until,
+		Counts: countsJSON{
+			Total:               c.Total,
+			Failures:            c.Failures,
+			Successes:           c.Successes,
+			Rejections:          c.Rejections,
+			SlowCalls:           c.SlowCalls,
+			WindowStart:         c.WindowStart,
+			ConsecutiveFailures: c.ConsecutiveFailures,
+			FailureScore:        c.FailureScore,
+			Latency: latencyJSON{
+				P50: c.Latency.P50.String(),
+				P95: c.Latency.P95.String(),
+				P99: c.Latency.P99.String(),
+			},
+			FailuresByCategory: c.FailuresByCategory,
+			LifetimeTotal:      c.LifetimeTotal,
+			LifetimeFailures:   c.LifetimeFailures,
+			LifetimeRejections: c.LifetimeRejections,
+			LifetimeBypasses:   c.LifetimeBypasses,
+			Transitions:        c.Transitions,
+		},
+		Config: configJSON{
+			Interval:    s.Config.Interval.String(),
+			Cooldown:    s.Config.Cooldown.String(),
+			AtLeastReqs: s.Config.AtLeastReqs,
+		},
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var v statusJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	state, ok := parseState(v.State)
+	if !ok {
+		return fmt.Errorf("circuit: unknown state %q", v.State)
+	}
+	override, ok := parseOverride(v.Override)
+	if !ok {
+		return fmt.Errorf("circuit: unknown override %q", v.Override)
+	}
+
+	var latency Latency
+	var interval, cooldown time.Duration
+	for _, d := range []struct {
+		dst *time.Duration
+		src string
+	}{
+		{&latency.P50, v.Counts.Latency.P50},
+		{&latency.P95, v.Counts.Latency.P95},
+		{&latency.P99, v.Counts.Latency.P99},
+		{&interval, v.Config.Interval},
+		{&cooldown, v.Config.Cooldown},
+	} {
+		if d.src == "" {
+			continue
+		}
+		var err error
+		if *d.dst, err = time.ParseDuration(d.src); err != nil {
+			return fmt.Errorf("circuit: invalid duration: %w", err)
+		}
+	}
+
+	c := v.Counts
+	*s = Status{
+		Name:     v.Name,
+		State:    state,
+		Override: override,
+		Disabled: v.Disabled,
+		Until:    v.Until,
+		Counts: Counts{
+			State:               state,
+			Override:            override,
+			Total:               c.Total,
+			Failures:            c.Failures,
+			Successes:           c.Successes,
+			Rejections:          c.Rejections,
+			SlowCalls:           c.SlowCalls,
+			WindowStart:         c.WindowStart,
+			ConsecutiveFailures: c.ConsecutiveFailures,
+			FailureScore:        c.FailureScore,
+			Latency:             latency,
+			FailuresByCategory:  c.FailuresByCategory,
+			LifetimeTotal:       c.LifetimeTotal,
+			LifetimeFailures:    c.LifetimeFailures,
+			LifetimeRejections:  c.LifetimeRejections,
+			LifetimeBypasses:    c.LifetimeBypasses,
+			Transitions:         c.Transitions,
+		},
+		Config: Config{Interval: interval, Cooldown: cooldown, AtLeastReqs: v.Config.AtLeastReqs},
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package circuit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_Status(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, 10*time.Second, 1, toOpen, toClosed, now(1520100000), WithName("payments"))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return nil })
+
+	s := b.Status()
+	assert.Equal(t, "payments", s.Name)
+	assert.Equal(t, StateOpen, s.State)
+	assert.Equal(t, time.Unix(1520100010, 0), s.Until)
+	assert.Equal(t, uint32(1), s.Counts.Rejections)
+	assert.Equal(t, Config{Interval: time.Minute, Cooldown: 10 * time.Second, AtLeastReqs: 1}, s.Config)
+}
+
+func TestStatus_JSON(t *testing.T) {
+	s := Status{
+		Name:     "payments",
+		State:    StateOpen,
+		Override: OverrideOpen,
+		Until:    time.Unix(1520100060, 0).UTC(),
+		Counts: Counts{
+			State:       StateOpen,
+			Override:    OverrideOpen,
+			Total:       10,
+			Failures:    6,
+			Successes:   4,
+			WindowStart: time.Unix(1520100000, 0).UTC(),
+			Latency:     Latency{P50: 12 * time.Millisecond, P95: 80 * time.Millisecond, P99: 1200 * time.Millisecond},
+			Transitions: 2,
+
+			FailureScore:       9,
+			FailuresByCategory: map[string]uint32{"timeout": 4, "unavailable": 2},
+			LifetimeBypasses:   1,
+		},
+		Config: Config{Interval: time.Minute, Cooldown: 10 * time.Second, AtLeastReqs: 1},
+	}
+
+	data, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "payments",
+		"state": "open",
+		"override": "forced-open",
+		"disabled": false,
+		"until": "2018-03-03T18:01:00Z",
+		"counts": {
+			"total": 10, "failures": 6, "successes": 4, "rejections": 0, "slowCalls": 0,
+			"windowStart": "2018-03-03T18:00:00Z", "consecutiveFailures": 0, "failureScore": 9,
+			"latency": {"p50": "12ms", "p95": "80ms", "p99": "1.2s"},
+			"failuresByCategory": {"timeout": 4, "unavailable": 2},
+			"lifetimeTotal": 0, "lifetimeFailures": 0, "lifetimeRejections": 0, "lifetimeBypasses": 1,
+			"transitions": 2
+		},
+		"config": {"interval": "1m0s", "cooldown": "10s", "atLeastReqs": 1}
+	}`, string(data))
+
+	var decoded Status
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, s, decoded)
+
+	assert.EqualError(t, json.Unmarshal([]byte(`{"state":"ajar"}`), &decoded), `circuit: unknown state "ajar"`)
+	assert.Error(t, json.Unmarshal([]byte(`{"state":"open","config":{"interval":"soon"}}`), &decoded))
+}
@@ -0,0 +1,310 @@
+package circuit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StoredState is the state of a circuit breaker kept in a Storage.
+type StoredState struct {
+	State   State     // current state
+	Until   time.Time // end of the current period
+	Version uint64    // incremented on every change, zero if never stored
+}
+
+// Storage keeps the state of the circuit breakers by name,
+// shared by the instances of an application, e.g. in Redis or DynamoDB.
+//
+// The circuit breaker keeps counting and deciding locally,
+// it publishes its transitions with CompareAndSetState,
+// adopts the ones of the other instances loaded with LoadState,
+// and reports the outcomes of the requests with RecordOutcome.
+type Storage interface {
+	// LoadState returns the state stored for a given name,
+	// the zero StoredState if there is none.
+	LoadState(ctx context.Context, name string) (StoredState, error)
+
+	// RecordOutcome records the outcome of a request for a given name.
+	RecordOutcome(ctx context.Context, name string, failed bool) error
+
+	// CompareAndSetState stores the new state for a given name
+	// if the stored one has the version of old, reports whether it did.
+	CompareAndSetState(ctx context.Context, name string, old, new StoredState) (bool, error)
+}
+
+// OutcomesRecorder is implemented by the storages recording the outcomes of the requests
+// in batches, e.g. with one increment per batch, it's used instead of Storage.RecordOutcome.
+type OutcomesRecorder interface {
+	// RecordOutcomes records a batch of the outcomes for a given name,
+	// total requests of which failures failed.
+	RecordOutcomes(ctx context.Context, name string, total, failures uint64) error
+}
+
+const (
+	// defaultStorageTimeout is the max duration of a call of the storage unless set with WithStorageTimeout.
+	defaultStorageTimeout = 100 * time.Millisecond
+	// defaultStorageSyncInterval is the min interval between the loads of the state
+	// unless set with WithStorageSyncInterval.
+	defaultStorageSyncInterval = time.Second
+)
+
+// WithStorage makes the circuit breaker share its state through a given storage
+// with the other circuit breakers of the same name, see Storage.
+//
+// The state is loaded in the background at most once per WithStorageSyncInterval,
+// the transitions are published and the outcomes are recorded in batches, see OutcomesRecorder,
+// in the background as well, the requests never wait for the storage.
+// The calls of the storage are bounded by WithStorageTimeout, their errors
+// are logged with WithLogger and the circuit breaker keeps working locally.
+func WithStorage(s Storage) Option {
+	return func(b *Breaker) {
+		b.storage = s
+	}
+}
+
+// WithStorageTimeout sets the max duration of a call of the storage, 100ms by default.
+func WithStorageTimeout(d time.Duration) Option {
+	return func(b *Breaker) {
+		b.storageTimeout = d
+	}
+}
+
+// WithStorageSyncInterval sets the min interval between the loads of the state from the storage,
+// a second by default.
+func WithStorageSyncInterval(d time.Duration) Option {
+	return func(b *Breaker) {
+		b.storageSyncInterval = d
+	}
+}
+
+// storageContext returns a context bounding a call of the storage.
+func (b *Breaker) storageContext() (context.Context, context.CancelFunc) {
+	d := b.storageTimeout
+	if d <= 0 {
+		d = defaultStorageTimeout
+	}
+	return context.WithTimeout(context.Background(), d)
+}
+
+// syncState loads the state from the storage in the background
+// unless it was loaded within the sync interval or is being loaded already.
+func (b *Breaker) syncState() {
+	now := b.nanotime()
+	if now < atomic.LoadInt64(&b.nextSync) || !atomic.CompareAndSwapInt32(&b.syncing, 0, 1) {
+		return
+	}
+
+	d := b.storageSyncInterval
+	if d <= 0 {
+		d = defaultStorageSyncInterval
+	}
+	atomic.StoreInt64(&b.nextSync, now+int64(d))
+	go b.adoptState()
+}
+
+// adoptState adopts the state stored by another instance if it's newer.
+func (b *Breaker) adoptState() {
+	defer atomic.StoreInt32(&b.syncing, 0)
+
+	ctx, cancel := b.storageContext()
+	st, err := b.storage.LoadState(ctx, b.name)
+	cancel()
+	if err != nil {
+		b.logStorageError(err)
+		return
+	}
+
+	b.storedMu.Lock()
+	newer := st.Version > b.stored.Version
+	b.storedMu.Unlock()
+	if !newer {
+		return
+	}
+
+	// any state changes are done based on CompareAndSwap(word)
+	w := atomic.LoadUint64(&b.word)
+	if State(stateOf(w)) != st.State && !b.transition(int32(st.State), w, b.nanotime(), st.Until.UnixNano(), false) {
+		// adopted by the next load
+		return
+	}
+
+	b.storedMu.Lock()
+	if st.Version > b.stored.Version {
+		b.stored = st
+	}
+	b.storedMu.Unlock()
+}
+
+// publishState stores a new state of the circuit breaker until next in the background,
+// only the latest one if it changes again meanwhile.
+// It's adopted from the storage on the next load if another instance did it first.
+func (b *Breaker) publishState(state int32, next int64) {
+	b.storedMu.Lock()
+	b.publish = &StoredState{State: State(state), Until: time.Unix(0, next)}
+	publishing := b.publishing
+	b.publishing = true
+	b.storedMu.Unlock()
+
+	if !publishing {
+		go b.publishStates()
+	}
+}
+
+// publishStates publishes the states set by publishState until there are none.
+func (b *Breaker) publishStates() {
+	for {
+		b.storedMu.Lock()
+		st := b.publish
+		b.publish = nil
+		b.publishing = st != nil
+		old := b.stored
+		b.storedMu.Unlock()
+		if st == nil {
+			return
+		}
+
+		st.Version = old.Version + 1
+		ctx, cancel := b.storageContext()
+		ok, err := b.storage.CompareAndSetState(ctx, b.name, old, *st)
+		cancel()
+		if err != nil {
+			b.logStorageError(err)
+			continue
+		}
+
+		if ok {
+			b.storedMu.Lock()
+			if st.Version > b.stored.Version {
+				b.stored = *st
+			}
+			b.storedMu.Unlock()
+		}
+	}
+}
+
+// recordOutcome adds the outcome of a request to the batch recorded in the background.
+func (b *Breaker) recordOutcome(failed bool) {
+	if b.storage == nil {
+		return
+	}
+
+	// the total first, so a batch never has more failures than requests
+	atomic.AddUint64(&b.pendingTotal, 1)
+	if failed {
+		atomic.AddUint64(&b.pendingFailures, 1)
+	}
+	if atomic.CompareAndSwapInt32(&b.flushing, 0, 1) {
+		go b.flushOutcomes()
+	}
+}
+
+// flushOutcomes records the pending outcomes to the storage until there are none.
+func (b *Breaker) flushOutcomes() {
+	for {
+		// the total first, the failures added since belong to the requests of the next batch
+		total := atomic.SwapUint64(&b.pendingTotal, 0)
+		failures := atomic.SwapUint64(&b.pendingFailures, 0)
+		if failures > total {
+			atomic.AddUint64(&b.pendingFailures, failures-total)
+			failures = total
+		}
+		if total > 0 {
+			if err := b.recordOutcomes(total, failures); err != nil {
+				b.logStorageError(err)
+			}
+		}
+
+		atomic.StoreInt32(&b.flushing, 0)
+		if atomic.LoadUint64(&b.pendingTotal) == 0 || !atomic.CompareAndSwapInt32(&b.flushing, 0, 1) {
+			return
+		}
+	}
+}
+
+func (b *Breaker) recordOutcomes(total, failures uint64) error {
+	ctx, cancel := b.storageContext()
+	defer cancel()
+
+	if r, ok := b.storage.(OutcomesRecorder); ok {
+		return r.RecordOutcomes(ctx, b.name, total, failures)
+	}
+	for i := uint64(0); i < total; i++ {
+		if err := b.storage.RecordOutcome(ctx, b.name, i < failures); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemoryStorage is a Storage in the memory,
+// e.g. shared by the circuit breakers of a process or in tests.
+type MemoryStorage struct {
+	mu       sync.Mutex
+	states   map[string]StoredState
+	outcomes map[string][2]uint64 // # of requests in total and failed
+}
+
+// NewMemoryStorage returns a new empty storage in the memory.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		states:   make(map[string]StoredState),
+		outcomes: make(map[string][2]uint64),
+	}
+}
+
+// LoadState implements Storage.
+func (s *MemoryStorage) LoadState(_ context.Context, name string) (StoredState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[name], nil
+}
+
+// RecordOutcome implements Storage.
+func (s *MemoryStorage) RecordOutcome(_ context.Context, name string, failed bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.outcomes[name]
+	o[0]++
+	if failed {
+		o[1]++
+	}
+	s.outcomes[name] = o
+	return nil
+}
+
+// RecordOutcomes implements OutcomesRecorder.
+func (s *MemoryStorage) RecordOutcomes(_ context.Context, name string, total, failures uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.outcomes[name]
+	o[0] += total
+	o[1] += failures
+	s.outcomes[name] = o
+	return nil
+}
+
+// CompareAndSetState implements Storage.
+func (s *MemoryStorage) CompareAndSetState(_ context.Context, name string, old, new StoredState) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.states[name].Version != old.Version {
+		return false, nil
+	}
+	s.states[name] = new
+	return true, nil
+}
+
+// Outcomes returns the number of the requests recorded for a given name
+// in total and failed.
+func (s *MemoryStorage) Outcomes(name string) (total uint64, failures uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.outcomes[name]
+	return o[0], o[1]
+}
@@ -0,0 +1,159 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	s := NewMemoryStorage()
+	ctx := context.Background()
+
+	st, err := s.LoadState(ctx, "payments")
+	assert.NoError(t, err)
+	assert.Equal(t, StoredState{}, st)
+
+	opened := StoredState{State: StateOpen, Until: time.Unix(1520100060, 0), Version: 1}
+	ok, err := s.CompareAndSetState(ctx, "payments", st, opened)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// a stale version
+	ok, err = s.CompareAndSetState(ctx, "payments", st, StoredState{State: StateClosed, Version: 1})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	st, _ = s.LoadState(ctx, "payments")
+	assert.Equal(t, opened, st)
+
+	s.RecordOutcome(ctx, "payments", true)
+	s.RecordOutcome(ctx, "payments", false)
+	total, failures := s.Outcomes("payments")
+	assert.Equal(t, uint64(2), total)
+	assert.Equal(t, uint64(1), failures)
+}
+
+// waitStorage waits for the calls of the storage in the background to end.
+func waitStorage(t *testing.T, b *Breaker) {
+	assert.Eventually(t, func() bool {
+		b.storedMu.Lock()
+		defer b.storedMu.Unlock()
+		return atomic.LoadInt32(&b.syncing) == 0 && !b.publishing
+	}, time.Second, time.Millisecond)
+}
+
+func TestBreaker_WithStorage(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	s := NewMemoryStorage()
+
+	b1, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithName("payments"), WithStorage(s))
+	assert.NoError(t, err)
+	b2, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithName("payments"), WithStorage(s))
+	assert.NoError(t, err)
+
+	assert.NoError(t, b2.Execute(func() error { return nil }))
+	waitStorage(t, b2)
+
+	// opened by the first one, adopted by the second one on its next load
+	b1.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b1.State())
+	waitStorage(t, b1)
+	assert.NoError(t, b2.Execute(func() error { return nil }), "loaded at most once per second")
+	waitStorage(t, b2)
+	b2.now = now(1520100001)
+	b2.syncState()
+	waitStorage(t, b2)
+	assert.ErrorIs(t, b2.Execute(func() error { return nil }), ErrBreakerOpen)
+	assert.Equal(t, StateOpen, b2.State())
+	assert.Equal(t, b1.until, b2.until)
+
+	// half-open by the second one
+	b2.now = now(1520100061)
+	assert.NoError(t, b2.Execute(func() error { return nil }))
+	assert.Equal(t, StateHalfOpen, b2.State())
+	waitStorage(t, b2)
+	b1.now = now(1520100061)
+	b1.syncState()
+	waitStorage(t, b1)
+	assert.Equal(t, StateHalfOpen, b1.State())
+
+	st, _ := s.LoadState(context.Background(), "payments")
+	assert.Equal(t, StateHalfOpen, st.State)
+	assert.Equal(t, uint64(2), st.Version)
+
+	assert.Eventually(t, func() bool {
+		total, failures := s.Outcomes("payments")
+		return total == 4 && failures == 1
+	}, time.Second, time.Millisecond, "recorded in the background")
+}
+
+func TestBreaker_flushOutcomes(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	s := NewMemoryStorage()
+	b, err := NewBreaker(time.Minute, time.Minute, 1, to, to, WithName("payments"), WithStorage(s))
+	assert.NoError(t, err)
+
+	// a failure added after its total was flushed
+	b.pendingTotal, b.pendingFailures, b.flushing = 1, 2, 1
+	b.flushOutcomes()
+
+	total, failures := s.Outcomes("payments")
+	assert.Equal(t, uint64(1), total)
+	assert.Equal(t, uint64(1), failures)
+	assert.Equal(t, uint64(1), b.pendingFailures, "carried to the next batch")
+}
+
+type slowStorage struct {
+	Storage
+}
+
+func (s slowStorage) LoadState(ctx context.Context, name string) (StoredState, error) {
+	<-ctx.Done()
+	return StoredState{}, ctx.Err()
+}
+
+func (s slowStorage) CompareAndSetState(ctx context.Context, name string, old, new StoredState) (bool, error) {
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+func TestBreaker_WithStorageTimeout(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	s := NewMemoryStorage()
+	b, err := NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed, WithName("payments"),
+		WithStorage(slowStorage{s}), WithStorageTimeout(10*time.Millisecond))
+	assert.NoError(t, err)
+
+	start := time.Now()
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	assert.Less(t, time.Since(start), time.Second, "bounded by the timeout")
+
+	// recorded one by one without OutcomesRecorder
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Eventually(t, func() bool {
+		total, failures := s.Outcomes("payments")
+		return total == 2 && failures == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestBreaker_WithStorage_Background(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed, WithName("payments"),
+		WithStorage(slowStorage{NewMemoryStorage()}), WithStorageTimeout(200*time.Millisecond))
+	assert.NoError(t, err)
+
+	start := time.Now()
+	assert.NoError(t, b.Execute(func() error { return nil }))
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, StateOpen, b.State())
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "neither the load nor the publish waited for")
+	waitStorage(t, b)
+}
@@ -0,0 +1,63 @@
+package circuit
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrExecutionTimeout is returned when a request didn't return
+// within the timeout set with WithExecutionTimeout.
+var ErrExecutionTimeout = errors.New("circuit: execution timeout")
+
+// WithExecutionTimeout makes the circuit breaker stop waiting for a request
+// after a given duration and return ErrExecutionTimeout, counted as a failure.
+//
+// The request keeps running in the background as it can't be stopped,
+// ExecuteContext passes it a context cancelled on the timeout.
+func WithExecutionTimeout(d time.Duration) Option {
+	return func(b *Breaker) {
+		b.executionTimeout = d
+	}
+}
+
+// timed returns a given request limited by the execution timeout if it's set.
+func (b *Breaker) timed(req func() error) func() error {
+	if b.executionTimeout == 0 {
+		return req
+	}
+
+	return func() error {
+		_, err := runTimed(b.after, b.executionTimeout, func() (struct{}, error) {
+			return struct{}{}, req()
+		})
+		return err
+	}
+}
+
+// runTimed runs a given request in a new goroutine and returns its result,
+// or ErrExecutionTimeout once d elapsed by after, a panic is returned as *PanicError.
+func runTimed[T any](after func(time.Duration) <-chan time.Time, d time.Duration, req func() (T, error)) (T, error) {
+	type result struct {
+		v   T
+		err error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		r.err = guard(func() error {
+			var err error
+			r.v, err = req()
+			return err
+		})
+		done <- r
+	}()
+
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-after(d):
+		var zero T
+		return zero, ErrExecutionTimeout
+	}
+}
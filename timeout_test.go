@@ -0,0 +1,59 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithExecutionTimeout(t *testing.T) {
+	toOpen := func(uint32, uint32) bool { return false }
+	toClosed := func(uint32, uint32) bool { return true }
+	isFailure := func(error) bool { return false }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000),
+		WithExecutionTimeout(10*time.Millisecond), WithFailureClassifier(isFailure))
+	assert.NoError(t, err)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	err = b.Execute(func() error { <-release; return nil })
+	assert.Equal(t, ErrExecutionTimeout, err)
+
+	err = b.Execute(func() error { return errors.New("failed") })
+	assert.EqualError(t, err, "failed")
+
+	// the context is cancelled on the timeout
+	err = b.ExecuteContext(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		<-release
+		return ctx.Err()
+	})
+	assert.Equal(t, ErrExecutionTimeout, err)
+
+	v, err := Do(b, func() (int, error) { <-release; return 1, nil })
+	assert.Equal(t, ErrExecutionTimeout, err)
+	assert.Equal(t, 0, v)
+
+	v, err = Do(b, func() (int, error) { return 1, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	counts := b.Counts()
+	assert.Equal(t, uint32(3), counts.Failures)
+	assert.Equal(t, uint32(2), counts.Successes)
+}
+
+func TestBreaker_WithExecutionTimeout_Panic(t *testing.T) {
+	to := func(uint32, uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, to, to, now(1520100000), WithExecutionTimeout(time.Second))
+	assert.NoError(t, err)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		b.Execute(func() error { panic("boom") })
+	})
+	assert.Equal(t, uint32(1), b.Counts().Failures)
+}
@@ -0,0 +1,114 @@
+package circuit
+
+// Token is a permission to run a request given by Allow,
+// the outcome of the request is recorded later with Success, Failure or Ignore.
+type Token struct {
+	b     *Breaker
+	gen   uint64 // generation of the period the request was accepted in
+	start int64  // start timestamp of the request if the latency is tracked
+	probe bool   // whether the request is a probe of the half-open state
+}
+
+// Allow reports whether the circuit breaker accepts a request,
+// for the operations which can't be wrapped in a closure,
+// e.g. async callbacks or streaming writes.
+//
+// Returns ErrBreakerOpen when it doesn't accept the request,
+// otherwise a token to record the outcome of the request with,
+// exactly one of Success, Failure or Ignore must be called.
+//
+// In the shadow mode a request which would be rejected gets a token
+// discarding its outcome instead of ErrBreakerOpen.
+func (b *Breaker) Allow() (Token, error) {
+	b.injectOpen()
+	ok, probe := b.ready()
+	if !ok {
+		b.reject(nil)
+		if !b.shadow {
+			return Token{}, b.openError()
+		}
+		return Token{}, nil
+	}
+
+	gen, start := b.accept(nil)
+	return Token{b: b, gen: gen, start: start, probe: probe}, nil
+}
+
+// Observe records the outcome of a request made without asking the circuit breaker,
+// e.g. a message already delivered to a consumer, as if it was accepted.
+//
+// The outcome is dropped when the circuit breaker wouldn't accept the request,
+// e.g. over the limit of the probes in the half-open state, but it's not counted as a rejection,
+// the request reached the dependency anyway.
+func (b *Breaker) Observe(o Outcome) {
+	ok, probe := b.ready()
+	if !ok {
+		return
+	}
+
+	gen, start := b.accept(nil)
+	b.release(probe)
+	b.settle(gen, start, o, nil, nil)
+}
+
+// Success records a successful outcome of the request.
+//
+// The outcome is discarded if the counters were reset
+// since the request had been accepted, as it belongs to the previous period.
+func (t Token) Success() {
+	if t.b == nil {
+		return
+	}
+	t.b.release(t.probe)
+	t.b.finish(t.gen, t.start, false, nil, nil)
+}
+
+// Failure records a failed outcome of the request.
+//
+// The outcome is discarded if the counters were reset
+// since the request had been accepted, as it belongs to the previous period,
+// only the lifetime failures count it.
+func (t Token) Failure() {
+	if t.b == nil {
+		return
+	}
+	t.b.release(t.probe)
+	t.b.finish(t.gen, t.start, true, nil, nil)
+}
+
+// Critical records a failed outcome of the request which opens the circuit breaker
+// immediately regardless of the thresholds, e.g. a connection refused.
+//
+// The outcome is discarded like the one of Failure if the counters were reset
+// since the request had been accepted, then it doesn't open the circuit breaker either.
+func (t Token) Critical() {
+	if t.b == nil {
+		return
+	}
+	t.b.release(t.probe)
+	t.b.finish(t.gen, t.start, true, nil, nil)
+	t.b.tripCritical(t.gen)
+}
+
+// Ignore doesn't count the request at all, as if it was never accepted.
+func (t Token) Ignore() {
+	if t.b == nil {
+		return
+	}
+	t.b.release(t.probe)
+	t.b.ignore(t.gen)
+}
+
+// Record records a given outcome of the request, e.g. decided by a classifier.
+func (t Token) Record(o Outcome) {
+	switch o {
+	case OutcomeSuccess:
+		t.Success()
+	case OutcomeFailure:
+		t.Failure()
+	case OutcomeIgnored:
+		t.Ignore()
+	case OutcomeCritical:
+		t.Critical()
+	}
+}
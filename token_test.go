@@ -0,0 +1,126 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_Allow(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	tok1, err := b.Allow()
+	assert.NoError(t, err)
+	tok2, err := b.Allow()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), b.total)
+
+	tok1.Success()
+	tok2.Failure()
+	assert.Equal(t, uint64(1), b.successes)
+	assert.Equal(t, uint64(1), b.failures)
+	assert.Equal(t, closed, b.loadState())
+
+	tok3, err := b.Allow()
+	assert.NoError(t, err)
+	tok3.Failure()
+	assert.Equal(t, open, b.loadState())
+
+	tok4, err := b.Allow()
+	assert.ErrorIs(t, err, ErrBreakerOpen)
+	assert.Equal(t, uint32(1), b.rejections)
+
+	// a token of the rejected request is a no-op
+	tok4.Failure()
+	tok4.Success()
+	assert.Equal(t, uint64(0), b.failures)
+	assert.Equal(t, uint64(0), b.successes)
+}
+
+func TestToken_PreviousPeriod(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	tok, err := b.Allow()
+	assert.NoError(t, err)
+
+	// passed interval period, the counters are reset
+	b.now = now(1520100061)
+	_, err = b.Allow()
+	assert.NoError(t, err)
+
+	// the outcome belongs to the previous interval
+	tok.Failure()
+	assert.Equal(t, uint64(0), b.failures)
+	assert.Equal(t, uint64(1), b.total)
+	assert.Equal(t, closed, b.loadState())
+}
+
+func TestToken_Ignore(t *testing.T) {
+	to := func(total uint32, failures uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, to, to, now(1520100000))
+	assert.NoError(t, err)
+
+	tok, err := b.Allow()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), b.total)
+
+	tok.Ignore()
+	assert.Equal(t, uint64(0), b.total)
+	assert.Equal(t, uint64(0), b.successes)
+	assert.Equal(t, uint64(0), b.failures)
+}
+
+func BenchmarkBreaker_Allow(b *testing.B) {
+	toOpen := func(total uint32, failures uint32) bool { return false }
+	toClosed := func(total uint32, failures uint32) bool { return true }
+	cb, err := NewBreaker(time.Minute, time.Minute, 1, toOpen, toClosed)
+	assert.NoError(b, err)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			tok, _ := cb.Allow()
+			tok.Success()
+		}
+	})
+}
+
+func TestBreaker_Observe(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(total uint32, failures uint32) bool { return failures == 0 }
+	b, err := withTimeNow(time.Minute, time.Minute, 2, toOpen, toClosed, now(1520100000), WithMaxHalfOpenProbes(1))
+	assert.NoError(t, err)
+
+	b.Observe(OutcomeSuccess)
+	b.Observe(OutcomeIgnored)
+	assert.Equal(t, uint64(1), b.successes)
+	b.Observe(OutcomeFailure)
+	assert.Equal(t, open, b.loadState())
+
+	// dropped while open
+	b.Observe(OutcomeSuccess)
+	assert.Equal(t, uint64(0), b.successes)
+	assert.Equal(t, uint32(0), b.rejections)
+
+	// dropped over the limit of the probes
+	b.now = now(1520100061)
+	tok, err := b.Allow()
+	assert.NoError(t, err)
+	assert.Equal(t, halfOpen, b.loadState())
+	b.Observe(OutcomeSuccess)
+	assert.Equal(t, uint64(1), b.total)
+	assert.Equal(t, uint32(0), b.rejections)
+
+	tok.Success()
+	b.Observe(OutcomeSuccess)
+	assert.Equal(t, uint64(2), b.successes)
+	b.Observe(OutcomeSuccess)
+	assert.Equal(t, closed, b.loadState())
+}
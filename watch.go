@@ -0,0 +1,70 @@
+package circuit
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// watchBuffer is the capacity of a watch channel.
+const watchBuffer = 16
+
+// StateChange is a transition of the circuit breaker sent to the watchers.
+type StateChange struct {
+	From   State
+	To     State
+	Counts Counts    // counters of the finished period
+	At     time.Time // when the transition was made
+}
+
+type watchers struct {
+	n    int32 // # of the watchers, checked without locking
+	mu   sync.Mutex
+	subs map[chan StateChange]struct{}
+}
+
+// Watch returns a channel receiving every transition of the circuit breaker,
+// e.g. to pre-warm caches once it recovers,
+// and a function to stop watching which closes the channel.
+//
+// The channel is buffered, a transition is dropped for a watcher
+// which doesn't keep up receiving them.
+func (b *Breaker) Watch() (<-chan StateChange, func()) {
+	ch := make(chan StateChange, watchBuffer)
+
+	w := &b.watchers
+	w.mu.Lock()
+	if w.subs == nil {
+		w.subs = make(map[chan StateChange]struct{})
+	}
+	w.subs[ch] = struct{}{}
+	atomic.AddInt32(&w.n, 1)
+	w.mu.Unlock()
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			w.mu.Lock()
+			delete(w.subs, ch)
+			atomic.AddInt32(&w.n, -1)
+			close(ch)
+			w.mu.Unlock()
+		})
+	}
+	return ch, stop
+}
+
+func (w *watchers) watched() bool {
+	return atomic.LoadInt32(&w.n) > 0
+}
+
+func (w *watchers) send(c StateChange) {
+	w.mu.Lock()
+	for ch := range w.subs {
+		select {
+		case ch <- c:
+		default:
+		}
+	}
+	w.mu.Unlock()
+}
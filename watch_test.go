@@ -0,0 +1,67 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_Watch(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	ch1, stop1 := b.Watch()
+	ch2, stop2 := b.Watch()
+
+	b.Execute(func() error { return errors.New("failed") })
+
+	c := <-ch1
+	assert.Equal(t, StateClosed, c.From)
+	assert.Equal(t, StateOpen, c.To)
+	assert.Equal(t, uint32(1), c.Counts.Failures)
+	assert.Equal(t, time.Unix(1520100000, 0), c.At)
+	assert.Equal(t, c, <-ch2)
+
+	stop1()
+	stop1()
+	_, ok := <-ch1
+	assert.False(t, ok)
+
+	b.now = now(1520100121)
+	b.Execute(func() error { return nil })
+
+	c = <-ch2
+	assert.Equal(t, StateOpen, c.From)
+	assert.Equal(t, StateHalfOpen, c.To)
+
+	stop2()
+	_, ok = <-ch2
+	assert.False(t, ok)
+	assert.False(t, b.watchers.watched())
+}
+
+func TestBreaker_Watch_SlowWatcher(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 0 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000))
+	assert.NoError(t, err)
+
+	ch, stop := b.Watch()
+	defer stop()
+
+	// closed -> open -> half-open -> closed, 3 transitions per loop
+	sec := int64(1520100000)
+	for i := 0; i < watchBuffer; i++ {
+		b.Execute(func() error { return errors.New("failed") })
+		sec += 61
+		b.now = now(sec)
+		b.Execute(func() error { return nil })
+		b.Execute(func() error { return nil })
+	}
+
+	assert.Len(t, ch, watchBuffer)
+}
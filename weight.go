@@ -0,0 +1,41 @@
+package circuit
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// WithFailureWeight makes the policies see the weighted failures of the interval,
+// the sum of the weights of their errors returned by weight, instead of their number,
+// so the severe failure modes open the circuit breaker faster than the benign ones,
+// e.g. a timeout weighing 3 counts as three 500s weighing 1.
+//
+// The failures without an error, e.g. recorded with Token.Failure, and the zero weights weigh 1.
+// The weighted failures are exposed as Counts().FailureScore, they don't apply
+// to the outcomes of the requests kept by WithCountWindow or WithRollingWindow.
+func WithFailureWeight(weight func(err error) uint32) Option {
+	return func(b *Breaker) {
+		b.weight = weight
+	}
+}
+
+// addFailureScore adds the weight of a failure with err, nil if unknown.
+func (b *Breaker) addFailureScore(err error) {
+	w := uint32(1)
+	if err != nil {
+		if n := b.weight(err); n > 0 {
+			w = n
+		}
+	}
+	atomic.AddUint64(&b.failureScore, uint64(w))
+}
+
+// policyCounts returns the number of requests in total and failed during the interval
+// as the policies see them, the failures are weighted with WithFailureWeight.
+func (b *Breaker) policyCounts() (total uint32, failures uint32) {
+	total, failures, _ = b.counters()
+	if b.weight != nil {
+		failures = uint32(min(atomic.LoadUint64(&b.failureScore), math.MaxUint32))
+	}
+	return total, failures
+}
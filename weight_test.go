@@ -0,0 +1,44 @@
+package circuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithFailureWeight(t *testing.T) {
+	var got [2]uint32
+	toOpen := func(total uint32, failures uint32) bool {
+		got = [2]uint32{total, failures}
+		return failures >= 6
+	}
+	toClosed := func(total uint32, failures uint32) bool { return failures == 0 }
+	weight := func(err error) uint32 {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return 3
+		}
+		return 0
+	}
+	b, err := withTimeNow(time.Minute, 2*time.Minute, 1, toOpen, toClosed, now(1520100000), WithFailureWeight(weight))
+	assert.NoError(t, err)
+
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, [2]uint32{1, 1}, got)
+
+	tok, _ := b.Allow()
+	tok.Failure()
+	b.Execute(func() error { return context.DeadlineExceeded })
+	assert.Equal(t, [2]uint32{3, 5}, got)
+	counts := b.Counts()
+	assert.Equal(t, uint32(3), counts.Failures)
+	assert.Equal(t, uint32(5), counts.FailureScore)
+	assert.Equal(t, closed, b.loadState())
+
+	// trips faster than the number of failures would
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState())
+	assert.Zero(t, b.Counts().FailureScore)
+}
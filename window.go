@@ -0,0 +1,164 @@
+package circuit
+
+import "sync"
+
+// window aggregates the outcomes of the requests for the toOpen decision
+// instead of the counters of the fixed interval.
+// It's reset on every transition.
+type window interface {
+	record(now int64, failure bool)
+	counts(now int64) (total uint32, failures uint32)
+	reset(now int64)
+}
+
+// WithCountWindow makes the circuit breaker decide on opening
+// by the outcomes of the last n requests instead of the requests
+// during the interval, i.e. toOpen gets the counts of the last n requests.
+//
+// It suits bursty and low-traffic dependencies where a full interval
+// may see only a couple of requests. It's ignored if n is zero.
+func WithCountWindow(n uint32) Option {
+	return func(b *Breaker) {
+		if n > 0 {
+			b.window = newCountWindow(n)
+		}
+	}
+}
+
+// countWindow is a ring buffer of the outcomes of the last requests.
+type countWindow struct {
+	mu       sync.Mutex
+	outcomes []bool // true for a failure
+	next     int    // index of the next outcome
+	total    uint32 // # of recorded outcomes, up to the size
+	failures uint32
+}
+
+func newCountWindow(n uint32) *countWindow {
+	return &countWindow{outcomes: make([]bool, n)}
+}
+
+func (w *countWindow) record(_ int64, failure bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.total == uint32(len(w.outcomes)) {
+		// evict the oldest outcome
+		if w.outcomes[w.next] {
+			w.failures--
+		}
+	} else {
+		w.total++
+	}
+
+	w.outcomes[w.next] = failure
+	if failure {
+		w.failures++
+	}
+	w.next = (w.next + 1) % len(w.outcomes)
+}
+
+func (w *countWindow) counts(int64) (uint32, uint32) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.total, w.failures
+}
+
+func (w *countWindow) reset(int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range w.outcomes {
+		w.outcomes[i] = false
+	}
+	w.next, w.total, w.failures = 0, 0, 0
+}
+
+// WithRollingWindow makes the circuit breaker decide on opening
+// by the outcomes of the requests during the last interval
+// sliding continuously in n buckets, instead of the fixed interval
+// reset at its boundaries, so a spike of failures split across
+// the boundary isn't missed. It's ignored if n is zero.
+func WithRollingWindow(n uint32) Option {
+	return func(b *Breaker) {
+		if n > 0 {
+			b.window = newRollingWindow(b.interval, n)
+		}
+	}
+}
+
+// rollingWindow is a ring of the buckets counting the outcomes,
+// each bucket spans width nanoseconds.
+type rollingWindow struct {
+	mu      sync.Mutex
+	width   int64
+	buckets []bucket
+	head    int64 // # of the latest bucket since the epoch
+}
+
+type bucket struct {
+	total    uint32
+	failures uint32
+}
+
+func newRollingWindow(span int64, n uint32) *rollingWindow {
+	width := span / int64(n)
+	if width == 0 {
+		width = 1
+	}
+	return &rollingWindow{width: width, buckets: make([]bucket, n)}
+}
+
+func (w *rollingWindow) record(now int64, failure bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	bk := w.advance(now)
+	bk.total++
+	if failure {
+		bk.failures++
+	}
+}
+
+func (w *rollingWindow) counts(now int64) (total uint32, failures uint32) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.advance(now)
+	for _, bk := range w.buckets {
+		total += bk.total
+		failures += bk.failures
+	}
+	return total, failures
+}
+
+func (w *rollingWindow) reset(now int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+	w.head = now / w.width
+}
+
+// advance slides the window to now clearing the expired buckets,
+// returns the bucket of now.
+func (w *rollingWindow) advance(now int64) *bucket {
+	n := int64(len(w.buckets))
+	idx := now / w.width
+
+	if idx > w.head {
+		from := w.head + 1
+		if idx-w.head > n {
+			from = idx - n + 1
+		}
+		for i := from; i <= idx; i++ {
+			w.buckets[i%n] = bucket{}
+		}
+		w.head = idx
+	}
+
+	// a late outcome goes into the latest bucket
+	return &w.buckets[w.head%n]
+}
@@ -0,0 +1,113 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountWindow(t *testing.T) {
+	w := newCountWindow(3)
+
+	w.record(0, true)
+	w.record(0, false)
+	total, failures := w.counts(0)
+	assert.Equal(t, uint32(2), total)
+	assert.Equal(t, uint32(1), failures)
+
+	w.record(0, true)
+	w.record(0, false) // evicts the first failure
+	total, failures = w.counts(0)
+	assert.Equal(t, uint32(3), total)
+	assert.Equal(t, uint32(1), failures)
+
+	w.record(0, false)
+	w.record(0, false)
+	total, failures = w.counts(0)
+	assert.Equal(t, uint32(3), total)
+	assert.Equal(t, uint32(0), failures)
+
+	w.record(0, true)
+	w.reset(0)
+	total, failures = w.counts(0)
+	assert.Equal(t, uint32(0), total)
+	assert.Equal(t, uint32(0), failures)
+}
+
+func TestBreaker_WithCountWindow(t *testing.T) {
+	var seen [][2]uint32
+	toOpen := func(total uint32, failures uint32) bool {
+		seen = append(seen, [2]uint32{total, failures})
+		return total >= 4 && failures >= 2
+	}
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Second, time.Minute, 1, toOpen, toClosed, now(1520100000), WithCountWindow(4))
+	assert.NoError(t, err)
+
+	sec := int64(1520100000)
+	execute := func(err error) {
+		// every request in its own interval, the window spans them
+		sec += 2
+		b.now = now(sec)
+		b.Execute(func() error { return err })
+	}
+
+	execute(errors.New("failed"))
+	execute(nil)
+	execute(nil)
+	assert.Equal(t, closed, b.loadState())
+
+	execute(errors.New("failed"))
+	assert.Equal(t, open, b.loadState())
+	assert.Equal(t, [][2]uint32{{1, 1}, {4, 2}}, seen)
+
+	// the window is reset on the transition
+	total, failures := b.window.counts(0)
+	assert.Equal(t, uint32(0), total)
+	assert.Equal(t, uint32(0), failures)
+}
+
+func TestRollingWindow(t *testing.T) {
+	sec := int64(time.Second)
+	w := newRollingWindow(4*sec, 4)
+	w.reset(100 * sec)
+
+	w.record(100*sec, true)
+	w.record(101*sec, false)
+	w.record(103*sec, true)
+	total, failures := w.counts(103 * sec)
+	assert.Equal(t, uint32(3), total)
+	assert.Equal(t, uint32(2), failures)
+
+	// the first bucket slides out
+	total, failures = w.counts(104 * sec)
+	assert.Equal(t, uint32(2), total)
+	assert.Equal(t, uint32(1), failures)
+
+	w.record(105*sec, true)
+	total, failures = w.counts(105 * sec)
+	assert.Equal(t, uint32(2), total)
+	assert.Equal(t, uint32(2), failures)
+
+	// everything slides out
+	total, failures = w.counts(200 * sec)
+	assert.Equal(t, uint32(0), total)
+	assert.Equal(t, uint32(0), failures)
+}
+
+func TestBreaker_WithRollingWindow(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures >= 3 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithRollingWindow(6))
+	assert.NoError(t, err)
+
+	// a spike of failures split across the interval boundary
+	b.now = now(1520100050)
+	b.Execute(func() error { return errors.New("failed") })
+	b.Execute(func() error { return errors.New("failed") })
+	b.now = now(1520100061)
+	b.Execute(func() error { return errors.New("failed") })
+	assert.Equal(t, open, b.loadState())
+}
@@ -0,0 +1,56 @@
+package circuit
+
+import "fmt"
+
+// RequestError is an error returned by a request wrapped with the details
+// of the circuit breaker when WithErrorWrapping is used,
+// the original error is returned by errors.Unwrap.
+type RequestError struct {
+	Name        string  // name of the circuit breaker
+	State       State   // state of the circuit breaker once the outcome was recorded
+	FailureRate float64 // rate of the failed requests in the current interval or the window
+	Err         error   // error returned by the request
+}
+
+// Error returns the error of the request prefixed with the details of the circuit breaker.
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("circuit: breaker %q (%s, %.0f%% failed): %v", e.Name, e.State, e.FailureRate*100, e.Err)
+}
+
+// Unwrap returns the error returned by the request.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// WithErrorWrapping makes the circuit breaker wrap the errors returned by the requests
+// into *RequestError with its name, state and failure rate,
+// making it obvious in the logs which dependency and circuit breaker produced a failure.
+//
+// The rejections with ErrBreakerOpen are not wrapped.
+func WithErrorWrapping() Option {
+	return func(b *Breaker) {
+		b.wrapErrors = true
+	}
+}
+
+// wrap returns the error of a request wrapped if WithErrorWrapping is used.
+func (b *Breaker) wrap(err error) error {
+	if err == nil || !b.wrapErrors {
+		return err
+	}
+
+	var total, failures uint32
+	if b.window != nil {
+		total, failures = b.window.counts(b.nanotime())
+	} else {
+		var successes uint32
+		_, failures, successes = b.counters()
+		total = failures + successes
+	}
+
+	e := &RequestError{Name: b.name, State: State(b.loadState()), Err: err}
+	if total > 0 {
+		e.FailureRate = float64(failures) / float64(total)
+	}
+	return e
+}
@@ -0,0 +1,50 @@
+package circuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker_WithErrorWrapping(t *testing.T) {
+	toOpen := func(total uint32, failures uint32) bool { return failures > 1 }
+	toClosed := func(uint32, uint32) bool { return true }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, toOpen, toClosed, now(1520100000), WithName("payments"), WithErrorWrapping())
+	assert.NoError(t, err)
+
+	failed := errors.New("failed")
+	assert.NoError(t, b.Execute(func() error { return nil }))
+
+	err = b.Execute(func() error { return failed })
+	assert.EqualError(t, err, `circuit: breaker "payments" (closed, 50% failed): failed`)
+	assert.Equal(t, failed, errors.Unwrap(err))
+	assert.ErrorIs(t, err, failed)
+
+	var re *RequestError
+	assert.True(t, errors.As(err, &re))
+	assert.Equal(t, "payments", re.Name)
+	assert.Equal(t, StateClosed, re.State)
+	assert.InDelta(t, 0.5, re.FailureRate, 1e-9)
+
+	// the state once the outcome was recorded
+	_, err = Do(b, func() (int, error) { return 0, failed })
+	assert.EqualError(t, err, `circuit: breaker "payments" (open, 0% failed): failed`)
+
+	// the rejections are not wrapped
+	err = b.Execute(func() error { return nil })
+	assert.IsType(t, &OpenError{}, err)
+}
+
+func TestBreaker_WithErrorWrapping_Window(t *testing.T) {
+	never := func(uint32, uint32) bool { return false }
+	b, err := withTimeNow(time.Minute, time.Minute, 1, never, never, now(1520100000), WithName("payments"), WithErrorWrapping(), WithCountWindow(4))
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		b.Execute(func() error { return nil })
+	}
+	err = b.Execute(func() error { return errors.New("failed") })
+	assert.EqualError(t, err, `circuit: breaker "payments" (closed, 25% failed): failed`)
+}